@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -26,12 +27,34 @@ High-performance local graph database for MCP tools
 )
 
 func main() {
+	// Subcommands take their own flags
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "load-csv":
+			runLoadCSV(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
 		showHelp    = flag.Bool("help", false, "Show help information")
 		debug       = flag.Bool("debug", false, "Enable debug logging")
 		dbPath      = flag.String("db", "", "Database file path (optional, uses in-memory if not specified)")
 		dumpPath    = flag.String("dump", "", "Pretty print contents of database file and exit")
+		indexProps  = flag.String("index", "", "Comma-separated node property keys to index (persisted with -db)")
+		schemaPath  = flag.String("schema", "", "JSON schema file that add_node and add_edge enforce (persisted with -db)")
+		autoSave    = flag.Duration("autosave", 0, "Checkpoint interval for -db (e.g. 30s); 0 writes every change through")
+		storageKind = flag.String("storage", storage.BackendBolt, "Storage backend for -db and -dump: "+strings.Join(storage.BackendKinds, ", "))
+		listenAddr  = flag.String("listen", "", "Serve MCP over HTTP on this address (e.g. 127.0.0.1:7400) instead of stdio")
+		workers     = flag.Int("workers", mcp.DefaultWorkers, "Maximum number of stdio requests processed at once")
+		idleTimeout = flag.Duration("session-timeout", mcp.DefaultSessionIdleTimeout, "End -listen sessions idle this long; 0 keeps them until deleted")
 	)
 
 	flag.Parse()
@@ -47,7 +70,7 @@ func main() {
 	}
 
 	if *dumpPath != "" {
-		dumpDatabase(*dumpPath, *debug)
+		dumpDatabase(*dumpPath, *storageKind, *debug)
 		return
 	}
 
@@ -72,52 +95,126 @@ func main() {
 		cancel()
 	}()
 
+	// closeGraph writes a persistent graph's final checkpoint and closes it.
+	// log.Fatalf skips deferred calls, so fatal errors go through fatalf.
+	closeGraph := func() error { return nil }
+	fatalf := func(format string, args ...interface{}) {
+		if err := closeGraph(); err != nil {
+			log.Printf("Failed to close database: %v", err)
+		}
+		log.Fatalf(format, args...)
+	}
+
 	// Initialize the graph
 	var g graph.Graph
+	var indexer graph.PropertyIndexer
 	if *dbPath != "" {
-		// Initialize persistent graph with BoltDB backend
-		backend := storage.NewBoltBackend()
+		// Initialize persistent graph with the selected backend
+		backend, err := storage.NewBackend(*storageKind)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
 		if err := backend.Open(*dbPath); err != nil {
 			log.Fatalf("Failed to open database: %v", err)
 		}
-		defer backend.Close()
 
-		// Disable auto-save until SaveGraph is fully implemented
-		persistentGraph := storage.NewPersistentGraph(backend, false, 30*time.Second)
+		// With -autosave, changes stay in memory and are checkpointed periodically.
+		// A new database loads as an empty graph; any other load error is fatal,
+		// since saving would overwrite the database with the empty graph.
+		persistentGraph := storage.NewPersistentGraph(backend, *autoSave > 0, *autoSave)
 		if err := persistentGraph.Load(ctx); err != nil {
-			if *debug {
-				log.Printf("No existing database found, starting with empty graph: %v", err)
-			}
+			backend.Close()
+			log.Fatalf("Failed to load database: %v", err)
 		}
-		defer persistentGraph.Close()
+		// The persistent graph owns the backend and closes it
+		closeGraph = persistentGraph.Close
 
 		g = persistentGraph
+		indexer = persistentGraph
 		if *debug {
-			log.Printf("Using persistent graph storage at %s", *dbPath)
+			log.Printf("Using %s graph storage at %s", *storageKind, *dbPath)
+			if *autoSave > 0 {
+				log.Printf("Auto-saving every %s", *autoSave)
+			}
 		}
 	} else {
-		g = graph.NewMemoryGraph()
+		memGraph := graph.NewMemoryGraph()
+		g = memGraph
+		indexer = memGraph
 		if *debug {
 			log.Printf("Using in-memory graph storage")
 		}
 	}
 
-	// Create MCP handler
-	handler := mcp.NewStdioHandler(g, *debug)
+	// Declare property indexes requested on the command line
+	for _, key := range parseList(*indexProps) {
+		if err := indexer.CreatePropertyIndex(key); err != nil {
+			fatalf("Failed to create property index %s: %v", key, err)
+		}
+	}
+	if *debug && len(indexer.PropertyIndexes()) > 0 {
+		log.Printf("Property indexes: %s", strings.Join(indexer.PropertyIndexes(), ", "))
+	}
 
-	// Run the MCP handler
-	if err := handler.Run(ctx); err != nil {
-		if err == context.Canceled {
-			if *debug {
-				log.Println("Shutdown completed")
-			}
-			return
+	// Replace the schema with the one given on the command line
+	if *schemaPath != "" {
+		data, err := os.ReadFile(*schemaPath)
+		if err != nil {
+			fatalf("Failed to read schema: %v", err)
+		}
+		schema, err := graph.ParseSchema(data)
+		if err != nil {
+			fatalf("Failed to parse schema %s: %v", *schemaPath, err)
+		}
+		if err := g.(graph.SchemaProvider).SetSchema(schema); err != nil {
+			fatalf("Failed to set schema: %v", err)
+		}
+		if *debug {
+			log.Printf("Schema loaded from %s", *schemaPath)
 		}
-		log.Fatalf("Handler error: %v", err)
 	}
+
+	// Serve several clients over HTTP, or a single one over stdio
+	if *listenAddr != "" {
+		server := mcp.NewHTTPServer(g, *debug)
+		server.IdleTimeout = *idleTimeout
+		if *debug {
+			log.Printf("Serving MCP on http://%s%s", *listenAddr, mcp.HTTPEndpoint)
+		}
+		if err := server.ListenAndServe(ctx, *listenAddr); err != nil {
+			fatalf("HTTP server error: %v", err)
+		}
+	} else {
+		// Create MCP handler
+		handler := mcp.NewStdioHandler(g, *debug)
+		handler.Workers = *workers
+
+		// Run the MCP handler until stdin closes or a signal arrives
+		if err := handler.Run(ctx); err != nil && err != context.Canceled {
+			fatalf("Handler error: %v", err)
+		}
+	}
+
+	if err := closeGraph(); err != nil {
+		log.Fatalf("Failed to close database: %v", err)
+	}
+	if *debug {
+		log.Println("Shutdown completed")
+	}
+}
+
+// parseList splits a comma-separated flag value, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-func dumpDatabase(dbPath string, debug bool) {
+func dumpDatabase(dbPath, storageKind string, debug bool) {
 	// Check if file exists
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		fmt.Fprintf(os.Stderr, "Error: Database file does not exist: %s\n", dbPath)
@@ -126,17 +223,21 @@ func dumpDatabase(dbPath string, debug bool) {
 
 	ctx := context.Background()
 
-	// Initialize persistent graph with BoltDB backend
-	backend := storage.NewBoltBackend()
+	// Initialize persistent graph with the selected backend
+	backend, err := storage.NewBackend(storageKind)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 	if err := backend.Open(dbPath); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to open database: %v\n", err)
 		os.Exit(1)
 	}
-	defer backend.Close()
 
-	// Create persistent graph
+	// Create persistent graph, which owns the backend from here on
 	persistentGraph := storage.NewPersistentGraph(backend, false, 30*time.Second)
 	if err := persistentGraph.Load(ctx); err != nil {
+		backend.Close()
 		fmt.Fprintf(os.Stderr, "Error: Failed to load database: %v\n", err)
 		os.Exit(1)
 	}
@@ -250,7 +351,7 @@ func printEdge(edge graph.Edge, debug bool) {
 	fmt.Printf("\n")
 }
 
-func printProperties(props map[string]string, debug bool) {
+func printProperties(props map[string]graph.Value, debug bool) {
 	if len(props) == 0 {
 		return
 	}
@@ -274,10 +375,10 @@ func printProperties(props map[string]string, debug bool) {
 			}
 		} else {
 			// Pretty print with truncation for long values
-			if len(value) > 100 {
-				fmt.Printf("    %s: %s...\n", key, value[:97])
+			if text := value.String(); len(text) > 100 {
+				fmt.Printf("    %s: %s...\n", key, text[:97])
 			} else {
-				fmt.Printf("    %s: %s\n", key, value)
+				fmt.Printf("    %s: %s\n", key, text)
 			}
 		}
 	}
@@ -287,6 +388,9 @@ func showUsage() {
 	fmt.Printf(banner, version)
 	fmt.Println("USAGE:")
 	fmt.Println("  relatixdb [OPTIONS]")
+	fmt.Println("  relatixdb export -db PATH [-format FORMAT] [-o FILE]")
+	fmt.Println("  relatixdb import -db PATH [-merge] [FILE]")
+	fmt.Println("  relatixdb load-csv -db PATH [-nodes FILE] [-edges FILE] [-dry-run]")
 	fmt.Println()
 	fmt.Println("OPTIONS:")
 	fmt.Println("  -version      Show version information")
@@ -294,11 +398,32 @@ func showUsage() {
 	fmt.Println("  -debug        Enable debug logging to stderr")
 	fmt.Println("  -db PATH      Database file path (optional, uses in-memory if not specified)")
 	fmt.Println("  -dump PATH    Pretty print contents of database file and exit")
+	fmt.Println("  -index KEYS   Comma-separated node property keys to index (persisted with -db)")
+	fmt.Println("  -schema FILE  JSON schema that add_node and add_edge enforce (persisted with -db)")
+	fmt.Println("  -autosave DUR Checkpoint interval for -db (e.g. 30s); 0 writes every change through")
+	fmt.Println("  -storage KIND Storage backend for -db, -dump, export and import: bolt (default)")
+	fmt.Println("                or wal (append-only log with background compaction)")
+	fmt.Println("  -listen ADDR  Serve MCP over streamable HTTP on ADDR (e.g. 127.0.0.1:7400)")
+	fmt.Println("                instead of stdio, so several clients can share one graph")
+	fmt.Println("  -session-timeout DUR  End -listen sessions idle this long (default 30m);")
+	fmt.Println("                0 keeps them until the client deletes them")
+	fmt.Println("  -workers N    Maximum number of stdio requests processed at once (default 8);")
+	fmt.Println("                1 processes requests one at a time, in order")
+	fmt.Println()
+	fmt.Println("SUBCOMMANDS:")
+	fmt.Println("  export        Write a database as jsonl (default), graphml, gexf or dot")
+	fmt.Println("                (stdout unless -o is given); dot can render the -node/-hops")
+	fmt.Println("                neighborhood of a node or the -from/-to paths between two")
+	fmt.Println("                nodes")
+	fmt.Println("  import        Load a JSON Lines export into a database, replacing its")
+	fmt.Println("                contents unless -merge is given (stdin unless FILE is given)")
+	fmt.Println("  load-csv      Bulk load node and edge CSVs; run 'relatixdb load-csv -help'")
+	fmt.Println("                for column mappings")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  RelatixDB is a high-performance local graph database designed for use as an")
 	fmt.Println("  MCP (Model Context Protocol) tool server. It operates via JSON commands on")
-	fmt.Println("  stdin with JSON responses on stdout.")
+	fmt.Println("  stdin with JSON responses on stdout, or over HTTP at /mcp with -listen.")
 	fmt.Println()
 	fmt.Println("  The database supports:")
 	fmt.Println("  - Nodes with unique IDs, optional types, and key/value properties")
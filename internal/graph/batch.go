@@ -0,0 +1,237 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// Batch operation kinds
+const (
+	OpAddNode    = "add_node"
+	OpUpdateNode = "update_node"
+	OpUpsertNode = "upsert_node"
+	OpDeleteNode = "delete_node"
+	OpAddEdge    = "add_edge"
+	OpUpdateEdge = "update_edge"
+	OpUpsertEdge = "upsert_edge"
+	OpDeleteEdge = "delete_edge"
+)
+
+// BatchOp is a single mutation in a batch. Node operations use ID, Type and
+// Props; edge operations use From, To, Label and Props. Updates and upserts of
+// existing elements apply Props, Remove and Mode as described by Update.
+type BatchOp struct {
+	Op     string           `json:"op"`
+	ID     string           `json:"id,omitempty"`
+	Type   string           `json:"type,omitempty"`
+	From   string           `json:"from,omitempty"`
+	To     string           `json:"to,omitempty"`
+	Label  string           `json:"label,omitempty"`
+	Props  map[string]Value `json:"props,omitempty"`
+	Remove []string         `json:"remove,omitempty"`
+	Mode   string           `json:"mode,omitempty"` // "merge" (default) or "replace"
+}
+
+// Batch operation statuses
+const (
+	BatchApplied    = "applied"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped"
+)
+
+// BatchResult reports the outcome of one batch operation
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  string `json:"status"`
+	Created bool   `json:"created,omitempty"` // set when an upsert created a new element
+	Error   string `json:"error,omitempty"`
+}
+
+// Change is a single effective mutation produced by a batch, including the
+// edges removed by cascading node deletes. Exactly one of Node and Edge is
+// set; updates also carry the replaced state in PrevNode or PrevEdge.
+type Change struct {
+	Node     *Node `json:"node,omitempty"`
+	Edge     *Edge `json:"edge,omitempty"`
+	Created  bool  `json:"created,omitempty"`
+	Deleted  bool  `json:"deleted,omitempty"`
+	PrevNode *Node `json:"prev_node,omitempty"`
+	PrevEdge *Edge `json:"prev_edge,omitempty"`
+}
+
+// Batcher is implemented by graphs that can apply several mutations atomically
+type Batcher interface {
+	// ApplyBatch applies ops in order, all or nothing. If commit is non-nil it
+	// is called with the resulting changes before the batch is finalized, and
+	// an error from commit rolls the whole batch back.
+	ApplyBatch(ctx context.Context, ops []BatchOp, commit func([]Change) error) ([]BatchResult, error)
+}
+
+// String describes the target of a batch operation, e.g. "add_edge a -[calls]-> b"
+func (op BatchOp) String() string {
+	switch op.Op {
+	case OpAddEdge, OpUpdateEdge, OpUpsertEdge, OpDeleteEdge:
+		return fmt.Sprintf("%s %s -[%s]-> %s", op.Op, op.From, op.Label, op.To)
+	default:
+		return fmt.Sprintf("%s %s", op.Op, op.ID)
+	}
+}
+
+// ApplyBatch applies a batch of mutations atomically under a single write lock
+func (g *MemoryGraph) ApplyBatch(ctx context.Context, ops []BatchOp, commit func([]Change) error) ([]BatchResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return nil, ErrGraphClosed
+	}
+
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = BatchResult{Index: i, Op: op.Op, Status: BatchSkipped}
+	}
+
+	var (
+		changes []Change
+		undo    []func()
+	)
+
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+			results[i].Status = BatchRolledBack
+		}
+	}
+
+	for i, op := range ops {
+		created := (op.Op == OpUpsertNode && g.nodes[op.ID] == nil) ||
+			(op.Op == OpUpsertEdge && g.edges[g.makeEdgeKey(op.From, op.To, op.Label)] == nil)
+
+		opChanges, opUndo, err := g.applyOpLocked(op)
+		if err != nil {
+			rollback()
+			results[i].Status = BatchFailed
+			results[i].Error = err.Error()
+			return results, fmt.Errorf("%w: operation %d (%s): %w", ErrBatchFailed, i, op, err)
+		}
+
+		results[i].Status = BatchApplied
+		results[i].Created = created
+		changes = append(changes, opChanges...)
+		undo = append(undo, opUndo)
+	}
+
+	if commit != nil {
+		if err := commit(changes); err != nil {
+			rollback()
+			return results, fmt.Errorf("%w: %w", ErrBatchFailed, err)
+		}
+	}
+
+	g.feed.Publish(changes)
+	return results, nil
+}
+
+// applyLocked applies a single mutation outside a batch and publishes its
+// changes. Callers must hold the write lock.
+func (g *MemoryGraph) applyLocked(op BatchOp) error {
+	changes, _, err := g.applyOpLocked(op)
+	if err != nil {
+		return err
+	}
+
+	g.feed.Publish(changes)
+	return nil
+}
+
+// applyOpLocked applies one batch operation and returns its effective changes
+// and a function that reverses it. Callers must hold the write lock.
+func (g *MemoryGraph) applyOpLocked(op BatchOp) ([]Change, func(), error) {
+	switch op.Op {
+	case OpAddNode:
+		node := Node{ID: op.ID, Type: op.Type, Props: copyProps(op.Props)}
+		if err := node.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if err := g.addNodeLocked(node); err != nil {
+			return nil, nil, err
+		}
+		undo := func() { g.deleteNodeLocked(node.ID) }
+		return []Change{{Node: &node, Created: true}}, undo, nil
+
+	case OpUpdateNode:
+		updated, previous, err := g.updateNodeLocked(op.ID, op.update())
+		if err != nil {
+			return nil, nil, err
+		}
+		undo := func() { g.replaceNodeLocked(previous) }
+		return []Change{{Node: &updated, PrevNode: &previous}}, undo, nil
+
+	case OpUpsertNode:
+		if _, exists := g.nodes[op.ID]; exists {
+			return g.applyOpLocked(BatchOp{Op: OpUpdateNode, ID: op.ID, Type: op.Type, Props: op.Props, Remove: op.Remove, Mode: op.Mode})
+		}
+		return g.applyOpLocked(BatchOp{Op: OpAddNode, ID: op.ID, Type: op.Type, Props: op.Props})
+
+	case OpDeleteNode:
+		node, removed, err := g.deleteNodeLocked(op.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes := make([]Change, 0, len(removed)+1)
+		for i := range removed {
+			changes = append(changes, Change{Edge: &removed[i], Deleted: true})
+		}
+		changes = append(changes, Change{Node: node, Deleted: true})
+		undo := func() {
+			g.addNodeLocked(*node)
+			for _, edge := range removed {
+				g.addEdgeLocked(edge)
+			}
+		}
+		return changes, undo, nil
+
+	case OpAddEdge:
+		edge := Edge{From: op.From, To: op.To, Label: op.Label, Props: copyProps(op.Props)}
+		if err := edge.Validate(); err != nil {
+			return nil, nil, err
+		}
+		if err := g.addEdgeLocked(edge); err != nil {
+			return nil, nil, err
+		}
+		undo := func() { g.deleteEdgeLocked(edge.From, edge.To, edge.Label) }
+		return []Change{{Edge: &edge, Created: true}}, undo, nil
+
+	case OpUpdateEdge:
+		updated, previous, err := g.updateEdgeLocked(op.From, op.To, op.Label, op.update())
+		if err != nil {
+			return nil, nil, err
+		}
+		undo := func() { g.replaceEdgeLocked(previous) }
+		return []Change{{Edge: &updated, PrevEdge: &previous}}, undo, nil
+
+	case OpUpsertEdge:
+		if _, exists := g.edges[g.makeEdgeKey(op.From, op.To, op.Label)]; exists {
+			return g.applyOpLocked(BatchOp{Op: OpUpdateEdge, From: op.From, To: op.To, Label: op.Label, Props: op.Props, Remove: op.Remove, Mode: op.Mode})
+		}
+		return g.applyOpLocked(BatchOp{Op: OpAddEdge, From: op.From, To: op.To, Label: op.Label, Props: op.Props})
+
+	case OpDeleteEdge:
+		edge, err := g.deleteEdgeLocked(op.From, op.To, op.Label)
+		if err != nil {
+			return nil, nil, err
+		}
+		undo := func() { g.addEdgeLocked(*edge) }
+		return []Change{{Edge: edge, Deleted: true}}, undo, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown operation '%s'", op.Op)
+	}
+}
+
+// update returns the property update carried by an update or upsert operation
+func (op BatchOp) update() Update {
+	return Update{Type: op.Type, Props: op.Props, Remove: op.Remove, Mode: op.Mode}
+}
@@ -0,0 +1,111 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryGraph_ApplyBatch(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	ops := []BatchOp{
+		{Op: OpAddNode, ID: "file:a", Type: "file", Props: StringProps(map[string]string{"path": "a.go"})},
+		{Op: OpAddNode, ID: "func:x", Type: "function"},
+		{Op: OpAddEdge, From: "func:x", To: "file:a", Label: "defined_in"},
+		{Op: OpUpdateNode, ID: "file:a", Props: StringProps(map[string]string{"lines": "10"})},
+		{Op: OpUpdateEdge, From: "func:x", To: "file:a", Label: "defined_in", Props: StringProps(map[string]string{"line": "3"})},
+	}
+
+	var changes []Change
+	results, err := g.ApplyBatch(ctx, ops, func(c []Change) error {
+		changes = c
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, result := range results {
+		if result.Status != BatchApplied {
+			t.Fatalf("Expected every operation applied, got %+v", results)
+		}
+	}
+
+	if len(changes) != len(ops) {
+		t.Fatalf("Expected %d changes, got %d", len(ops), len(changes))
+	}
+
+	node, _ := g.GetNode(ctx, "file:a")
+	if node.Props["path"].String() != "a.go" || node.Props["lines"].String() != "10" {
+		t.Fatalf("Expected merged props, got %v", node.Props)
+	}
+
+	edges, _ := g.GetEdges(ctx, "file:a", "in")
+	if len(edges) != 1 || edges[0].Props["line"].String() != "3" {
+		t.Fatalf("Expected updated edge kept by node update, got %v", edges)
+	}
+}
+
+func TestMemoryGraph_ApplyBatchRollback(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.AddNode(ctx, Node{ID: "a", Type: "file", Props: StringProps(map[string]string{"path": "a.go"})})
+	g.AddNode(ctx, Node{ID: "b", Type: "file"})
+	g.AddEdge(ctx, Edge{From: "a", To: "b", Label: "imports"})
+	g.CreatePropertyIndex("path")
+
+	ops := []BatchOp{
+		{Op: OpAddNode, ID: "c"},
+		{Op: OpUpdateNode, ID: "a", Type: "module", Props: StringProps(map[string]string{"path": "pkg/a"})},
+		{Op: OpDeleteNode, ID: "b"},
+		{Op: OpAddEdge, From: "a", To: "missing", Label: "imports"},
+		{Op: OpAddNode, ID: "d"},
+	}
+
+	results, err := g.ApplyBatch(ctx, ops, nil)
+	if !errors.Is(err, ErrBatchFailed) || !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("Expected ErrBatchFailed wrapping ErrNodeNotFound, got %v", err)
+	}
+
+	want := []string{BatchRolledBack, BatchRolledBack, BatchRolledBack, BatchFailed, BatchSkipped}
+	for i, status := range want {
+		if results[i].Status != status {
+			t.Fatalf("Expected status %s for operation %d, got %+v", status, i, results[i])
+		}
+	}
+
+	// The graph is exactly as before the batch
+	if g.NodeExists(ctx, "c") || !g.NodeExists(ctx, "b") {
+		t.Fatalf("Expected added node removed and deleted node restored")
+	}
+
+	if _, err := g.GetEdge(ctx, "a", "b", "imports"); err != nil {
+		t.Fatalf("Expected cascaded edge restored, got %v", err)
+	}
+
+	node, _ := g.GetNode(ctx, "a")
+	if node.Type != "file" || node.Props["path"].String() != "a.go" {
+		t.Fatalf("Expected node update reverted, got %+v", node)
+	}
+
+	files, _ := g.GetNodesByType(ctx, "file")
+	indexed, _ := g.GetNodesByProperty(ctx, "path", "a.go")
+	if len(files) != 2 || len(indexed) != 1 {
+		t.Fatalf("Expected indexes restored, got %d files and %d indexed", len(files), len(indexed))
+	}
+
+	// A failing commit hook also rolls back
+	_, err = g.ApplyBatch(ctx, []BatchOp{{Op: OpDeleteEdge, From: "a", To: "b", Label: "imports"}}, func([]Change) error {
+		return errors.New("disk full")
+	})
+	if !errors.Is(err, ErrBatchFailed) {
+		t.Fatalf("Expected ErrBatchFailed, got %v", err)
+	}
+
+	if _, err := g.GetEdge(ctx, "a", "b", "imports"); err != nil {
+		t.Fatalf("Expected edge restored after commit failure, got %v", err)
+	}
+}
@@ -18,9 +18,9 @@ func BenchmarkAddNode(b *testing.B) {
 		node := Node{
 			ID:   fmt.Sprintf("node:%d", i),
 			Type: "benchmark",
-			Props: map[string]string{
+			Props: StringProps(map[string]string{
 				"index": fmt.Sprintf("%d", i),
-			},
+			}),
 		}
 
 		if err := g.AddNode(ctx, node); err != nil {
@@ -50,9 +50,9 @@ func BenchmarkAddEdge(b *testing.B) {
 			From:  fmt.Sprintf("node:%d", i),
 			To:    fmt.Sprintf("node:%d", i+1),
 			Label: "connects",
-			Props: map[string]string{
+			Props: StringProps(map[string]string{
 				"weight": "1.0",
-			},
+			}),
 		}
 
 		if err := g.AddEdge(ctx, edge); err != nil {
@@ -182,9 +182,9 @@ func TestPerformanceTargets(t *testing.T) {
 	node := Node{
 		ID:   "perf:test:1",
 		Type: "performance",
-		Props: map[string]string{
+		Props: StringProps(map[string]string{
 			"test": "performance",
-		},
+		}),
 	}
 	err := g.AddNode(ctx, node)
 	duration := time.Since(start)
@@ -209,9 +209,9 @@ func TestPerformanceTargets(t *testing.T) {
 		From:  "perf:test:1",
 		To:    "perf:test:2",
 		Label: "connects",
-		Props: map[string]string{
+		Props: StringProps(map[string]string{
 			"weight": "1.0",
-		},
+		}),
 	}
 	err = g.AddEdge(ctx, edge)
 	duration = time.Since(start)
@@ -9,6 +9,10 @@ var (
 	ErrNodeNotFound = errors.New("node not found")
 	ErrNodeExists   = errors.New("node already exists")
 
+	// Property errors
+	ErrEmptyPropertyKey = errors.New("property key cannot be empty")
+	ErrInvalidValue     = errors.New("invalid property value")
+
 	// Edge errors
 	ErrEmptyFromNode  = errors.New("edge 'from' node cannot be empty")
 	ErrEmptyToNode    = errors.New("edge 'to' node cannot be empty")
@@ -20,6 +24,22 @@ var (
 	ErrInvalidQuery     = errors.New("invalid query")
 	ErrInvalidDirection = errors.New("invalid direction: must be 'in', 'out', or 'both'")
 	ErrMaxDepthExceeded = errors.New("maximum query depth exceeded")
+	ErrInvalidWeight    = errors.New("invalid edge weight: must be a finite, non-negative number")
+	ErrInvalidFilter    = errors.New("invalid filter")
+	ErrInvalidPattern   = errors.New("invalid pattern")
+
+	// Update errors
+	ErrInvalidUpdateMode = errors.New("invalid update mode: must be 'merge' or 'replace'")
+
+	// Schema errors
+	ErrInvalidSchema   = errors.New("invalid schema")
+	ErrSchemaViolation = errors.New("schema violation")
+
+	// Batch errors
+	ErrBatchFailed = errors.New("batch failed and was rolled back")
+
+	// History errors
+	ErrHistoryUnavailable = errors.New("history is not recorded for this graph")
 
 	// General errors
 	ErrGraphClosed = errors.New("graph is closed")
@@ -0,0 +1,53 @@
+package graph
+
+import "sync"
+
+// ChangeFeed is implemented by graphs that publish their committed changes
+type ChangeFeed interface {
+	// Watch calls fn with the changes of every committed mutation, in commit
+	// order, until the returned cancel function is called. fn runs while the
+	// graph is locked, so it must return quickly and must not call back into
+	// the graph.
+	Watch(fn func([]Change)) (cancel func())
+}
+
+// Feed fans committed changes out to watchers. The zero value is ready to use.
+type Feed struct {
+	mu       sync.Mutex
+	watchers map[uint64]func([]Change)
+	next     uint64
+}
+
+// Watch registers fn to receive published changes until cancel is called
+func (f *Feed) Watch(fn func([]Change)) (cancel func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.watchers == nil {
+		f.watchers = make(map[uint64]func([]Change))
+	}
+	id := f.next
+	f.next++
+	f.watchers[id] = fn
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.watchers, id)
+	}
+}
+
+// Publish hands changes to every watcher. Publishers call it once per
+// committed mutation while still holding the lock that ordered the commit.
+func (f *Feed) Publish(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, fn := range f.watchers {
+		fn(changes)
+	}
+}
@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryGraph_Watch(t *testing.T) {
+	ctx := context.Background()
+	g := NewMemoryGraph()
+
+	var published [][]Change
+	cancel := g.Watch(func(changes []Change) {
+		published = append(published, changes)
+	})
+
+	g.AddNode(ctx, Node{ID: "a", Type: "file"})
+	g.AddNode(ctx, Node{ID: "b"})
+	g.UpdateNode(ctx, "a", Update{Type: "module"})
+	g.ApplyBatch(ctx, []BatchOp{{Op: OpAddEdge, From: "a", To: "b", Label: "imports"}}, nil)
+
+	// Failed and rolled back mutations publish nothing
+	g.AddNode(ctx, Node{ID: "a"})
+	g.UpdateEdge(ctx, "a", "b", "missing", Update{Props: StringProps(map[string]string{"x": "1"})})
+	g.ApplyBatch(ctx, []BatchOp{{Op: OpAddNode, ID: "c"}, {Op: OpDeleteNode, ID: "missing"}}, nil)
+
+	g.DeleteNode(ctx, "b")
+
+	if len(published) != 5 {
+		t.Fatalf("Expected 5 published mutations, got %d: %+v", len(published), published)
+	}
+
+	update := published[2][0]
+	if update.Node.Type != "module" || update.PrevNode == nil || update.PrevNode.Type != "file" {
+		t.Fatalf("Expected the update with its previous state, got %+v", update)
+	}
+	if deleted := published[4]; len(deleted) != 2 || deleted[0].Edge == nil || !deleted[1].Deleted {
+		t.Fatalf("Expected the cascaded edge delete before the node delete, got %+v", deleted)
+	}
+
+	cancel()
+	g.AddNode(ctx, Node{ID: "d"})
+	if len(published) != 5 {
+		t.Fatalf("Expected no changes after cancel, got %d mutations", len(published))
+	}
+}
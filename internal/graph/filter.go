@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter operators
+const (
+	OpEq        = "eq"
+	OpNe        = "ne"
+	OpPrefix    = "prefix"
+	OpSuffix    = "suffix"
+	OpContains  = "contains"
+	OpRegex     = "regex"
+	OpExists    = "exists"
+	OpNotExists = "not_exists"
+	OpIn        = "in"
+	OpLt        = "lt"
+	OpLte       = "lte"
+	OpGt        = "gt"
+	OpGte       = "gte"
+	OpBetween   = "between"
+)
+
+// Filter is a structured predicate over the fields of a node or edge. A
+// filter is either a combinator (And, Or or Not) or a comparison of Field
+// using Op. Fields name built-in attributes ("id" and "type" for nodes;
+// "label", "from" and "to" for edges) or property keys; "props.<key>"
+// always refers to a property, even when it shadows a built-in name.
+// Comparisons use the text form of property values, except that "contains"
+// tests the items of a list and the ordering operators compare numbers, or
+// timestamps when Value is an RFC 3339 time.
+type Filter struct {
+	And []Filter `json:"and,omitempty"`
+	Or  []Filter `json:"or,omitempty"`
+	Not *Filter  `json:"not,omitempty"`
+
+	Field  string   `json:"field,omitempty"`
+	Op     string   `json:"op,omitempty"`
+	Value  string   `json:"value,omitempty"`
+	Values []string `json:"values,omitempty"` // set for "in", [min, max] for "between"
+}
+
+// UnmarshalJSON decodes a filter, accepting numbers and booleans as well as
+// strings for value and values so clients can write {"op": "gt", "value": 10}
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	type plainFilter Filter
+	var raw struct {
+		plainFilter
+		Value  json.RawMessage   `json:"value,omitempty"`
+		Values []json.RawMessage `json:"values,omitempty"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*f = Filter(raw.plainFilter)
+
+	if len(raw.Value) > 0 {
+		value, err := scalarString(raw.Value)
+		if err != nil {
+			return fmt.Errorf("%w: value: %v", ErrInvalidFilter, err)
+		}
+		f.Value = value
+	}
+
+	f.Values = nil
+	for _, rawValue := range raw.Values {
+		value, err := scalarString(rawValue)
+		if err != nil {
+			return fmt.Errorf("%w: values: %v", ErrInvalidFilter, err)
+		}
+		f.Values = append(f.Values, value)
+	}
+
+	return nil
+}
+
+// scalarString converts a JSON string, number, boolean or timestamp to the
+// text form of the property value it encodes
+func scalarString(data json.RawMessage) (string, error) {
+	var value Value
+	if err := json.Unmarshal(data, &value); err != nil || value.Kind() == KindList {
+		return "", fmt.Errorf("expected a string, number, boolean or timestamp, got %s", string(data))
+	}
+	return value.String(), nil
+}
+
+// fieldGetter resolves a filter field to a value and whether it is present
+type fieldGetter func(field string) (Value, bool)
+
+// compiledFilter is a validated Filter with its regular expressions and
+// numeric or timestamp operands parsed once up front
+type compiledFilter struct {
+	filter   Filter
+	children []*compiledFilter
+	regex    *regexp.Regexp
+	numbers  []float64
+	times    []time.Time
+}
+
+// compileFilter validates a filter tree and prepares it for evaluation
+func compileFilter(f Filter) (*compiledFilter, error) {
+	compiled := &compiledFilter{filter: f}
+
+	combinators := 0
+	if len(f.And) > 0 {
+		combinators++
+	}
+	if len(f.Or) > 0 {
+		combinators++
+	}
+	if f.Not != nil {
+		combinators++
+	}
+
+	if combinators > 0 {
+		if combinators > 1 || f.Field != "" || f.Op != "" {
+			return nil, fmt.Errorf("%w: a filter must be exactly one of and, or, not, or a field comparison", ErrInvalidFilter)
+		}
+
+		children := f.And
+		if len(f.Or) > 0 {
+			children = f.Or
+		}
+		if f.Not != nil {
+			children = []Filter{*f.Not}
+		}
+
+		for _, child := range children {
+			compiledChild, err := compileFilter(child)
+			if err != nil {
+				return nil, err
+			}
+			compiled.children = append(compiled.children, compiledChild)
+		}
+		return compiled, nil
+	}
+
+	if f.Field == "" {
+		return nil, fmt.Errorf("%w: field is required", ErrInvalidFilter)
+	}
+
+	switch f.Op {
+	case OpEq, OpNe, OpPrefix, OpSuffix, OpContains, OpExists, OpNotExists:
+	case OpIn:
+		if len(f.Values) == 0 {
+			return nil, fmt.Errorf("%w: %s on '%s' requires values", ErrInvalidFilter, f.Op, f.Field)
+		}
+	case OpRegex:
+		re, err := regexp.Compile(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad regex for '%s': %v", ErrInvalidFilter, f.Field, err)
+		}
+		compiled.regex = re
+	case OpLt, OpLte, OpGt, OpGte:
+		if !compiled.addOperand(f.Value) {
+			return nil, fmt.Errorf("%w: %s on '%s' requires a numeric or timestamp value", ErrInvalidFilter, f.Op, f.Field)
+		}
+	case OpBetween:
+		if len(f.Values) != 2 {
+			return nil, fmt.Errorf("%w: between on '%s' requires [min, max] values", ErrInvalidFilter, f.Field)
+		}
+		for _, raw := range f.Values {
+			if !compiled.addOperand(raw) {
+				return nil, fmt.Errorf("%w: between on '%s' requires numeric or timestamp values", ErrInvalidFilter, f.Field)
+			}
+		}
+		if len(compiled.numbers) != 2 && len(compiled.times) != 2 {
+			return nil, fmt.Errorf("%w: between on '%s' requires two numbers or two timestamps", ErrInvalidFilter, f.Field)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown operator '%s'", ErrInvalidFilter, f.Op)
+	}
+
+	return compiled, nil
+}
+
+// addOperand parses an operand of an ordering comparison as a number or
+// else an RFC 3339 timestamp, reporting whether it is either
+func (cf *compiledFilter) addOperand(raw string) bool {
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		cf.numbers = append(cf.numbers, number)
+		return true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		cf.times = append(cf.times, t)
+		return true
+	}
+	return false
+}
+
+// compare orders a value against the operands of an ordering comparison,
+// returning the comparison with each and whether the value is comparable
+func (cf *compiledFilter) compare(value Value) ([]int, bool) {
+	var result []int
+	if len(cf.times) > 0 {
+		// Timestamps stored as strings compare too
+		t, err := time.Parse(time.RFC3339Nano, value.String())
+		if err != nil {
+			return nil, false
+		}
+		for _, operand := range cf.times {
+			result = append(result, t.Compare(operand))
+		}
+		return result, true
+	}
+
+	// Numeric comparisons only match values that parse as numbers
+	number, err := strconv.ParseFloat(value.String(), 64)
+	if err != nil {
+		return nil, false
+	}
+	for _, operand := range cf.numbers {
+		switch {
+		case number < operand:
+			result = append(result, -1)
+		case number > operand:
+			result = append(result, 1)
+		default:
+			result = append(result, 0)
+		}
+	}
+	return result, true
+}
+
+// match evaluates the filter against the fields exposed by get
+func (cf *compiledFilter) match(get fieldGetter) bool {
+	f := cf.filter
+
+	switch {
+	case len(f.And) > 0:
+		for _, child := range cf.children {
+			if !child.match(get) {
+				return false
+			}
+		}
+		return true
+	case len(f.Or) > 0:
+		for _, child := range cf.children {
+			if child.match(get) {
+				return true
+			}
+		}
+		return false
+	case f.Not != nil:
+		return !cf.children[0].match(get)
+	}
+
+	propValue, present := get(f.Field)
+	value := propValue.String()
+
+	switch f.Op {
+	case OpExists:
+		return present
+	case OpNotExists:
+		return !present
+	case OpNe:
+		return !present || value != f.Value
+	}
+
+	if !present {
+		return false
+	}
+
+	switch f.Op {
+	case OpEq:
+		return value == f.Value
+	case OpPrefix:
+		return strings.HasPrefix(value, f.Value)
+	case OpSuffix:
+		return strings.HasSuffix(value, f.Value)
+	case OpContains:
+		if items, ok := propValue.List(); ok {
+			for _, item := range items {
+				if item == f.Value {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(value, f.Value)
+	case OpRegex:
+		return cf.regex.MatchString(value)
+	case OpIn:
+		for _, candidate := range f.Values {
+			if value == candidate {
+				return true
+			}
+		}
+		return false
+	}
+
+	order, ok := cf.compare(propValue)
+	if !ok {
+		return false
+	}
+
+	switch f.Op {
+	case OpLt:
+		return order[0] < 0
+	case OpLte:
+		return order[0] <= 0
+	case OpGt:
+		return order[0] > 0
+	case OpGte:
+		return order[0] >= 0
+	case OpBetween:
+		return order[0] >= 0 && order[1] <= 0
+	}
+
+	return false
+}
+
+// nodeFields exposes a node's attributes and properties to filters
+func nodeFields(node Node) fieldGetter {
+	return func(field string) (Value, bool) {
+		switch field {
+		case "id":
+			return StringValue(node.ID), true
+		case "type":
+			return StringValue(node.Type), node.Type != ""
+		}
+		value, ok := node.Props[strings.TrimPrefix(field, "props.")]
+		return value, ok
+	}
+}
+
+// edgeFields exposes an edge's attributes and properties to filters
+func edgeFields(edge Edge) fieldGetter {
+	return func(field string) (Value, bool) {
+		switch field {
+		case "label":
+			return StringValue(edge.Label), true
+		case "from":
+			return StringValue(edge.From), true
+		case "to":
+			return StringValue(edge.To), true
+		}
+		value, ok := edge.Props[strings.TrimPrefix(field, "props.")]
+		return value, ok
+	}
+}
+
+// equalityConstraints collects the "eq" comparisons that every match of the
+// filter must satisfy (top-level comparisons and direct children of a
+// top-level "and"), keyed by field. Used to pick a starting index.
+func equalityConstraints(f *Filter) map[string]string {
+	constraints := make(map[string]string)
+	if f == nil {
+		return constraints
+	}
+
+	conditions := []Filter{*f}
+	if len(f.And) > 0 {
+		conditions = f.And
+	}
+
+	for _, condition := range conditions {
+		if condition.Op == OpEq && condition.Field != "" {
+			constraints[condition.Field] = condition.Value
+		}
+	}
+
+	return constraints
+}
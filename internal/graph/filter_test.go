@@ -0,0 +1,205 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFilter_Operators(t *testing.T) {
+	node := Node{
+		ID:   "func:login",
+		Type: "function",
+		Props: StringProps(map[string]string{
+			"path":  "auth/login.go",
+			"lines": "42",
+			"lang":  "go",
+		}),
+	}
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"eq", Filter{Field: "lang", Op: OpEq, Value: "go"}, true},
+		{"ne", Filter{Field: "lang", Op: OpNe, Value: "rust"}, true},
+		{"ne missing", Filter{Field: "owner", Op: OpNe, Value: "bob"}, true},
+		{"prefix", Filter{Field: "path", Op: OpPrefix, Value: "auth/"}, true},
+		{"suffix", Filter{Field: "path", Op: OpSuffix, Value: ".py"}, false},
+		{"contains", Filter{Field: "path", Op: OpContains, Value: "login"}, true},
+		{"regex", Filter{Field: "path", Op: OpRegex, Value: `^auth/.*\.go$`}, true},
+		{"exists", Filter{Field: "lines", Op: OpExists}, true},
+		{"not exists", Filter{Field: "owner", Op: OpNotExists}, true},
+		{"in", Filter{Field: "lang", Op: OpIn, Values: []string{"go", "rust"}}, true},
+		{"lt", Filter{Field: "lines", Op: OpLt, Value: "42"}, false},
+		{"lte", Filter{Field: "lines", Op: OpLte, Value: "42"}, true},
+		{"gt", Filter{Field: "lines", Op: OpGt, Value: "9"}, true}, // numeric, not lexical
+		{"gte non-numeric value", Filter{Field: "lang", Op: OpGte, Value: "1"}, false},
+		{"between", Filter{Field: "lines", Op: OpBetween, Values: []string{"10", "100"}}, true},
+		{"id field", Filter{Field: "id", Op: OpPrefix, Value: "func:"}, true},
+		{"type field", Filter{Field: "type", Op: OpEq, Value: "function"}, true},
+		{"props prefix", Filter{Field: "props.lang", Op: OpEq, Value: "go"}, true},
+		{"and", Filter{And: []Filter{
+			{Field: "lang", Op: OpEq, Value: "go"},
+			{Field: "lines", Op: OpGt, Value: "100"},
+		}}, false},
+		{"or", Filter{Or: []Filter{
+			{Field: "lang", Op: OpEq, Value: "rust"},
+			{Field: "lines", Op: OpGt, Value: "10"},
+		}}, true},
+		{"not", Filter{Not: &Filter{Field: "path", Op: OpPrefix, Value: "auth/"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := compileFilter(tc.filter)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if got := compiled.match(nodeFields(node)); got != tc.want {
+				t.Fatalf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFilter_TypedValues(t *testing.T) {
+	node := Node{
+		ID: "user:1",
+		Props: map[string]Value{
+			"age":     IntValue(30),
+			"score":   FloatValue(7.5),
+			"admin":   BoolValue(true),
+			"tags":    ListValue("ops", "db"),
+			"joined":  TimeValue(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)),
+			"renewed": StringValue("2025-01-01T00:00:00Z"),
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"int eq text", Filter{Field: "age", Op: OpEq, Value: "30"}, true},
+		{"int gt", Filter{Field: "age", Op: OpGt, Value: "29.5"}, true},
+		{"float between", Filter{Field: "score", Op: OpBetween, Values: []string{"7", "8"}}, true},
+		{"bool eq", Filter{Field: "admin", Op: OpEq, Value: "true"}, true},
+		{"list contains item", Filter{Field: "tags", Op: OpContains, Value: "db"}, true},
+		{"list contains substring", Filter{Field: "tags", Op: OpContains, Value: "d"}, false},
+		{"timestamp gte", Filter{Field: "joined", Op: OpGte, Value: "2024-03-01T10:00:00+01:00"}, true},
+		{"timestamp lt", Filter{Field: "joined", Op: OpLt, Value: "2024-01-01T00:00:00Z"}, false},
+		{"timestamp between", Filter{Field: "joined", Op: OpBetween, Values: []string{"2024-01-01T00:00:00Z", "2024-12-31T00:00:00Z"}}, true},
+		{"timestamp string", Filter{Field: "renewed", Op: OpGt, Value: "2024-06-01T00:00:00Z"}, true},
+		{"timestamp against number", Filter{Field: "age", Op: OpGt, Value: "2024-06-01T00:00:00Z"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := compileFilter(tc.filter)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := compiled.match(nodeFields(node)); got != tc.want {
+				t.Fatalf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	mixed := Filter{Field: "joined", Op: OpBetween, Values: []string{"1", "2024-12-31T00:00:00Z"}}
+	if _, err := compileFilter(mixed); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("Expected a number and a timestamp to be rejected, got %v", err)
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	invalid := []Filter{
+		{Field: "lang", Op: "like", Value: "go"},
+		{Op: OpEq, Value: "go"},
+		{Field: "path", Op: OpRegex, Value: "("},
+		{Field: "lines", Op: OpGt, Value: "many"},
+		{Field: "lines", Op: OpBetween, Values: []string{"1"}},
+		{Field: "lang", Op: OpIn},
+		{Field: "lang", Op: OpEq, Value: "go", Not: &Filter{Field: "lang", Op: OpExists}},
+	}
+
+	for _, f := range invalid {
+		if _, err := compileFilter(f); !errors.Is(err, ErrInvalidFilter) {
+			t.Fatalf("Expected ErrInvalidFilter for %+v, got %v", f, err)
+		}
+	}
+}
+
+func TestFilter_UnmarshalJSON(t *testing.T) {
+	data := `{"and": [{"field": "lines", "op": "gt", "value": 10}, {"field": "lines", "op": "between", "values": [1, 99.5]}, {"field": "since", "op": "lt", "value": {"timestamp": "2024-03-01T10:00:00+01:00"}}]}`
+
+	var f Filter
+	if err := json.Unmarshal([]byte(data), &f); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(f.And) != 3 || f.And[0].Value != "10" || f.And[1].Values[1] != "99.5" || f.And[2].Value != "2024-03-01T09:00:00Z" {
+		t.Fatalf("Expected numeric values decoded as strings, got %+v", f)
+	}
+}
+
+func TestQueryEngine_FindWhere(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.AddNode(ctx, Node{ID: "file:a", Type: "file", Props: StringProps(map[string]string{"path": "auth/a.go", "size": "120"})})
+	g.AddNode(ctx, Node{ID: "file:b", Type: "file", Props: StringProps(map[string]string{"path": "auth/b.py", "size": "80"})})
+	g.AddNode(ctx, Node{ID: "file:c", Type: "file", Props: StringProps(map[string]string{"path": "db/c.go", "size": "500"})})
+	g.AddNode(ctx, Node{ID: "func:x", Type: "function", Props: StringProps(map[string]string{"path": "auth/a.go"})})
+
+	// Where expression without flat filters
+	where := &Filter{And: []Filter{
+		{Field: "type", Op: OpEq, Value: "file"},
+		{Or: []Filter{
+			{Field: "path", Op: OpSuffix, Value: ".py"},
+			{Field: "size", Op: OpGte, Value: "500"},
+		}},
+	}}
+
+	result, err := g.Query(ctx, Query{Type: "find", Where: where})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %v", result.Nodes)
+	}
+
+	// Flat filters combine with the where expression
+	result, err = g.Query(ctx, Query{
+		Type:    "find",
+		Filters: map[string]string{"type": "file"},
+		Where:   &Filter{Field: "path", Op: OpPrefix, Value: "auth/"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %v", result.Nodes)
+	}
+
+	// ID equality short-circuits to a direct lookup
+	result, err = g.Query(ctx, Query{Type: "find", Where: &Filter{Field: "id", Op: OpEq, Value: "func:x"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 1 || result.Nodes[0].ID != "func:x" {
+		t.Fatalf("Expected func:x, got %v", result.Nodes)
+	}
+
+	// Invalid expressions are reported
+	if _, err := g.Query(ctx, Query{Type: "find", Where: &Filter{Field: "path", Op: "glob"}}); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("Expected ErrInvalidFilter, got %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Version actions
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Version records one change to a node or edge. Node or Edge holds the element
+// after the change, or as it was when deleted; for updates PrevNode or
+// PrevEdge holds the state it replaced.
+type Version struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Actor    string    `json:"actor,omitempty"`
+	Node     *Node     `json:"node,omitempty"`
+	Edge     *Edge     `json:"edge,omitempty"`
+	PrevNode *Node     `json:"prev_node,omitempty"`
+	PrevEdge *Edge     `json:"prev_edge,omitempty"`
+}
+
+// HistoryProvider is implemented by graphs that keep version history
+type HistoryProvider interface {
+	// NodeHistory returns the versions of a node, oldest first
+	NodeHistory(ctx context.Context, id string) ([]Version, error)
+
+	// EdgeHistory returns the versions of an edge, oldest first
+	EdgeHistory(ctx context.Context, from, to, label string) ([]Version, error)
+
+	// AsOf returns a read-only copy of the graph as it was at the given time
+	AsOf(ctx context.Context, at time.Time) (Graph, error)
+
+	// Changes returns the versions recorded after the given time, oldest first
+	Changes(ctx context.Context, since time.Time) ([]Version, error)
+}
+
+// History is an append-only record of node and edge versions. Versions are
+// kept in sequence order with non-decreasing timestamps.
+type History struct {
+	mu       sync.RWMutex
+	versions []Version
+	byKey    map[string][]int // element key -> indexes into versions
+}
+
+// NewHistory creates a history holding previously recorded versions
+func NewHistory(versions []Version) *History {
+	h := &History{byKey: make(map[string][]int)}
+	h.Append(versions)
+	return h
+}
+
+// Len returns the number of recorded versions
+func (h *History) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.versions)
+}
+
+// Prepare turns the changes of one committed mutation into versions that
+// follow the recorded ones. They are not recorded until passed to Append.
+func (h *History) Prepare(changes []Change, actor string, at time.Time) []Version {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var seq uint64
+	if n := len(h.versions); n > 0 {
+		seq = h.versions[n-1].Seq
+		// Keep timestamps ordered even if the clock steps back
+		if last := h.versions[n-1].Time; at.Before(last) {
+			at = last
+		}
+	}
+
+	versions := make([]Version, len(changes))
+	for i, change := range changes {
+		seq++
+		versions[i] = Version{
+			Seq:      seq,
+			Time:     at,
+			Action:   change.action(),
+			Actor:    actor,
+			Node:     change.Node,
+			Edge:     change.Edge,
+			PrevNode: change.PrevNode,
+			PrevEdge: change.PrevEdge,
+		}
+	}
+	return versions
+}
+
+// Append records versions. Versions at or below the last recorded sequence
+// number are ignored, so replaying a log twice is harmless.
+func (h *History) Append(versions []Version) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, v := range versions {
+		if n := len(h.versions); n > 0 && v.Seq <= h.versions[n-1].Seq {
+			continue
+		}
+		key := v.key()
+		h.byKey[key] = append(h.byKey[key], len(h.versions))
+		h.versions = append(h.versions, v)
+	}
+}
+
+// NodeVersions returns the versions of a node, oldest first
+func (h *History) NodeVersions(id string) []Version {
+	return h.elementVersions(nodeHistoryKey(id))
+}
+
+// EdgeVersions returns the versions of an edge, oldest first
+func (h *History) EdgeVersions(from, to, label string) []Version {
+	return h.elementVersions(edgeHistoryKey(from, to, label))
+}
+
+// VersionsSince returns the versions recorded after the given time, oldest first
+func (h *History) VersionsSince(since time.Time) []Version {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	first := sort.Search(len(h.versions), func(i int) bool {
+		return h.versions[i].Time.After(since)
+	})
+	versions := make([]Version, len(h.versions)-first)
+	copy(versions, h.versions[first:])
+	return versions
+}
+
+func (h *History) elementVersions(key string) []Version {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	indexes := h.byKey[key]
+	versions := make([]Version, len(indexes))
+	for i, idx := range indexes {
+		versions[i] = h.versions[idx]
+	}
+	return versions
+}
+
+// StateAt rebuilds the graph as it was at the given time by taking the
+// current graph and undoing every change recorded after it. Elements that
+// predate the history are kept as they are, so only changes made since
+// history was first recorded can be undone.
+func (h *History) StateAt(ctx context.Context, current Graph, at time.Time) (*MemoryGraph, error) {
+	nodes, err := current.GetAllNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := current.GetAllEdges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeState := make(map[string]*Node, len(nodes))
+	for i := range nodes {
+		nodeState[nodes[i].ID] = &nodes[i]
+	}
+	edgeState := make(map[string]*Edge, len(edges))
+	for i := range edges {
+		edge := &edges[i]
+		edgeState[edgeHistoryKey(edge.From, edge.To, edge.Label)] = edge
+	}
+
+	h.mu.RLock()
+	first := sort.Search(len(h.versions), func(i int) bool {
+		return h.versions[i].Time.After(at)
+	})
+
+	// The earliest later change to each element tells what it looked like at the time
+	undone := make(map[string]bool)
+	for _, v := range h.versions[first:] {
+		key := v.key()
+		if undone[key] {
+			continue
+		}
+		undone[key] = true
+
+		if v.Node != nil {
+			id := v.Node.ID
+			switch v.Action {
+			case ActionCreated:
+				delete(nodeState, id)
+			case ActionDeleted:
+				nodeState[id] = v.Node
+			default:
+				nodeState[id] = v.PrevNode
+			}
+		} else if v.Edge != nil {
+			switch v.Action {
+			case ActionCreated:
+				delete(edgeState, key)
+			case ActionDeleted:
+				edgeState[key] = v.Edge
+			default:
+				edgeState[key] = v.PrevEdge
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	state := NewMemoryGraph()
+	if indexer, ok := current.(PropertyIndexer); ok {
+		for _, key := range indexer.PropertyIndexes() {
+			state.CreatePropertyIndex(key)
+		}
+	}
+
+	for _, node := range nodeState {
+		if node == nil {
+			continue
+		}
+		if err := state.AddNode(ctx, *node); err != nil {
+			return nil, err
+		}
+	}
+	for _, edge := range edgeState {
+		if edge == nil {
+			continue
+		}
+		// Edges whose endpoints are missing can only come from contents
+		// replaced outside the history, such as an import; leave them out
+		if err := state.AddEdge(ctx, *edge); err != nil && !errors.Is(err, ErrNodeNotFound) {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// action classifies the change for history
+func (c Change) action() string {
+	switch {
+	case c.Deleted:
+		return ActionDeleted
+	case c.Created:
+		return ActionCreated
+	default:
+		return ActionUpdated
+	}
+}
+
+// key identifies the element a version belongs to
+func (v Version) key() string {
+	if v.Node != nil {
+		return nodeHistoryKey(v.Node.ID)
+	}
+	if v.Edge != nil {
+		return edgeHistoryKey(v.Edge.From, v.Edge.To, v.Edge.Label)
+	}
+	return ""
+}
+
+func nodeHistoryKey(id string) string {
+	return "n\x00" + id
+}
+
+func edgeHistoryKey(from, to, label string) string {
+	return "e\x00" + from + "\x00" + to + "\x00" + label
+}
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes changes made with it to actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "" if there is none
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
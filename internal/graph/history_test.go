@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHistory_StateAt(t *testing.T) {
+	ctx := context.Background()
+	g := NewMemoryGraph()
+	h := NewHistory(nil)
+
+	// A node that predates the history survives every rollback
+	g.AddNode(ctx, Node{ID: "legacy"})
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	step := func(minute int, ops ...BatchOp) {
+		t.Helper()
+		_, err := g.ApplyBatch(ctx, ops, func(changes []Change) error {
+			h.Append(h.Prepare(changes, "tester", base.Add(time.Duration(minute)*time.Minute)))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to apply batch: %v", err)
+		}
+	}
+
+	step(1,
+		BatchOp{Op: OpAddNode, ID: "a", Type: "file", Props: StringProps(map[string]string{"v": "1"})},
+		BatchOp{Op: OpAddNode, ID: "b"},
+		BatchOp{Op: OpAddEdge, From: "a", To: "b", Label: "imports"},
+	)
+	step(2, BatchOp{Op: OpUpdateNode, ID: "a", Props: StringProps(map[string]string{"v": "2"})})
+	step(3, BatchOp{Op: OpDeleteNode, ID: "b"})
+
+	if h.Len() != 6 {
+		t.Fatalf("Expected 6 versions, got %d", h.Len())
+	}
+
+	versions := h.NodeVersions("a")
+	if len(versions) != 2 || versions[0].Action != ActionCreated || versions[1].Action != ActionUpdated {
+		t.Fatalf("Expected a to be created then updated, got %+v", versions)
+	}
+	if versions[1].PrevNode == nil || versions[1].PrevNode.Props["v"].String() != "1" || versions[1].Actor != "tester" {
+		t.Fatalf("Expected the update to record the previous state and actor, got %+v", versions[1])
+	}
+
+	edgeVersions := h.EdgeVersions("a", "b", "imports")
+	if len(edgeVersions) != 2 || edgeVersions[1].Action != ActionDeleted {
+		t.Fatalf("Expected the cascaded edge delete to be recorded, got %+v", edgeVersions)
+	}
+
+	tests := []struct {
+		name   string
+		minute int
+		nodes  int
+		edges  int
+		v      string
+	}{
+		{"before history", 0, 1, 0, ""},
+		{"after creation", 1, 3, 1, "1"},
+		{"after update", 2, 3, 1, "2"},
+		{"after delete", 3, 2, 0, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := h.StateAt(ctx, g, base.Add(time.Duration(tt.minute)*time.Minute))
+			if err != nil {
+				t.Fatalf("Failed to rebuild state: %v", err)
+			}
+
+			nodes, _ := state.GetAllNodes(ctx)
+			edges, _ := state.GetAllEdges(ctx)
+			if len(nodes) != tt.nodes || len(edges) != tt.edges {
+				t.Fatalf("Expected %d nodes and %d edges, got %v and %v", tt.nodes, tt.edges, nodes, edges)
+			}
+			if !state.NodeExists(ctx, "legacy") {
+				t.Fatalf("Expected the pre-history node to be kept")
+			}
+
+			if tt.v != "" {
+				node, err := state.GetNode(ctx, "a")
+				if err != nil || node.Props["v"].String() != tt.v {
+					t.Fatalf("Expected a with v=%s, got %+v (%v)", tt.v, node, err)
+				}
+			}
+		})
+	}
+}
+
+func TestHistory_Append(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHistory(nil)
+
+	first := h.Prepare([]Change{{Node: &Node{ID: "a"}, Created: true}}, "", at)
+	h.Append(first)
+
+	// Replayed versions are ignored and the clock never runs backwards
+	h.Append(first)
+	second := h.Prepare([]Change{{Node: &Node{ID: "a"}, Deleted: true}}, "", at.Add(-time.Hour))
+	h.Append(second)
+
+	versions := h.NodeVersions("a")
+	if len(versions) != 2 || versions[1].Seq != 2 {
+		t.Fatalf("Expected 2 versions with sequence numbers 1 and 2, got %+v", versions)
+	}
+	if versions[1].Time.Before(versions[0].Time) {
+		t.Fatalf("Expected timestamps to stay ordered, got %v then %v", versions[0].Time, versions[1].Time)
+	}
+}
+
+func TestMemoryGraph_QueryAsOf(t *testing.T) {
+	g := NewMemoryGraph()
+	now := time.Now()
+
+	_, err := g.Query(context.Background(), Query{Type: "find", Filters: map[string]string{"type": "x"}, AsOf: &now})
+	if err != ErrHistoryUnavailable {
+		t.Fatalf("Expected ErrHistoryUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,589 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Match query row limits
+const (
+	DefaultMatchLimit = 1000
+	MaxMatchLimit     = 10000
+)
+
+// errMatchLimit stops matching once the row limit is reached
+var errMatchLimit = errors.New("match limit reached")
+
+// matchNodeRef is a node variable with every occurrence of it in the
+// pattern, all of which its node must match. Anonymous nodes get a key of
+// their own that can't clash with a variable.
+type matchNodeRef struct {
+	key      string
+	named    bool
+	patterns []PatternNode
+	find     findConstraints // the occurrences merged, for choosing an index
+}
+
+// matches reports whether a node matches every occurrence of the variable
+func (ref *matchNodeRef) matches(node Node) bool {
+	for _, pattern := range ref.patterns {
+		if !nodeMatchesPattern(node, pattern) {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the node with its occurrences merged, e.g. (f:function)
+func (ref *matchNodeRef) String() string {
+	merged := PatternNode{Type: ref.find.nodeType, Props: make(map[string]string)}
+	if ref.named {
+		merged.Var = ref.key
+	}
+	for key, value := range ref.find.props {
+		merged.Props[key] = value
+	}
+	if ref.find.id != "" {
+		merged.Props["id"] = ref.find.id
+	}
+	return merged.String()
+}
+
+// matchRelRef is a relationship pattern with its binding key and the nodes
+// it joins, left to right as written
+type matchRelRef struct {
+	key         string
+	named       bool
+	pattern     PatternRel
+	left, right *matchNodeRef
+}
+
+// matchStep is one step of a match plan. A scan binds node from the access
+// path chosen for it. An expansion follows rel from the bound node from to
+// node to; reverse is set when from is the relationship's right-hand node.
+type matchStep struct {
+	node   *matchNodeRef
+	access accessPath
+
+	rel      *matchRelRef
+	from, to *matchNodeRef
+	reverse  bool
+}
+
+// matchPlan is the order in which a pattern's nodes are bound, with its
+// estimated cost in nodes and edges visited and a description of each step
+type matchPlan struct {
+	steps   []matchStep
+	columns []string
+	cost    float64
+	explain []string
+}
+
+// planPattern chooses the order in which to bind a pattern's nodes. Every
+// node is tried as the starting point, expanding greedily along the
+// relationship that adds the least work, and the cheapest plan is kept.
+func planPattern(p *planner, pattern *Pattern) *matchPlan {
+	refs := make(map[string]*matchNodeRef)
+	var nodes []*matchNodeRef
+	var rels []*matchRelRef
+	var columns []string
+	seen := make(map[string]bool)
+	column := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			columns = append(columns, name)
+		}
+	}
+
+	for i, path := range pattern.Paths {
+		pathNodes := make([]*matchNodeRef, len(path.Nodes))
+		for j, node := range path.Nodes {
+			key := node.Var
+			if key == "" {
+				key = fmt.Sprintf("#n%d.%d", i, j)
+			}
+			ref := refs[key]
+			if ref == nil {
+				ref = &matchNodeRef{key: key, named: node.Var != ""}
+				refs[key] = ref
+				nodes = append(nodes, ref)
+			}
+			ref.patterns = append(ref.patterns, node)
+			pathNodes[j] = ref
+		}
+
+		column(path.Nodes[0].Var)
+		for j, rel := range path.Rels {
+			key := rel.Var
+			if key == "" {
+				key = fmt.Sprintf("#r%d.%d", i, j)
+			}
+			column(rel.Var)
+			column(path.Nodes[j+1].Var)
+			rels = append(rels, &matchRelRef{
+				key:     key,
+				named:   rel.Var != "",
+				pattern: rel,
+				left:    pathNodes[j],
+				right:   pathNodes[j+1],
+			})
+		}
+	}
+	for _, ref := range nodes {
+		ref.find = mergeConstraints(ref.patterns)
+	}
+
+	var best *matchPlan
+	for _, start := range nodes {
+		plan := p.planFrom(start, nodes, rels)
+		if best == nil || plan.cost < best.cost {
+			best = plan
+		}
+	}
+	best.columns = columns
+	return best
+}
+
+// planFrom builds a plan that scans start first, then repeatedly takes the
+// cheapest expansion from a bound node, scanning the most selective unbound
+// node whenever no expansion is possible
+func (p *planner) planFrom(start *matchNodeRef, nodes []*matchNodeRef, rels []*matchRelRef) *matchPlan {
+	plan := &matchPlan{}
+	bound := make(map[*matchNodeRef]bool)
+	done := make(map[*matchRelRef]bool)
+	rows := 1.0
+
+	scan := func(ref *matchNodeRef) {
+		access := p.access(ref.find)
+		matched := access.estimate * p.selectivity(ref.find, access)
+		plan.cost += rows * access.estimate
+		rows *= matched
+		bound[ref] = true
+		plan.steps = append(plan.steps, matchStep{node: ref, access: access})
+		plan.explain = append(plan.explain, p.describe(
+			fmt.Sprintf("scan %s using %s", ref, access),
+			fmt.Sprintf("~%s candidates, ~%s rows", formatEstimate(access.estimate), formatEstimate(rows))))
+	}
+
+	scan(start)
+	for {
+		var next *matchStep
+		var nextCost, nextRows, nextScore float64
+		for _, rel := range rels {
+			if done[rel] {
+				continue
+			}
+			for _, reverse := range []bool{false, true} {
+				from, to := rel.left, rel.right
+				if reverse {
+					from, to = to, from
+				}
+				if !bound[from] {
+					continue
+				}
+				cost, matched := p.expansion(rel, from, to, reverse, bound[to])
+				cost *= rows
+				matched *= rows
+				if next == nil || cost+matched < nextScore {
+					next = &matchStep{rel: rel, from: from, to: to, reverse: reverse}
+					nextCost, nextRows, nextScore = cost, matched, cost+matched
+				}
+			}
+		}
+
+		if next == nil {
+			var unbound *matchNodeRef
+			var estimate float64
+			for _, ref := range nodes {
+				if bound[ref] {
+					continue
+				}
+				if access := p.access(ref.find); unbound == nil || access.estimate < estimate {
+					unbound, estimate = ref, access.estimate
+				}
+			}
+			if unbound == nil {
+				return plan
+			}
+			scan(unbound)
+			continue
+		}
+
+		from := "(" + next.from.key + ")"
+		if !next.from.named {
+			from = next.from.String()
+		}
+		to := next.to.String()
+		if bound[next.to] && next.to.named {
+			to = "(" + next.to.key + ")"
+		}
+		rel := next.rel.pattern
+		if next.reverse {
+			rel.Direction = reverseDirection(rel.Direction)
+		}
+
+		plan.cost += nextCost
+		rows = nextRows
+		bound[next.to] = true
+		done[next.rel] = true
+		plan.steps = append(plan.steps, *next)
+		plan.explain = append(plan.explain, p.describe(
+			"expand "+from+rel.String()+to,
+			fmt.Sprintf("~%s edges, ~%s rows", formatEstimate(nextCost), formatEstimate(rows))))
+	}
+}
+
+// expansion estimates, per bound row, the edges an expansion walks and the
+// rows it produces
+func (p *planner) expansion(rel *matchRelRef, from, to *matchNodeRef, reverse, toBound bool) (walked, matched float64) {
+	direction := rel.pattern.Direction
+	if reverse {
+		direction = reverseDirection(direction)
+	}
+
+	fanOut := p.fanOut(from.find.id, direction, rel.pattern.Labels)
+	matching := fanOut * math.Pow(unindexedSelectivity, float64(len(rel.pattern.Props)))
+	reached, walked := matching, fanOut
+	if rel.pattern.VarLength {
+		reached, walked = hops(matching, rel.pattern.MinHops, rel.pattern.MaxHops)
+	}
+
+	if toBound {
+		return walked, reached / p.nodeCount()
+	}
+	access := p.access(to.find)
+	return walked, reached * access.estimate * p.selectivity(to.find, access) / p.nodeCount()
+}
+
+// describe formats a plan step, with its estimates when the graph keeps
+// statistics to base them on
+func (p *planner) describe(step, estimates string) string {
+	if p.stats == nil {
+		return step
+	}
+	return step + " (" + estimates + ")"
+}
+
+// queryMatch handles pattern match queries, returning a row of variable
+// bindings per match, up to query.Limit rows (default DefaultMatchLimit)
+func (qe *QueryEngine) queryMatch(ctx context.Context, query Query) (*QueryResult, error) {
+	if query.Pattern == "" {
+		return nil, fmt.Errorf("%w: pattern is required for match queries", ErrInvalidQuery)
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultMatchLimit
+	}
+	if limit < 0 || limit > MaxMatchLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d rows", ErrInvalidQuery, MaxMatchLimit)
+	}
+
+	pattern, err := ParsePattern(query.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	plan := planPattern(qe.newPlanner(), pattern)
+	if query.Explain {
+		return &QueryResult{Columns: plan.columns, Plan: plan.explain}, nil
+	}
+
+	m := &matcher{
+		ctx:       ctx,
+		qe:        qe,
+		plan:      plan,
+		limit:     limit,
+		nodes:     make(map[string]*Node),
+		rels:      make(map[string]Binding),
+		usedEdges: make(map[string]bool),
+		cache:     make(map[string]*Node),
+	}
+	if err := m.run(0); err != nil && !errors.Is(err, errMatchLimit) {
+		return nil, fmt.Errorf("failed to match pattern: %w", err)
+	}
+
+	return &QueryResult{Columns: plan.columns, Rows: m.rows, Truncated: m.truncated}, nil
+}
+
+// matcher executes a plan by backtracking: each step binds a node in every
+// way it can and runs the remaining steps for each, emitting a row when all
+// steps are bound. An edge is used at most once per row.
+type matcher struct {
+	ctx   context.Context
+	qe    *QueryEngine
+	plan  *matchPlan
+	limit int
+
+	nodes     map[string]*Node
+	rels      map[string]Binding
+	usedEdges map[string]bool
+	cache     map[string]*Node
+
+	rows      []map[string]Binding
+	truncated bool
+}
+
+func (m *matcher) run(i int) error {
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+	if i == len(m.plan.steps) {
+		return m.emit()
+	}
+
+	step := m.plan.steps[i]
+	if step.rel == nil {
+		return m.scan(i, step)
+	}
+	return m.expand(i, step)
+}
+
+// scan binds a node from its access path's candidates
+func (m *matcher) scan(i int, step matchStep) error {
+	ref := step.node
+	candidates, err := m.qe.findCandidates(m.ctx, step.access)
+	if err != nil {
+		return err
+	}
+	for j := range candidates {
+		node := &candidates[j]
+		if !ref.matches(*node) {
+			continue
+		}
+		m.cache[node.ID] = node
+		m.nodes[ref.key] = node
+		err := m.run(i + 1)
+		delete(m.nodes, ref.key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expand follows a relationship from its bound node
+func (m *matcher) expand(i int, step matchStep) error {
+	from := m.nodes[step.from.key]
+	direction := step.rel.pattern.Direction
+	if step.reverse {
+		direction = reverseDirection(direction)
+	}
+
+	if !step.rel.pattern.VarLength {
+		return m.forEachEdge(from.ID, direction, step.rel.pattern, func(edge Edge, next string) error {
+			if step.rel.named {
+				m.rels[step.rel.key] = Binding{Edge: &edge}
+			}
+			return m.bind(i, step.to, next)
+		})
+	}
+
+	visited := map[string]bool{from.ID: true}
+	return m.walk(i, step, direction, []Node{*from}, nil, visited)
+}
+
+// walk extends a variable-length match one edge at a time along simple
+// paths, binding the far node at every length within the allowed range
+func (m *matcher) walk(i int, step matchStep, direction string, nodes []Node, edges []Edge, visited map[string]bool) error {
+	at := nodes[len(nodes)-1].ID
+	rel := step.rel.pattern
+
+	if len(edges) >= rel.MinHops {
+		if step.rel.named {
+			path := Path{Nodes: append([]Node(nil), nodes...), Edges: append([]Edge(nil), edges...)}
+			if step.reverse {
+				reversePath(&path)
+			}
+			m.rels[step.rel.key] = Binding{Path: &path}
+		}
+		if err := m.bind(i, step.to, at); err != nil {
+			return err
+		}
+	}
+	if len(edges) == rel.MaxHops {
+		return nil
+	}
+	if err := m.ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.forEachEdge(at, direction, rel, func(edge Edge, next string) error {
+		if visited[next] {
+			return nil
+		}
+		node, err := m.node(next)
+		if err != nil {
+			return err
+		}
+		visited[next] = true
+		err = m.walk(i, step, direction, append(nodes, *node), append(edges, edge), visited)
+		delete(visited, next)
+		return err
+	})
+}
+
+// forEachEdge calls fn with each unused edge of a node matching rel and the
+// node at its far end, marking the edge used for the duration of the call
+func (m *matcher) forEachEdge(nodeID, direction string, rel PatternRel, fn func(edge Edge, next string) error) error {
+	edges, err := m.qe.graph.GetEdges(m.ctx, nodeID, direction)
+	if err != nil {
+		return err
+	}
+
+	for _, edge := range edges {
+		if !edgeMatchesPattern(edge, rel) {
+			continue
+		}
+		key := edgeHistoryKey(edge.From, edge.To, edge.Label)
+		if m.usedEdges[key] {
+			continue
+		}
+
+		next := otherEnd(edge, nodeID)
+		switch direction {
+		case "out":
+			next = edge.To
+		case "in":
+			next = edge.From
+		}
+
+		m.usedEdges[key] = true
+		err := fn(edge, next)
+		delete(m.usedEdges, key)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind binds a node reached by an expansion and runs the remaining steps. A
+// node bound earlier must be the same node.
+func (m *matcher) bind(i int, ref *matchNodeRef, id string) error {
+	if bound := m.nodes[ref.key]; bound != nil {
+		if bound.ID != id {
+			return nil
+		}
+		return m.run(i + 1)
+	}
+
+	node, err := m.node(id)
+	if err != nil {
+		return err
+	}
+	if !ref.matches(*node) {
+		return nil
+	}
+
+	m.nodes[ref.key] = node
+	err = m.run(i + 1)
+	delete(m.nodes, ref.key)
+	return err
+}
+
+// node returns a node by ID, memoized for the query
+func (m *matcher) node(id string) (*Node, error) {
+	if node, ok := m.cache[id]; ok {
+		return node, nil
+	}
+	node, err := m.qe.graph.GetNode(m.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	m.cache[id] = node
+	return node, nil
+}
+
+// emit records the current bindings as a row
+func (m *matcher) emit() error {
+	if len(m.rows) >= m.limit {
+		m.truncated = true
+		return errMatchLimit
+	}
+
+	row := make(map[string]Binding, len(m.plan.columns))
+	for _, name := range m.plan.columns {
+		if node, ok := m.nodes[name]; ok {
+			copied := *node
+			row[name] = Binding{Node: &copied}
+		} else {
+			row[name] = m.rels[name]
+		}
+	}
+	m.rows = append(m.rows, row)
+	return nil
+}
+
+// mergeConstraints turns the occurrences of a node variable into find
+// constraints; where occurrences disagree the first wins, and matching the
+// others rules the candidates out
+func mergeConstraints(patterns []PatternNode) findConstraints {
+	constraints := findConstraints{props: make(map[string]string)}
+	for _, pattern := range patterns {
+		if constraints.nodeType == "" {
+			constraints.nodeType = pattern.Type
+		}
+		for key, value := range pattern.Props {
+			if key == "id" {
+				if constraints.id == "" {
+					constraints.id = value
+				}
+			} else if _, exists := constraints.props[key]; !exists {
+				constraints.props[key] = value
+			}
+		}
+	}
+	return constraints
+}
+
+// nodeMatchesPattern reports whether a node has the pattern's type and properties
+func nodeMatchesPattern(node Node, pattern PatternNode) bool {
+	if pattern.Type != "" && node.Type != pattern.Type {
+		return false
+	}
+	for key, value := range pattern.Props {
+		if key == "id" {
+			if node.ID != value {
+				return false
+			}
+			continue
+		}
+		if propValue, ok := node.Props[key]; !ok || propValue.String() != value {
+			return false
+		}
+	}
+	return true
+}
+
+// edgeMatchesPattern reports whether an edge has one of the pattern's labels
+// and its properties
+func edgeMatchesPattern(edge Edge, pattern PatternRel) bool {
+	if len(pattern.Labels) > 0 && !contains(pattern.Labels, edge.Label) {
+		return false
+	}
+	return matchesProps(edge.Props, pattern.Props)
+}
+
+// reverseDirection swaps "in" and "out"
+func reverseDirection(direction string) string {
+	switch direction {
+	case "out":
+		return "in"
+	case "in":
+		return "out"
+	default:
+		return direction
+	}
+}
+
+// reversePath reverses a path in place
+func reversePath(path *Path) {
+	for i, j := 0, len(path.Nodes)-1; i < j; i, j = i+1, j-1 {
+		path.Nodes[i], path.Nodes[j] = path.Nodes[j], path.Nodes[i]
+	}
+	for i, j := 0, len(path.Edges)-1; i < j; i, j = i+1, j-1 {
+		path.Edges[i], path.Edges[j] = path.Edges[j], path.Edges[i]
+	}
+}
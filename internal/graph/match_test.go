@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newCodeGraph builds a small call graph:
+//
+//	main -calls-> login -calls-> hash -calls-> encode, main -calls-> logout
+//
+// with every function defined in a file and login and hash written in Go
+func newCodeGraph(t *testing.T) *MemoryGraph {
+	t.Helper()
+	ctx := context.Background()
+	g := NewMemoryGraph()
+
+	for _, node := range []Node{
+		{ID: "main", Type: "function", Props: StringProps(map[string]string{"language": "go"})},
+		{ID: "login", Type: "function", Props: StringProps(map[string]string{"language": "go"})},
+		{ID: "logout", Type: "function", Props: StringProps(map[string]string{"language": "python"})},
+		{ID: "hash", Type: "function", Props: StringProps(map[string]string{"language": "go"})},
+		{ID: "encode", Type: "function", Props: StringProps(map[string]string{"language": "c"})},
+		{ID: "main.go", Type: "file"},
+		{ID: "auth.go", Type: "file"},
+		{ID: "auth.py", Type: "file"},
+		{ID: "codec.c", Type: "file"},
+	} {
+		if err := g.AddNode(ctx, node); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	for _, edge := range []Edge{
+		{From: "main", To: "login", Label: "calls"},
+		{From: "main", To: "logout", Label: "calls"},
+		{From: "login", To: "hash", Label: "calls", Props: map[string]Value{"hot": BoolValue(true), "weight": FloatValue(2)}},
+		{From: "hash", To: "encode", Label: "calls"},
+		{From: "main", To: "main.go", Label: "defined_in"},
+		{From: "login", To: "auth.go", Label: "defined_in"},
+		{From: "hash", To: "auth.go", Label: "defined_in"},
+		{From: "logout", To: "auth.py", Label: "defined_in"},
+		{From: "encode", To: "codec.c", Label: "defined_in"},
+	} {
+		if err := g.AddEdge(ctx, edge); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+	return g
+}
+
+// matchRows renders each row as "var=value ..." in column order, sorted, with
+// nodes as their ID, edges as from>to and paths as their node IDs joined by >
+func matchRows(result *QueryResult) []string {
+	rows := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		parts := make([]string, len(result.Columns))
+		for j, name := range result.Columns {
+			binding := row[name]
+			switch {
+			case binding.Node != nil:
+				parts[j] = name + "=" + binding.Node.ID
+			case binding.Edge != nil:
+				parts[j] = name + "=" + binding.Edge.From + ">" + binding.Edge.To
+			case binding.Path != nil:
+				ids := make([]string, len(binding.Path.Nodes))
+				for k, node := range binding.Path.Nodes {
+					ids[k] = node.ID
+				}
+				parts[j] = name + "=" + strings.Join(ids, ">")
+			}
+		}
+		rows[i] = strings.Join(parts, " ")
+	}
+	sort.Strings(rows)
+	return rows
+}
+
+func TestQueryEngine_Match(t *testing.T) {
+	ctx := context.Background()
+	g := newCodeGraph(t)
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"single node", `(f:function {language: "python"})`, []string{"f=logout"}},
+		{"by id", `(f {id: "hash"})-[:defined_in]->(file)`, []string{"f=hash file=auth.go"}},
+		{"one hop", `(a {id: "main"})-[:calls]->(b)`, []string{"a=main b=login", "a=main b=logout"}},
+		{"incoming", `(f:file {id: "auth.go"})<-[:defined_in]-(g)`, []string{"f=auth.go g=hash", "f=auth.go g=login"}},
+		{"either direction", `(a {id: "login"})-[:calls]-(b)`, []string{"a=login b=hash", "a=login b=main"}},
+		{"edge variable and props", `(a)-[r:calls {hot: "true"}]->(b)`, []string{"a=login r=login>hash b=hash"}},
+		{"typed props", `(a)-[:calls {hot: true, weight: 2.0}]->(b)`, []string{"a=login b=hash"}},
+		{"variable length", `(a {id: "main"})-[p:calls*2..3]->(b)`, []string{"a=main p=main>login>hash b=hash", "a=main p=main>login>hash>encode b=encode"}},
+		{"zero hops", `(a {id: "hash"})-[:calls*0..1]->(b)`, []string{"a=hash b=encode", "a=hash b=hash"}},
+		{"multi-hop join", `(f:function {language:"go"})-[:calls*1..3]->(g:function)-[:defined_in]->(file)`, []string{
+			"f=hash g=encode file=codec.c",
+			"f=login g=encode file=codec.c",
+			"f=login g=hash file=auth.go",
+			"f=main g=encode file=codec.c",
+			"f=main g=hash file=auth.go",
+			"f=main g=login file=auth.go",
+			"f=main g=logout file=auth.py",
+		}},
+		{"shared variable", `(a)-[:defined_in]->(file), (b)-[:defined_in]->(file), (a)-[:calls]->(b)`, []string{"a=login file=auth.go b=hash"}},
+		{"no match", `(a:file)-[:calls]->(b)`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := g.Query(ctx, Query{Type: "match", Pattern: tt.pattern})
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			got := matchRows(result)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("Expected rows\n%s\ngot\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+
+	// Edges are used once per row, so a -- b -- a can't retrace its edge
+	result, err := g.Query(ctx, Query{Type: "match", Pattern: "(a {id: \"hash\"})-[:defined_in]-(f)-[:defined_in]-(b)"})
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if rows := matchRows(result); len(rows) != 1 || rows[0] != "a=hash f=auth.go b=login" {
+		t.Fatalf("Expected only login to share hash's file, got %v", rows)
+	}
+
+	result, err = g.Query(ctx, Query{Type: "match", Pattern: "(f:function)", Limit: 2})
+	if err != nil {
+		t.Fatalf("Match failed: %v", err)
+	}
+	if len(result.Rows) != 2 || !result.Truncated {
+		t.Fatalf("Expected 2 rows and truncation, got %d rows (truncated %v)", len(result.Rows), result.Truncated)
+	}
+
+	errorTests := []struct {
+		name  string
+		query Query
+		want  error
+	}{
+		{"missing pattern", Query{Type: "match"}, ErrInvalidQuery},
+		{"bad pattern", Query{Type: "match", Pattern: "(a)-"}, ErrInvalidPattern},
+		{"limit too large", Query{Type: "match", Pattern: "(a)", Limit: MaxMatchLimit + 1}, ErrInvalidQuery},
+	}
+	for _, tt := range errorTests {
+		if _, err := g.Query(ctx, tt.query); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
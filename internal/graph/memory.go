@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"sort"
 	"sync"
 )
 
@@ -18,6 +19,19 @@ type MemoryGraph struct {
 	outEdges    map[string]map[string]*Edge // from_node -> edge_key -> Edge
 	inEdges     map[string]map[string]*Edge // to_node -> edge_key -> Edge
 
+	// Secondary property indexes, only maintained for declared keys
+	propIndex map[string]map[string]map[string]*Node // prop_key -> value text -> node_id -> Node
+
+	// Degree statistics for query planning, for all edges and by label
+	degrees      *degreeCounter
+	labelDegrees map[string]*degreeCounter
+
+	// Conventions enforced by Operations, if declared
+	schema *Schema
+
+	// Watchers of committed changes
+	feed Feed
+
 	closed bool
 }
 
@@ -29,6 +43,10 @@ func NewMemoryGraph() *MemoryGraph {
 		nodesByType: make(map[string]map[string]*Node),
 		outEdges:    make(map[string]map[string]*Edge),
 		inEdges:     make(map[string]map[string]*Edge),
+		propIndex:   make(map[string]map[string]map[string]*Node),
+
+		degrees:      newDegreeCounter(),
+		labelDegrees: make(map[string]*degreeCounter),
 	}
 }
 
@@ -45,6 +63,11 @@ func (g *MemoryGraph) AddNode(ctx context.Context, node Node) error {
 		return ErrGraphClosed
 	}
 
+	return g.applyLocked(BatchOp{Op: OpAddNode, ID: node.ID, Type: node.Type, Props: node.Props})
+}
+
+// addNodeLocked stores a new node and indexes it. Callers must hold the write lock.
+func (g *MemoryGraph) addNodeLocked(node Node) error {
 	// Check if node already exists
 	if _, exists := g.nodes[node.ID]; exists {
 		return ErrNodeExists
@@ -53,7 +76,7 @@ func (g *MemoryGraph) AddNode(ctx context.Context, node Node) error {
 	// Add to primary storage
 	nodeCopy := node
 	if nodeCopy.Props == nil {
-		nodeCopy.Props = make(map[string]string)
+		nodeCopy.Props = make(map[string]Value)
 	}
 	g.nodes[node.ID] = &nodeCopy
 
@@ -65,6 +88,9 @@ func (g *MemoryGraph) AddNode(ctx context.Context, node Node) error {
 		g.nodesByType[node.Type][node.ID] = &nodeCopy
 	}
 
+	// Update property indexes
+	g.indexNodeProps(&nodeCopy)
+
 	return nil
 }
 
@@ -96,30 +122,33 @@ func (g *MemoryGraph) DeleteNode(ctx context.Context, id string) error {
 		return ErrGraphClosed
 	}
 
+	return g.applyLocked(BatchOp{Op: OpDeleteNode, ID: id})
+}
+
+// deleteNodeLocked removes a node and its connected edges, returning both so
+// the deletion can be undone. Callers must hold the write lock.
+func (g *MemoryGraph) deleteNodeLocked(id string) (*Node, []Edge, error) {
 	node, exists := g.nodes[id]
 	if !exists {
-		return ErrNodeNotFound
+		return nil, nil, ErrNodeNotFound
 	}
 
-	// Remove all outgoing edges
-	if outgoing, exists := g.outEdges[id]; exists {
-		for edgeKey := range outgoing {
-			delete(g.edges, edgeKey)
-		}
-		delete(g.outEdges, id)
+	// Collect connected edges first; self-loops appear in both indexes
+	connected := make(map[string]*Edge)
+	for edgeKey, edge := range g.outEdges[id] {
+		connected[edgeKey] = edge
+	}
+	for edgeKey, edge := range g.inEdges[id] {
+		connected[edgeKey] = edge
 	}
 
-	// Remove all incoming edges
-	if incoming, exists := g.inEdges[id]; exists {
-		for edgeKey := range incoming {
-			edge := incoming[edgeKey]
-			delete(g.edges, edgeKey)
-			// Remove from outEdges of the source node
-			if outgoing, exists := g.outEdges[edge.From]; exists {
-				delete(outgoing, edgeKey)
-			}
+	removed := make([]Edge, 0, len(connected))
+	for _, edge := range connected {
+		removedEdge, err := g.deleteEdgeLocked(edge.From, edge.To, edge.Label)
+		if err != nil {
+			return nil, nil, err
 		}
-		delete(g.inEdges, id)
+		removed = append(removed, *removedEdge)
 	}
 
 	// Remove from type index
@@ -132,10 +161,13 @@ func (g *MemoryGraph) DeleteNode(ctx context.Context, id string) error {
 		}
 	}
 
+	// Remove from property indexes
+	g.unindexNodeProps(node)
+
 	// Remove from primary storage
 	delete(g.nodes, id)
 
-	return nil
+	return node, removed, nil
 }
 
 // AddEdge adds an edge to the graph
@@ -151,6 +183,11 @@ func (g *MemoryGraph) AddEdge(ctx context.Context, edge Edge) error {
 		return ErrGraphClosed
 	}
 
+	return g.applyLocked(BatchOp{Op: OpAddEdge, From: edge.From, To: edge.To, Label: edge.Label, Props: edge.Props})
+}
+
+// addEdgeLocked stores a new edge and indexes it. Callers must hold the write lock.
+func (g *MemoryGraph) addEdgeLocked(edge Edge) error {
 	// Check that both nodes exist
 	if _, exists := g.nodes[edge.From]; !exists {
 		return ErrNodeNotFound
@@ -169,7 +206,7 @@ func (g *MemoryGraph) AddEdge(ctx context.Context, edge Edge) error {
 	// Add to primary storage
 	edgeCopy := edge
 	if edgeCopy.Props == nil {
-		edgeCopy.Props = make(map[string]string)
+		edgeCopy.Props = make(map[string]Value)
 	}
 	g.edges[edgeKey] = &edgeCopy
 
@@ -185,6 +222,8 @@ func (g *MemoryGraph) AddEdge(ctx context.Context, edge Edge) error {
 	}
 	g.inEdges[edge.To][edgeKey] = &edgeCopy
 
+	g.countEdgeLocked(&edgeCopy, 1)
+
 	return nil
 }
 
@@ -217,11 +256,17 @@ func (g *MemoryGraph) DeleteEdge(ctx context.Context, from, to, label string) er
 		return ErrGraphClosed
 	}
 
+	return g.applyLocked(BatchOp{Op: OpDeleteEdge, From: from, To: to, Label: label})
+}
+
+// deleteEdgeLocked removes an edge and returns it. Callers must hold the write lock.
+func (g *MemoryGraph) deleteEdgeLocked(from, to, label string) (*Edge, error) {
 	edgeKey := g.makeEdgeKey(from, to, label)
 
 	// Check if edge exists
-	if _, exists := g.edges[edgeKey]; !exists {
-		return ErrEdgeNotFound
+	edge, exists := g.edges[edgeKey]
+	if !exists {
+		return nil, ErrEdgeNotFound
 	}
 
 	// Remove from primary storage
@@ -243,7 +288,9 @@ func (g *MemoryGraph) DeleteEdge(ctx context.Context, from, to, label string) er
 		}
 	}
 
-	return nil
+	g.countEdgeLocked(edge, -1)
+
+	return edge, nil
 }
 
 // NodeExists checks if a node exists in the graph
@@ -281,6 +328,119 @@ func (g *MemoryGraph) GetNodesByType(ctx context.Context, nodeType string) ([]No
 	return nodes, nil
 }
 
+// GetNodesByProperty returns all nodes whose property key has the text form
+// value, using a secondary index when one exists for key and a full scan
+// otherwise
+func (g *MemoryGraph) GetNodesByProperty(ctx context.Context, key, value string) ([]Node, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.closed {
+		return nil, ErrGraphClosed
+	}
+
+	if index, indexed := g.propIndex[key]; indexed {
+		valueNodes := index[value]
+		nodes := make([]Node, 0, len(valueNodes))
+		for _, node := range valueNodes {
+			nodes = append(nodes, *node)
+		}
+		return nodes, nil
+	}
+
+	var nodes []Node
+	for _, node := range g.nodes {
+		if propValue, ok := node.Props[key]; ok && propValue.String() == value {
+			nodes = append(nodes, *node)
+		}
+	}
+
+	return nodes, nil
+}
+
+// CreatePropertyIndex declares a secondary index on a node property key and
+// populates it from the nodes already in the graph
+func (g *MemoryGraph) CreatePropertyIndex(key string) error {
+	if key == "" {
+		return ErrEmptyPropertyKey
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return ErrGraphClosed
+	}
+
+	if _, exists := g.propIndex[key]; exists {
+		return nil
+	}
+
+	index := make(map[string]map[string]*Node)
+	for id, node := range g.nodes {
+		propValue, ok := node.Props[key]
+		if !ok {
+			continue
+		}
+		value := propValue.String()
+		if index[value] == nil {
+			index[value] = make(map[string]*Node)
+		}
+		index[value][id] = node
+	}
+	g.propIndex[key] = index
+
+	return nil
+}
+
+// PropertyIndexes returns the indexed property keys in sorted order
+func (g *MemoryGraph) PropertyIndexes() []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	keys := make([]string, 0, len(g.propIndex))
+	for key := range g.propIndex {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// indexNodeProps adds a node to every property index it has a value for.
+// Callers must hold the write lock.
+func (g *MemoryGraph) indexNodeProps(node *Node) {
+	for key, index := range g.propIndex {
+		propValue, ok := node.Props[key]
+		if !ok {
+			continue
+		}
+		value := propValue.String()
+		if index[value] == nil {
+			index[value] = make(map[string]*Node)
+		}
+		index[value][node.ID] = node
+	}
+}
+
+// unindexNodeProps removes a node from every property index.
+// Callers must hold the write lock.
+func (g *MemoryGraph) unindexNodeProps(node *Node) {
+	for key, index := range g.propIndex {
+		propValue, ok := node.Props[key]
+		if !ok {
+			continue
+		}
+		value := propValue.String()
+		if valueNodes, exists := index[value]; exists {
+			delete(valueNodes, node.ID)
+			if len(valueNodes) == 0 {
+				delete(index, value)
+			}
+		}
+	}
+}
+
 // GetNeighbors returns neighboring nodes in the specified direction
 func (g *MemoryGraph) GetNeighbors(ctx context.Context, nodeID, direction string) ([]Node, error) {
 	g.mu.RLock()
@@ -342,6 +502,52 @@ func (g *MemoryGraph) GetNeighbors(ctx context.Context, nodeID, direction string
 	return result, nil
 }
 
+// GetEdges returns the edges incident to a node in the specified direction
+func (g *MemoryGraph) GetEdges(ctx context.Context, nodeID, direction string) ([]Edge, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if g.closed {
+		return nil, ErrGraphClosed
+	}
+
+	if _, exists := g.nodes[nodeID]; !exists {
+		return nil, ErrNodeNotFound
+	}
+
+	var sources []map[string]*Edge
+	switch direction {
+	case "out":
+		sources = append(sources, g.outEdges[nodeID])
+	case "in":
+		sources = append(sources, g.inEdges[nodeID])
+	case "both":
+		sources = append(sources, g.outEdges[nodeID], g.inEdges[nodeID])
+	default:
+		return nil, ErrInvalidDirection
+	}
+
+	// Self-loops appear in both indexes, so deduplicate by edge key
+	seen := make(map[string]bool)
+	var result []Edge
+	for _, source := range sources {
+		for edgeKey, edge := range source {
+			if seen[edgeKey] {
+				continue
+			}
+			seen[edgeKey] = true
+			result = append(result, *edge)
+		}
+	}
+
+	return result, nil
+}
+
+// Watch calls fn with the changes of every committed mutation until cancel is called
+func (g *MemoryGraph) Watch(fn func([]Change)) (cancel func()) {
+	return g.feed.Watch(fn)
+}
+
 // Close closes the graph and prevents further operations
 func (g *MemoryGraph) Close() error {
 	g.mu.Lock()
@@ -12,9 +12,9 @@ func TestMemoryGraph_AddNode(t *testing.T) {
 	node := Node{
 		ID:   "test:1",
 		Type: "test",
-		Props: map[string]string{
+		Props: StringProps(map[string]string{
 			"name": "Test Node",
-		},
+		}),
 	}
 
 	err := g.AddNode(ctx, node)
@@ -36,9 +36,9 @@ func TestMemoryGraph_GetNode(t *testing.T) {
 	node := Node{
 		ID:   "test:1",
 		Type: "test",
-		Props: map[string]string{
+		Props: StringProps(map[string]string{
 			"name": "Test Node",
-		},
+		}),
 	}
 
 	// Add node
@@ -87,9 +87,9 @@ func TestMemoryGraph_AddEdge(t *testing.T) {
 		From:  "node1",
 		To:    "node2",
 		Label: "connects",
-		Props: map[string]string{
+		Props: StringProps(map[string]string{
 			"weight": "1.0",
-		},
+		}),
 	}
 
 	err := g.AddEdge(ctx, edge)
@@ -219,6 +219,15 @@ func TestMemoryGraph_DeleteNode(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
+
+	// Verify the deleted edge is gone from node2's incoming edges
+	incoming, err := g.GetEdges(ctx, "node2", "in")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(incoming) != 0 {
+		t.Fatalf("Expected no incoming edges, got %v", incoming)
+	}
 }
 
 func TestMemoryGraph_GetNodesByType(t *testing.T) {
@@ -266,3 +275,113 @@ func TestMemoryGraph_GetNodesByType(t *testing.T) {
 		t.Fatalf("Expected 0 nodes, got %d", len(empty))
 	}
 }
+
+func TestMemoryGraph_GetEdges(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	for _, id := range []string{"node1", "node2", "node3"} {
+		g.AddNode(ctx, Node{ID: id, Type: "test"})
+	}
+
+	edges := []Edge{
+		{From: "node1", To: "node2", Label: "calls"},
+		{From: "node3", To: "node2", Label: "imports"},
+		{From: "node2", To: "node2", Label: "recurses"},
+	}
+
+	for _, edge := range edges {
+		g.AddEdge(ctx, edge)
+	}
+
+	out, err := g.GetEdges(ctx, "node2", "out")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(out) != 1 {
+		t.Fatalf("Expected 1 outgoing edge, got %d", len(out))
+	}
+
+	in, err := g.GetEdges(ctx, "node2", "in")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(in) != 3 {
+		t.Fatalf("Expected 3 incoming edges, got %d", len(in))
+	}
+
+	// The self-loop must only be reported once
+	both, err := g.GetEdges(ctx, "node2", "both")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(both) != 3 {
+		t.Fatalf("Expected 3 edges, got %d", len(both))
+	}
+
+	if _, err := g.GetEdges(ctx, "node2", "sideways"); err != ErrInvalidDirection {
+		t.Fatalf("Expected ErrInvalidDirection, got %v", err)
+	}
+
+	if _, err := g.GetEdges(ctx, "nonexistent", "out"); err != ErrNodeNotFound {
+		t.Fatalf("Expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestMemoryGraph_PropertyIndex(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.AddNode(ctx, Node{ID: "file1", Type: "file", Props: StringProps(map[string]string{"path": "auth/login.go"})})
+	g.AddNode(ctx, Node{ID: "file2", Type: "file", Props: StringProps(map[string]string{"path": "auth/logout.go"})})
+
+	// Existing nodes are indexed when the index is declared
+	if err := g.CreatePropertyIndex("path"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := g.CreatePropertyIndex(""); err != ErrEmptyPropertyKey {
+		t.Fatalf("Expected ErrEmptyPropertyKey, got %v", err)
+	}
+
+	// New nodes are indexed as they are added
+	g.AddNode(ctx, Node{ID: "file3", Type: "file", Props: StringProps(map[string]string{"path": "auth/login.go"})})
+
+	nodes, err := g.GetNodesByProperty(ctx, "path", "auth/login.go")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, got %d", len(nodes))
+	}
+
+	// Deleted nodes leave the index
+	g.DeleteNode(ctx, "file1")
+
+	nodes, err = g.GetNodesByProperty(ctx, "path", "auth/login.go")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(nodes) != 1 || nodes[0].ID != "file3" {
+		t.Fatalf("Expected only file3, got %v", nodes)
+	}
+
+	// Unindexed keys fall back to a scan
+	nodes, err = g.GetNodesByProperty(ctx, "missing", "value")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(nodes) != 0 {
+		t.Fatalf("Expected 0 nodes, got %d", len(nodes))
+	}
+
+	if indexes := g.PropertyIndexes(); len(indexes) != 1 || indexes[0] != "path" {
+		t.Fatalf("Expected [path], got %v", indexes)
+	}
+}
@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// maxNeighborhoodHops bounds neighborhood expansion, matching the path query depth limit
+const maxNeighborhoodHops = 10
+
+// Subgraph result size limits, in nodes
+const (
+	DefaultSubgraphLimit = 500
+	MaxSubgraphLimit     = 10000
+)
+
+// SubgraphOptions selects the subgraph around a set of seed nodes
+type SubgraphOptions struct {
+	Seeds     []string // nodes to expand from; all must exist
+	Hops      int      // expansion radius; 0 selects the seeds alone
+	Direction string   // "in", "out" or "both"; empty means "both"
+	Labels    []string // edge labels to follow and return; empty allows all
+	NodeTypes []string // types of nodes to include besides the seeds; empty allows all
+	Limit     int      // maximum number of nodes; 0 means no limit
+}
+
+// Neighborhood returns the nodes within hops steps of center, following edges
+// in the given direction ("in", "out" or "both"; empty means "both"), together
+// with every edge between those nodes. Nodes are returned in discovery order,
+// starting with center.
+func Neighborhood(ctx context.Context, g Graph, center string, hops int, direction string) ([]Node, []Edge, error) {
+	nodes, edges, _, err := Subgraph(ctx, g, SubgraphOptions{Seeds: []string{center}, Hops: hops, Direction: direction})
+	return nodes, edges, err
+}
+
+// Subgraph expands breadth-first from the seeds and returns the induced
+// subgraph: the nodes reached within opts.Hops steps and every allowed edge
+// between them. Nodes of other types are neither returned nor expanded
+// through. Nodes are returned in discovery order, starting with the seeds.
+// When opts.Limit stops the expansion early, truncated is true.
+func Subgraph(ctx context.Context, g Graph, opts SubgraphOptions) (nodes []Node, edges []Edge, truncated bool, err error) {
+	if len(opts.Seeds) == 0 {
+		return nil, nil, false, fmt.Errorf("%w: at least one seed node is required", ErrInvalidQuery)
+	}
+	if opts.Hops < 0 {
+		return nil, nil, false, fmt.Errorf("%w: hops cannot be negative", ErrInvalidQuery)
+	}
+	if opts.Hops > maxNeighborhoodHops {
+		return nil, nil, false, ErrMaxDepthExceeded
+	}
+	if opts.Limit < 0 {
+		return nil, nil, false, fmt.Errorf("%w: limit cannot be negative", ErrInvalidQuery)
+	}
+	direction := opts.Direction
+	if direction == "" {
+		direction = "both"
+	}
+
+	labels := make(map[string]bool, len(opts.Labels))
+	for _, label := range opts.Labels {
+		labels[label] = true
+	}
+	types := make(map[string]bool, len(opts.NodeTypes))
+	for _, nodeType := range opts.NodeTypes {
+		types[nodeType] = true
+	}
+
+	seen := make(map[string]bool)
+	full := func() bool {
+		return opts.Limit > 0 && len(nodes) >= opts.Limit
+	}
+
+	var frontier []string
+	for _, id := range opts.Seeds {
+		if seen[id] {
+			continue
+		}
+		seed, err := g.GetNode(ctx, id)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("%w: %s", err, id)
+		}
+		if full() {
+			truncated = true
+			break
+		}
+		seen[id] = true
+		nodes = append(nodes, *seed)
+		frontier = append(frontier, id)
+	}
+
+	for depth := 0; depth < opts.Hops && len(frontier) > 0 && !truncated; depth++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, err
+		}
+
+		var next []string
+		for _, id := range frontier {
+			out, err := g.GetEdges(ctx, id, direction)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			for _, edge := range out {
+				if len(labels) > 0 && !labels[edge.Label] {
+					continue
+				}
+				neighborID := otherEnd(edge, id)
+				if seen[neighborID] {
+					continue
+				}
+
+				node, err := g.GetNode(ctx, neighborID)
+				if err != nil {
+					return nil, nil, false, err
+				}
+				if len(types) > 0 && !types[node.Type] {
+					continue
+				}
+				if full() {
+					truncated = true
+					break
+				}
+				seen[neighborID] = true
+				nodes = append(nodes, *node)
+				next = append(next, neighborID)
+			}
+			if truncated {
+				break
+			}
+		}
+		frontier = next
+	}
+
+	// Include every allowed edge among the collected nodes, visiting each once via its source
+	for _, node := range nodes {
+		out, err := g.GetEdges(ctx, node.ID, "out")
+		if err != nil {
+			return nil, nil, false, err
+		}
+		for _, edge := range out {
+			if seen[edge.To] && (len(labels) == 0 || labels[edge.Label]) {
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	return nodes, edges, truncated, nil
+}
+
+// querySubgraph handles subgraph queries. The seeds are query.Seeds plus
+// query.Node, the radius is query.MaxDepth (default 1) and the node limit is
+// query.Limit (default DefaultSubgraphLimit).
+func (qe *QueryEngine) querySubgraph(ctx context.Context, query Query) (*QueryResult, error) {
+	seeds := query.Seeds
+	if query.Node != "" {
+		seeds = append([]string{query.Node}, seeds...)
+	}
+
+	hops := query.MaxDepth
+	if hops == 0 {
+		hops = 1
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = DefaultSubgraphLimit
+	}
+	if limit > MaxSubgraphLimit {
+		return nil, fmt.Errorf("%w: limit cannot exceed %d nodes", ErrInvalidQuery, MaxSubgraphLimit)
+	}
+
+	var labels []string
+	for label := range allowedLabels(query) {
+		labels = append(labels, label)
+	}
+
+	nodes, edges, truncated, err := Subgraph(ctx, qe.graph, SubgraphOptions{
+		Seeds:     seeds,
+		Hops:      hops,
+		Direction: query.Direction,
+		Labels:    labels,
+		NodeTypes: query.NodeTypes,
+		Limit:     limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract subgraph: %w", err)
+	}
+
+	return &QueryResult{Nodes: nodes, Edges: edges, Truncated: truncated}, nil
+}
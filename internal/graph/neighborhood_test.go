@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestNeighborhood(t *testing.T) {
+	ctx := context.Background()
+	g := NewMemoryGraph()
+
+	// a -> b -> c -> d, plus e -> b and a shortcut a -> c
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		if err := g.AddNode(ctx, Node{ID: id}); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	for _, edge := range []Edge{
+		{From: "a", To: "b", Label: "next"},
+		{From: "b", To: "c", Label: "next"},
+		{From: "c", To: "d", Label: "next"},
+		{From: "e", To: "b", Label: "next"},
+		{From: "a", To: "c", Label: "skip"},
+	} {
+		if err := g.AddEdge(ctx, edge); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		center    string
+		hops      int
+		direction string
+		wantNodes []string
+		wantEdges int
+	}{
+		{"zero hops", "b", 0, "", []string{"b"}, 0},
+		{"one hop both", "b", 1, "", []string{"a", "b", "c", "e"}, 4},
+		{"one hop out", "b", 1, "out", []string{"b", "c"}, 1},
+		{"two hops in", "c", 2, "in", []string{"a", "b", "c", "e"}, 4},
+		{"whole chain", "a", 3, "out", []string{"a", "b", "c", "d"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, edges, err := Neighborhood(ctx, g, tt.center, tt.hops, tt.direction)
+			if err != nil {
+				t.Fatalf("Neighborhood failed: %v", err)
+			}
+
+			if nodes[0].ID != tt.center {
+				t.Errorf("Expected center %s first, got %s", tt.center, nodes[0].ID)
+			}
+
+			ids := make([]string, len(nodes))
+			for i, node := range nodes {
+				ids[i] = node.ID
+			}
+			sort.Strings(ids)
+			if len(ids) != len(tt.wantNodes) {
+				t.Fatalf("Expected nodes %v, got %v", tt.wantNodes, ids)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantNodes[i] {
+					t.Fatalf("Expected nodes %v, got %v", tt.wantNodes, ids)
+				}
+			}
+
+			if len(edges) != tt.wantEdges {
+				t.Errorf("Expected %d edges, got %d: %v", tt.wantEdges, len(edges), edges)
+			}
+		})
+	}
+
+	if _, _, err := Neighborhood(ctx, g, "missing", 1, ""); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+	if _, _, err := Neighborhood(ctx, g, "a", 11, ""); !errors.Is(err, ErrMaxDepthExceeded) {
+		t.Errorf("Expected ErrMaxDepthExceeded, got %v", err)
+	}
+	if _, _, err := Neighborhood(ctx, g, "a", 1, "sideways"); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("Expected ErrInvalidDirection, got %v", err)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	ctx := context.Background()
+	g := NewMemoryGraph()
+
+	// Two users in one team that owns a repo with a file; bob also reviews the file
+	for _, node := range []Node{
+		{ID: "alice", Type: "user"},
+		{ID: "bob", Type: "user"},
+		{ID: "core", Type: "team"},
+		{ID: "db", Type: "repo"},
+		{ID: "main.go", Type: "file"},
+	} {
+		if err := g.AddNode(ctx, node); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	for _, edge := range []Edge{
+		{From: "alice", To: "core", Label: "member_of"},
+		{From: "bob", To: "core", Label: "member_of"},
+		{From: "core", To: "db", Label: "owns"},
+		{From: "db", To: "main.go", Label: "contains"},
+		{From: "bob", To: "main.go", Label: "reviews"},
+	} {
+		if err := g.AddEdge(ctx, edge); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		query         Query
+		wantNodes     []string
+		wantEdges     int
+		wantTruncated bool
+	}{
+		{"default radius", Query{Type: "subgraph", Node: "core"}, []string{"alice", "bob", "core", "db"}, 3, false},
+		{"several seeds", Query{Type: "subgraph", Seeds: []string{"alice", "main.go"}}, []string{"alice", "bob", "core", "db", "main.go"}, 5, false},
+		{"labels", Query{Type: "subgraph", Node: "bob", MaxDepth: 3, Labels: []string{"member_of"}}, []string{"alice", "bob", "core"}, 2, false},
+		{"node types", Query{Type: "subgraph", Node: "alice", MaxDepth: 3, NodeTypes: []string{"user", "team"}}, []string{"alice", "bob", "core"}, 2, false},
+		{"direction", Query{Type: "subgraph", Node: "core", MaxDepth: 2, Direction: "out"}, []string{"core", "db", "main.go"}, 2, false},
+		{"limit", Query{Type: "subgraph", Node: "main.go", MaxDepth: 2, Direction: "in", Limit: 3}, []string{"bob", "db", "main.go"}, 2, true},
+		{"limit reached exactly", Query{Type: "subgraph", Node: "db", Direction: "out", Limit: 2}, []string{"db", "main.go"}, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := g.Query(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("Subgraph query failed: %v", err)
+			}
+
+			ids := make([]string, len(result.Nodes))
+			for i, node := range result.Nodes {
+				ids[i] = node.ID
+			}
+			sort.Strings(ids)
+			if strings.Join(ids, ",") != strings.Join(tt.wantNodes, ",") {
+				t.Fatalf("Expected nodes %v, got %v", tt.wantNodes, ids)
+			}
+			if len(result.Edges) != tt.wantEdges {
+				t.Errorf("Expected %d edges, got %d: %v", tt.wantEdges, len(result.Edges), result.Edges)
+			}
+			if result.Truncated != tt.wantTruncated {
+				t.Errorf("Expected truncated %v, got %v", tt.wantTruncated, result.Truncated)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name  string
+		query Query
+		want  error
+	}{
+		{"no seeds", Query{Type: "subgraph"}, ErrInvalidQuery},
+		{"missing seed", Query{Type: "subgraph", Seeds: []string{"alice", "carol"}}, ErrNodeNotFound},
+		{"too deep", Query{Type: "subgraph", Node: "alice", MaxDepth: 11}, ErrMaxDepthExceeded},
+		{"limit too large", Query{Type: "subgraph", Node: "alice", Limit: MaxSubgraphLimit + 1}, ErrInvalidQuery},
+	}
+	for _, tt := range errorTests {
+		if _, err := g.Query(ctx, tt.query); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
@@ -3,11 +3,17 @@ package graph
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Operations provides high-level graph operations that combine multiple low-level operations
 type Operations struct {
 	graph Graph
+
+	// OnSchemaWarning, if set, receives each schema violation of a node or
+	// edge written under a schema in warn mode
+	OnSchemaWarning func(violation string)
 }
 
 // NewOperations creates a new Operations instance
@@ -17,12 +23,19 @@ func NewOperations(graph Graph) *Operations {
 	}
 }
 
-// CreateNode creates a new node, validating that it doesn't already exist
+// CreateNode creates a new node, validating that it doesn't already exist and
+// that it follows the graph's schema, if it has one
 func (ops *Operations) CreateNode(ctx context.Context, node Node) error {
 	if err := node.Validate(); err != nil {
 		return fmt.Errorf("invalid node: %w", err)
 	}
 
+	if schema := ops.schema(); schema != nil {
+		if err := ops.enforce(schema, schema.CheckNode(node)); err != nil {
+			return err
+		}
+	}
+
 	if ops.graph.NodeExists(ctx, node.ID) {
 		return ErrNodeExists
 	}
@@ -30,21 +43,30 @@ func (ops *Operations) CreateNode(ctx context.Context, node Node) error {
 	return ops.graph.AddNode(ctx, node)
 }
 
-// CreateEdge creates a new edge, validating that both nodes exist and edge doesn't exist
+// CreateEdge creates a new edge, validating that both nodes exist, that the
+// edge doesn't, and that it follows the graph's schema, if it has one
 func (ops *Operations) CreateEdge(ctx context.Context, edge Edge) error {
 	if err := edge.Validate(); err != nil {
 		return fmt.Errorf("invalid edge: %w", err)
 	}
 
 	// Check that both nodes exist
-	if !ops.graph.NodeExists(ctx, edge.From) {
+	from, err := ops.graph.GetNode(ctx, edge.From)
+	if err != nil {
 		return fmt.Errorf("from node '%s' does not exist", edge.From)
 	}
 
-	if !ops.graph.NodeExists(ctx, edge.To) {
+	to, err := ops.graph.GetNode(ctx, edge.To)
+	if err != nil {
 		return fmt.Errorf("to node '%s' does not exist", edge.To)
 	}
 
+	if schema := ops.schema(); schema != nil {
+		if err := ops.enforce(schema, schema.CheckEdge(edge, from.Type, to.Type)); err != nil {
+			return err
+		}
+	}
+
 	// Check if edge already exists
 	if _, err := ops.graph.GetEdge(ctx, edge.From, edge.To, edge.Label); err == nil {
 		return ErrEdgeExists
@@ -53,41 +75,47 @@ func (ops *Operations) CreateEdge(ctx context.Context, edge Edge) error {
 	return ops.graph.AddEdge(ctx, edge)
 }
 
-// UpdateNode updates an existing node's properties
-func (ops *Operations) UpdateNode(ctx context.Context, nodeID string, props map[string]string) error {
-	if nodeID == "" {
-		return ErrEmptyNodeID
+// schema returns the graph's schema, or nil if it has none
+func (ops *Operations) schema() *Schema {
+	if provider, ok := ops.graph.(SchemaProvider); ok {
+		return provider.Schema()
 	}
+	return nil
+}
 
-	// Get existing node
-	existingNode, err := ops.graph.GetNode(ctx, nodeID)
-	if err != nil {
-		return err
+// enforce rejects schema violations in strict mode and reports them to
+// OnSchemaWarning in warn mode
+func (ops *Operations) enforce(schema *Schema, violations []string) error {
+	if len(violations) == 0 {
+		return nil
 	}
-
-	// Update properties
-	if existingNode.Props == nil {
-		existingNode.Props = make(map[string]string)
+	if schema.Strict() {
+		return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations, "; "))
 	}
-
-	for key, value := range props {
-		existingNode.Props[key] = value
+	if ops.OnSchemaWarning != nil {
+		for _, violation := range violations {
+			ops.OnSchemaWarning(violation)
+		}
 	}
+	return nil
+}
 
-	// Delete and re-add the node (since we don't have direct update)
-	if err := ops.graph.DeleteNode(ctx, nodeID); err != nil {
-		return fmt.Errorf("failed to delete node for update: %w", err)
+// UpdateNode merges props into an existing node's properties, keeping its
+// edges. Under a schema the updated node must follow it.
+func (ops *Operations) UpdateNode(ctx context.Context, nodeID string, props map[string]Value) error {
+	if nodeID == "" {
+		return ErrEmptyNodeID
 	}
 
-	if err := ops.graph.AddNode(ctx, *existingNode); err != nil {
-		return fmt.Errorf("failed to re-add updated node: %w", err)
+	if ops.schema() != nil {
+		_, err := ops.ApplyBatch(ctx, []BatchOp{{Op: OpUpdateNode, ID: nodeID, Props: props}})
+		return err
 	}
-
-	return nil
+	return ops.graph.UpdateNode(ctx, nodeID, Update{Props: props})
 }
 
-// UpdateEdge updates an existing edge's properties
-func (ops *Operations) UpdateEdge(ctx context.Context, from, to, label string, props map[string]string) error {
+// UpdateEdge merges props into an existing edge's properties
+func (ops *Operations) UpdateEdge(ctx context.Context, from, to, label string, props map[string]Value) error {
 	if from == "" {
 		return ErrEmptyFromNode
 	}
@@ -98,31 +126,100 @@ func (ops *Operations) UpdateEdge(ctx context.Context, from, to, label string, p
 		return ErrEmptyEdgeLabel
 	}
 
-	// Get existing edge
-	existingEdge, err := ops.graph.GetEdge(ctx, from, to, label)
-	if err != nil {
+	if ops.schema() != nil {
+		_, err := ops.ApplyBatch(ctx, []BatchOp{{Op: OpUpdateEdge, From: from, To: to, Label: label, Props: props}})
 		return err
 	}
+	return ops.graph.UpdateEdge(ctx, from, to, label, Update{Props: props})
+}
+
+// ApplyBatch applies ops atomically. Under a schema, every node and edge the
+// batch leaves created or updated must follow it: in strict mode a violation
+// rolls the whole batch back and is returned as ErrSchemaViolation, and in
+// warn mode it is reported to OnSchemaWarning.
+func (ops *Operations) ApplyBatch(ctx context.Context, batch []BatchOp) ([]BatchResult, error) {
+	batcher, ok := ops.graph.(Batcher)
+	if !ok {
+		return nil, fmt.Errorf("graph does not support atomic batches")
+	}
 
-	// Update properties
-	if existingEdge.Props == nil {
-		existingEdge.Props = make(map[string]string)
+	schema := ops.schema()
+	if schema == nil {
+		return batcher.ApplyBatch(ctx, batch, nil)
 	}
 
-	for key, value := range props {
-		existingEdge.Props[key] = value
+	// The batch holds the graph's lock while it commits, so look up the
+	// types of edge endpoints it doesn't write beforehand
+	types := make(map[string]string)
+	for _, op := range batch {
+		for _, id := range []string{op.From, op.To} {
+			if _, seen := types[id]; id == "" || seen {
+				continue
+			}
+			if node, err := ops.graph.GetNode(ctx, id); err == nil {
+				types[id] = node.Type
+			}
+		}
 	}
 
-	// Delete and re-add the edge
-	if err := ops.graph.DeleteEdge(ctx, from, to, label); err != nil {
-		return fmt.Errorf("failed to delete edge for update: %w", err)
+	var schemaErr error
+	results, err := batcher.ApplyBatch(ctx, batch, func(changes []Change) error {
+		schemaErr = ops.enforce(schema, checkChanges(schema, changes, types))
+		return schemaErr
+	})
+	if schemaErr != nil {
+		return results, schemaErr
 	}
+	return results, err
+}
 
-	if err := ops.graph.AddEdge(ctx, *existingEdge); err != nil {
-		return fmt.Errorf("failed to re-add updated edge: %w", err)
+// checkChanges checks the final state of each node and edge a batch created
+// or updated against schema, in the order they were first changed. types
+// holds the types of nodes the batch didn't write.
+func checkChanges(schema *Schema, changes []Change, types map[string]string) []string {
+	type edgeID struct{ from, to, label string }
+
+	var (
+		nodeOrder []string
+		edgeOrder []edgeID
+	)
+	nodes := make(map[string]*Node)
+	edges := make(map[edgeID]*Edge)
+	for _, change := range changes {
+		switch {
+		case change.Node != nil:
+			if _, seen := nodes[change.Node.ID]; !seen {
+				nodeOrder = append(nodeOrder, change.Node.ID)
+			}
+			nodes[change.Node.ID] = change.Node
+			if change.Deleted {
+				nodes[change.Node.ID] = nil
+			}
+		case change.Edge != nil:
+			id := edgeID{change.Edge.From, change.Edge.To, change.Edge.Label}
+			if _, seen := edges[id]; !seen {
+				edgeOrder = append(edgeOrder, id)
+			}
+			edges[id] = change.Edge
+			if change.Deleted {
+				edges[id] = nil
+			}
+		}
 	}
 
-	return nil
+	var violations []string
+	for _, id := range nodeOrder {
+		if node := nodes[id]; node != nil {
+			types[id] = node.Type
+			violations = append(violations, schema.CheckNode(*node)...)
+		}
+	}
+	for _, id := range edgeOrder {
+		if edge := edges[id]; edge != nil {
+			violations = append(violations, schema.CheckEdge(*edge, types[id.from], types[id.to])...)
+		}
+	}
+	return violations
 }
 
 // GetNodeWithEdges returns a node along with its connected edges
@@ -175,13 +272,59 @@ func (ops *Operations) EdgeCount(ctx context.Context) (int, error) {
 	return 0, fmt.Errorf("edge count not implemented")
 }
 
-// ValidateGraph performs basic validation on the graph structure
+// ValidateGraph checks every node and edge against the graph's schema,
+// returning ErrSchemaViolation listing the violations if there are any
 func (ops *Operations) ValidateGraph(ctx context.Context) error {
-	// This would implement various graph validation checks
-	// For now, we'll just return nil
+	violations, err := ops.SchemaViolations(ctx)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations, "; "))
+	}
 	return nil
 }
 
+// SchemaViolations returns the ways the graph's nodes and edges violate its
+// schema, nodes first, each in ID order; a graph without a schema has none
+func (ops *Operations) SchemaViolations(ctx context.Context) ([]string, error) {
+	schema := ops.schema()
+	if schema == nil {
+		return nil, nil
+	}
+
+	nodes, err := ops.graph.GetAllNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	edges, err := ops.graph.GetAllEdges(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Label < edges[j].Label
+	})
+
+	var violations []string
+	types := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		types[node.ID] = node.Type
+		violations = append(violations, schema.CheckNode(node)...)
+	}
+	for _, edge := range edges {
+		violations = append(violations, schema.CheckEdge(edge, types[edge.From], types[edge.To])...)
+	}
+	return violations, nil
+}
+
 // ClearGraph removes all nodes and edges from the graph
 func (ops *Operations) ClearGraph(ctx context.Context) error {
 	// This would need to be implemented more efficiently
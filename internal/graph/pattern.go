@@ -0,0 +1,558 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxPatternHops bounds variable-length relationships, matching the path query depth limit
+const maxPatternHops = maxNeighborhoodHops
+
+// Pattern is a parsed graph pattern: one or more comma-separated paths of
+// node and relationship patterns that must all match, sharing variables.
+//
+//	(f:function {language: "go"})-[:calls*1..3]->(g:function)-[:defined_in]->(file)
+//
+// A node pattern is (var:type {key: value, ...}) with every part optional; the
+// key "id" matches the node ID. A relationship pattern is -[var:label|label
+// *min..max {key: value}]-> with every part optional, written -> or <- for a
+// direction or - - for either; -->, <-- and -- are shorthands without details.
+type Pattern struct {
+	Paths []PatternPath
+}
+
+// PatternPath is a chain of nodes joined by relationships; Rels[i] joins
+// Nodes[i] and Nodes[i+1]
+type PatternPath struct {
+	Nodes []PatternNode
+	Rels  []PatternRel
+}
+
+// PatternNode matches a node with the given type and properties
+type PatternNode struct {
+	Var   string
+	Type  string
+	Props map[string]string // "id" matches the node ID
+}
+
+// PatternRel matches one edge, or with VarLength a simple path of MinHops to
+// MaxHops edges, carrying one of Labels and the given properties
+type PatternRel struct {
+	Var       string
+	Labels    []string
+	Props     map[string]string
+	Direction string // "out" (left to right), "in" (right to left) or "both"
+	VarLength bool
+	MinHops   int
+	MaxHops   int
+}
+
+// ParsePattern parses the pattern language described on Pattern
+func ParsePattern(text string) (*Pattern, error) {
+	p := &patternParser{input: text}
+
+	pattern := &Pattern{}
+	for {
+		path, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		pattern.Paths = append(pattern.Paths, *path)
+
+		p.skipSpace()
+		if !p.consume(",") {
+			break
+		}
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:p.pos+1])
+	}
+
+	if err := pattern.checkVariables(); err != nil {
+		return nil, err
+	}
+	return pattern, nil
+}
+
+// checkVariables rejects variables used for both nodes and relationships,
+// and relationship variables used twice
+func (pattern *Pattern) checkVariables() error {
+	nodeVars := make(map[string]bool)
+	relVars := make(map[string]bool)
+	for _, path := range pattern.Paths {
+		for _, node := range path.Nodes {
+			if node.Var != "" {
+				nodeVars[node.Var] = true
+			}
+		}
+		for _, rel := range path.Rels {
+			if rel.Var == "" {
+				continue
+			}
+			if relVars[rel.Var] {
+				return fmt.Errorf("%w: relationship variable '%s' is used more than once", ErrInvalidPattern, rel.Var)
+			}
+			relVars[rel.Var] = true
+		}
+	}
+
+	for name := range relVars {
+		if nodeVars[name] {
+			return fmt.Errorf("%w: variable '%s' is used for both a node and a relationship", ErrInvalidPattern, name)
+		}
+	}
+	return nil
+}
+
+// String renders the pattern in its canonical form
+func (pattern *Pattern) String() string {
+	paths := make([]string, len(pattern.Paths))
+	for i, path := range pattern.Paths {
+		var b strings.Builder
+		for j, node := range path.Nodes {
+			if j > 0 {
+				b.WriteString(path.Rels[j-1].String())
+			}
+			b.WriteString(node.String())
+		}
+		paths[i] = b.String()
+	}
+	return strings.Join(paths, ", ")
+}
+
+// String renders the node pattern, e.g. (f:function {language: "go"})
+func (n PatternNode) String() string {
+	text := "(" + n.Var
+	if n.Type != "" {
+		text += ":" + formatPatternName(n.Type)
+	}
+	if len(n.Props) > 0 {
+		if text != "(" {
+			text += " "
+		}
+		text += formatPatternProps(n.Props)
+	}
+	return text + ")"
+}
+
+// String renders the relationship pattern, e.g. -[:calls*1..3]->
+func (r PatternRel) String() string {
+	detail := r.Var
+	for i, label := range r.Labels {
+		if i == 0 {
+			detail += ":"
+		} else {
+			detail += "|"
+		}
+		detail += formatPatternName(label)
+	}
+	if r.VarLength {
+		detail += fmt.Sprintf("*%d..%d", r.MinHops, r.MaxHops)
+	}
+	if len(r.Props) > 0 {
+		if detail != "" {
+			detail += " "
+		}
+		detail += formatPatternProps(r.Props)
+	}
+
+	left, right := "-", "-"
+	switch r.Direction {
+	case "out":
+		right = "->"
+	case "in":
+		left = "<-"
+	}
+	if detail == "" {
+		return left + right
+	}
+	return left + "[" + detail + "]" + right
+}
+
+// formatPatternName quotes a type or label with backquotes unless it is a plain identifier
+func formatPatternName(name string) string {
+	for i, r := range name {
+		if !isIdentRune(r, i == 0) {
+			return "`" + name + "`"
+		}
+	}
+	return name
+}
+
+// formatPatternProps renders properties as {key: "value", ...} in key order
+func formatPatternProps(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %s", formatPatternName(key), strconv.Quote(props[key]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// patternParser is a recursive-descent parser over the pattern text
+type patternParser struct {
+	input string
+	pos   int
+}
+
+func (p *patternParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidPattern, fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *patternParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\r\n", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// consume skips s if the input continues with it
+func (p *patternParser) consume(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *patternParser) expect(s string) error {
+	p.skipSpace()
+	if !p.consume(s) {
+		return p.errorf("expected %q", s)
+	}
+	return nil
+}
+
+func (p *patternParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *patternParser) parsePath() (*PatternPath, error) {
+	path := &PatternPath{}
+
+	node, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	path.Nodes = append(path.Nodes, *node)
+
+	for {
+		p.skipSpace()
+		if c := p.peek(); c != '-' && c != '<' {
+			return path, nil
+		}
+
+		rel, err := p.parseRel()
+		if err != nil {
+			return nil, err
+		}
+		node, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		path.Rels = append(path.Rels, *rel)
+		path.Nodes = append(path.Nodes, *node)
+	}
+}
+
+func (p *patternParser) parseNode() (*PatternNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+
+	node := &PatternNode{}
+	p.skipSpace()
+	if name, ok, err := p.parseName(); err != nil {
+		return nil, err
+	} else if ok {
+		node.Var = name
+	}
+
+	p.skipSpace()
+	if p.consume(":") {
+		p.skipSpace()
+		nodeType, ok, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorf("expected a node type")
+		}
+		node.Type = nodeType
+	}
+
+	p.skipSpace()
+	if p.peek() == '{' {
+		props, err := p.parseProps()
+		if err != nil {
+			return nil, err
+		}
+		node.Props = props
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (p *patternParser) parseRel() (*PatternRel, error) {
+	rel := &PatternRel{MinHops: 1, MaxHops: 1}
+
+	p.skipSpace()
+	left := p.consume("<-")
+	if !left && !p.consume("-") {
+		return nil, p.errorf("expected a relationship")
+	}
+
+	p.skipSpace()
+	if p.consume("[") {
+		if err := p.parseRelDetail(rel); err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+	}
+
+	right := p.consume("->")
+	if !right && !p.consume("-") {
+		return nil, p.errorf("expected '-' or '->' to close the relationship")
+	}
+
+	switch {
+	case right && !left:
+		rel.Direction = "out"
+	case left && !right:
+		rel.Direction = "in"
+	default:
+		rel.Direction = "both"
+	}
+	return rel, nil
+}
+
+func (p *patternParser) parseRelDetail(rel *PatternRel) error {
+	p.skipSpace()
+	if name, ok, err := p.parseName(); err != nil {
+		return err
+	} else if ok {
+		rel.Var = name
+	}
+
+	p.skipSpace()
+	if p.consume(":") {
+		for {
+			p.skipSpace()
+			label, ok, err := p.parseName()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return p.errorf("expected an edge label")
+			}
+			rel.Labels = append(rel.Labels, label)
+
+			p.skipSpace()
+			if !p.consume("|") {
+				break
+			}
+			p.skipSpace()
+			p.consume(":")
+		}
+	}
+
+	p.skipSpace()
+	if p.consume("*") {
+		if err := p.parseHops(rel); err != nil {
+			return err
+		}
+	}
+
+	p.skipSpace()
+	if p.peek() == '{' {
+		props, err := p.parseProps()
+		if err != nil {
+			return err
+		}
+		rel.Props = props
+	}
+	return nil
+}
+
+// parseHops parses the range after '*': nothing, n, n.., ..m or n..m
+func (p *patternParser) parseHops(rel *PatternRel) error {
+	rel.VarLength = true
+	rel.MinHops, rel.MaxHops = 1, maxPatternHops
+
+	p.skipSpace()
+	min, hasMin := p.parseInt()
+	p.skipSpace()
+	if p.consume("..") {
+		p.skipSpace()
+		if max, hasMax := p.parseInt(); hasMax {
+			rel.MaxHops = max
+		}
+		if hasMin {
+			rel.MinHops = min
+		}
+	} else if hasMin {
+		rel.MinHops, rel.MaxHops = min, min
+	}
+
+	if rel.MinHops > rel.MaxHops {
+		return p.errorf("minimum hops %d exceed maximum %d", rel.MinHops, rel.MaxHops)
+	}
+	if rel.MaxHops > maxPatternHops {
+		return fmt.Errorf("%w: relationships can span at most %d hops", ErrMaxDepthExceeded, maxPatternHops)
+	}
+	return nil
+}
+
+func (p *patternParser) parseInt() (int, bool) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, false
+	}
+	n, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+func (p *patternParser) parseProps() (map[string]string, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]string)
+	p.skipSpace()
+	if p.consume("}") {
+		return props, nil
+	}
+
+	for {
+		p.skipSpace()
+		key, ok, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorf("expected a property key")
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		props[key] = value
+
+		p.skipSpace()
+		if p.consume("}") {
+			return props, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+// parseValue parses a quoted string, a number or a boolean into the text form
+// of the property value it denotes, so 2.0 matches the int 2
+func (p *patternParser) parseValue() (string, error) {
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		return p.parseString(c)
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.input) && strings.IndexByte("0123456789.eE+-", p.input[p.pos]) >= 0 {
+			p.pos++
+		}
+		text := p.input[start:p.pos]
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return IntValue(i).String(), nil
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.pos = start
+			return "", p.errorf("invalid number %q", text)
+		}
+		return FloatValue(f).String(), nil
+	case p.consume("true"):
+		return "true", nil
+	case p.consume("false"):
+		return "false", nil
+	default:
+		return "", p.errorf("expected a string, number or boolean value")
+	}
+}
+
+func (p *patternParser) parseString(quote byte) (string, error) {
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.input):
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	p.pos = start
+	return "", p.errorf("unterminated string")
+}
+
+// parseName parses an identifier or a `backquoted` name, reporting whether one was present
+func (p *patternParser) parseName() (string, bool, error) {
+	if p.consume("`") {
+		end := strings.IndexByte(p.input[p.pos:], '`')
+		if end < 0 {
+			return "", false, p.errorf("unterminated name")
+		}
+		name := p.input[p.pos : p.pos+end]
+		p.pos += end + 1
+		if name == "" {
+			return "", false, p.errorf("empty name")
+		}
+		return name, true, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && isIdentRune(rune(p.input[p.pos]), p.pos == start) {
+		p.pos++
+	}
+	return p.input[start:p.pos], p.pos > start, nil
+}
+
+func isIdentRune(r rune, first bool) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || !first && r >= '0' && r <= '9'
+}
@@ -0,0 +1,70 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // canonical form
+	}{
+		{"()", "()"},
+		{"(f:function)", "(f:function)"},
+		{`(f:function {language: "go", id: 'login'})`, `(f:function {id: "login", language: "go"})`},
+		{"(a)-->(b)", "(a)-->(b)"},
+		{"(a)<--(b)", "(a)<--(b)"},
+		{"(a)--(b)", "(a)--(b)"},
+		{"(a)<-->(b)", "(a)--(b)"},
+		{"(a)-[r:calls|:imports]->(b)", "(a)-[r:calls|imports]->(b)"},
+		{"(a)<-[:calls {weight: 2.5}]-(b)", `(a)<-[:calls {weight: "2.5"}]-(b)`},
+		{"(a)-[:calls*]->(b)", "(a)-[:calls*1..10]->(b)"},
+		{"(a)-[*2]->(b)", "(a)-[*2..2]->(b)"},
+		{"(a)-[*..3]->(b)", "(a)-[*1..3]->(b)"},
+		{"(a)-[*0..]->(b)", "(a)-[*0..10]->(b)"},
+		{"(a:`my type`)-[:`has-part`]->(b {flag: true})", "(a:`my type`)-[:`has-part`]->(b {flag: \"true\"})"},
+		{" ( a ) - [ : x ] -> ( b ) , ( b ) --> ( c ) ", "(a)-[:x]->(b), (b)-->(c)"},
+		{`(f:function {language:"go"})-[:calls*1..3]->(g:function)-[:defined_in]->(file)`, `(f:function {language: "go"})-[:calls*1..3]->(g:function)-[:defined_in]->(file)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			pattern, err := ParsePattern(tt.input)
+			if err != nil {
+				t.Fatalf("ParsePattern failed: %v", err)
+			}
+			if got := pattern.String(); got != tt.want {
+				t.Fatalf("Expected %s, got %s", tt.want, got)
+			}
+
+			// The canonical form parses back to itself
+			again, err := ParsePattern(pattern.String())
+			if err != nil || again.String() != tt.want {
+				t.Fatalf("Expected the canonical form to round-trip, got %v (%v)", again, err)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrInvalidPattern},
+		{"(a", ErrInvalidPattern},
+		{"(a)->(b)", ErrInvalidPattern},
+		{"(a)-[:]->(b)", ErrInvalidPattern},
+		{"(a {x: })", ErrInvalidPattern},
+		{`(a {x: "open})`, ErrInvalidPattern},
+		{"(a)-[*3..1]->(b)", ErrInvalidPattern},
+		{"(a)-[*1..11]->(b)", ErrMaxDepthExceeded},
+		{"(a)-[r]->(b)-[r]->(c)", ErrInvalidPattern},
+		{"(a)-[a]->(b)", ErrInvalidPattern},
+		{"(a) (b)", ErrInvalidPattern},
+	}
+	for _, tt := range errorTests {
+		if _, err := ParsePattern(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.input, tt.want, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Estimates used when a graph keeps no statistics. They rank access paths in
+// the fixed order the engine used before it had a planner: ID, indexed
+// property, type, full scan.
+const (
+	defaultPropertyEstimate = 10
+	defaultTypeEstimate     = 100
+	defaultScanEstimate     = 1000
+	defaultFanOut           = 10
+)
+
+// unindexedSelectivity is the fraction of nodes or edges assumed to match a
+// condition the statistics can't estimate
+const unindexedSelectivity = 0.1
+
+// maxEstimate caps estimates so long variable-length expansions stay finite
+const maxEstimate = 1e15
+
+// Access path kinds, from most to least selective in the usual case
+const (
+	accessID       = "id"
+	accessProperty = "property"
+	accessType     = "type"
+	accessScan     = "scan"
+)
+
+// accessPath is the index a node lookup starts from, with the number of
+// nodes it is expected to return
+type accessPath struct {
+	kind     string
+	key      string // property key, or the node ID or type
+	value    string // property value
+	estimate float64
+}
+
+// String describes the access path, e.g. type index function
+func (a accessPath) String() string {
+	switch a.kind {
+	case accessID:
+		return "id lookup " + strconv.Quote(a.key)
+	case accessProperty:
+		return fmt.Sprintf("property index %s=%s", a.key, strconv.Quote(a.value))
+	case accessType:
+		return "type index " + a.key
+	default:
+		return "full scan"
+	}
+}
+
+// edgeAccessPath is where an edge lookup starts: the edges of a node in a
+// direction, or every edge when node is empty
+type edgeAccessPath struct {
+	node      string
+	direction string
+	estimate  float64
+}
+
+// String describes the edge access path, e.g. outgoing edges of "main"
+func (a edgeAccessPath) String() string {
+	switch {
+	case a.node == "":
+		return "full edge scan"
+	case a.direction == "in":
+		return "incoming edges of " + strconv.Quote(a.node)
+	default:
+		return "outgoing edges of " + strconv.Quote(a.node)
+	}
+}
+
+// planner estimates how much work each way of answering a query would do,
+// using the graph's statistics when it keeps them
+type planner struct {
+	stats    StatisticsProvider // nil when the graph keeps no statistics
+	snapshot Statistics
+	indexes  []string
+}
+
+// newPlanner snapshots the statistics and property indexes of the engine's graph
+func (qe *QueryEngine) newPlanner() *planner {
+	p := &planner{}
+	if indexer, ok := qe.graph.(PropertyIndexer); ok {
+		p.indexes = indexer.PropertyIndexes()
+	}
+	if provider, ok := qe.graph.(StatisticsProvider); ok {
+		p.stats = provider
+		p.snapshot = provider.Statistics()
+	}
+	return p
+}
+
+// nodeCount returns the number of nodes in the graph
+func (p *planner) nodeCount() float64 {
+	if p.stats == nil {
+		return defaultScanEstimate
+	}
+	return math.Max(float64(p.snapshot.Nodes), 1)
+}
+
+// typeCount returns the number of nodes of a type
+func (p *planner) typeCount(nodeType string) float64 {
+	if p.stats == nil {
+		return defaultTypeEstimate
+	}
+	return float64(p.snapshot.NodesByType[nodeType])
+}
+
+// access picks the index expected to return the fewest nodes satisfying the
+// constraints. Indexed properties are preferred over the type index on ties.
+func (p *planner) access(constraints findConstraints) accessPath {
+	if constraints.id != "" {
+		return accessPath{kind: accessID, key: constraints.id, estimate: 1}
+	}
+
+	best := accessPath{kind: accessScan, estimate: p.nodeCount()}
+	for _, key := range p.indexes {
+		value, exists := constraints.props[key]
+		if !exists {
+			continue
+		}
+		estimate := float64(defaultPropertyEstimate)
+		if p.stats != nil {
+			count, _ := p.stats.PropertyCount(key, value)
+			estimate = float64(count)
+		}
+		if estimate < best.estimate || best.kind == accessScan {
+			best = accessPath{kind: accessProperty, key: key, value: value, estimate: estimate}
+		}
+	}
+
+	if constraints.nodeType != "" {
+		if estimate := p.typeCount(constraints.nodeType); estimate < best.estimate {
+			best = accessPath{kind: accessType, key: constraints.nodeType, estimate: estimate}
+		}
+	}
+	return best
+}
+
+// edgeAccess picks the narrowest starting set for a find_edges query: the
+// outgoing edges of From or the incoming edges of To, whichever node has
+// fewer, then every edge
+func (p *planner) edgeAccess(query Query) edgeAccessPath {
+	best := edgeAccessPath{estimate: defaultScanEstimate}
+	if p.stats != nil {
+		best.estimate = float64(p.snapshot.Edges.Count)
+	}
+
+	if query.From != "" {
+		best = edgeAccessPath{node: query.From, direction: "out", estimate: p.fanOut(query.From, "out", nil)}
+	}
+	if query.To != "" {
+		if estimate := p.fanOut(query.To, "in", nil); best.node == "" || estimate < best.estimate {
+			best = edgeAccessPath{node: query.To, direction: "in", estimate: estimate}
+		}
+	}
+	return best
+}
+
+// selectivity estimates the fraction of an access path's nodes that satisfy
+// the rest of the constraints
+func (p *planner) selectivity(constraints findConstraints, access accessPath) float64 {
+	selectivity := 1.0
+	if constraints.nodeType != "" && access.kind != accessType && access.kind != accessID {
+		if p.stats != nil {
+			selectivity *= p.typeCount(constraints.nodeType) / p.nodeCount()
+		} else {
+			selectivity *= unindexedSelectivity
+		}
+	}
+
+	for key, value := range constraints.props {
+		if access.kind == accessID || (access.kind == accessProperty && access.key == key) {
+			continue
+		}
+		if p.stats != nil {
+			if count, indexed := p.stats.PropertyCount(key, value); indexed {
+				selectivity *= float64(count) / p.nodeCount()
+				continue
+			}
+		}
+		selectivity *= unindexedSelectivity
+	}
+	return selectivity
+}
+
+// fanOut estimates the number of edges with one of the labels (any label when
+// none are given) a node has in a direction. A node known by ID uses its
+// actual degree, so hubs are costed as hubs.
+func (p *planner) fanOut(nodeID, direction string, labels []string) float64 {
+	if p.stats == nil {
+		return defaultFanOut
+	}
+	if nodeID != "" {
+		return float64(p.stats.Degree(nodeID, direction, labels...))
+	}
+
+	degrees := []DegreeStats{p.snapshot.Edges}
+	if len(labels) > 0 {
+		degrees = degrees[:0]
+		for _, label := range labels {
+			degrees = append(degrees, p.snapshot.Labels[label])
+		}
+	}
+
+	fanOut := 0.0
+	for _, stats := range degrees {
+		if direction != "in" {
+			fanOut += stats.AvgOut()
+		}
+		if direction != "out" {
+			fanOut += stats.AvgIn()
+		}
+	}
+	return fanOut
+}
+
+// hops estimates the nodes reached by a variable-length expansion with the
+// given fan-out per hop, and the edges walked to reach them
+func hops(fanOut float64, minHops, maxHops int) (reached, walked float64) {
+	level := 1.0
+	for k := 0; k <= maxHops; k++ {
+		if k >= minHops {
+			reached += level
+		}
+		if k > 0 {
+			walked += level
+		}
+		level = math.Min(level*fanOut, maxEstimate)
+	}
+	return math.Min(reached, maxEstimate), math.Min(walked, maxEstimate)
+}
+
+// formatEstimate renders an estimate for explain output, to one decimal
+// place below 10 and as a whole number above
+func formatEstimate(estimate float64) string {
+	if estimate < 10 {
+		return strconv.FormatFloat(math.Round(estimate*10)/10, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(estimate, 'f', 0, 64)
+}
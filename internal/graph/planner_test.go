@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// newHubGraph builds a graph where 100 functions call the "log" hub, which
+// three files import; only f7 also calls "parse"
+func newHubGraph(t *testing.T) *MemoryGraph {
+	t.Helper()
+	ctx := context.Background()
+	g := NewMemoryGraph()
+
+	add := func(node Node) {
+		if err := g.AddNode(ctx, node); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	link := func(from, to, label string) {
+		if err := g.AddEdge(ctx, Edge{From: from, To: to, Label: label}); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+
+	add(Node{ID: "log", Type: "function"})
+	add(Node{ID: "parse", Type: "function"})
+	for i := 0; i < 3; i++ {
+		add(Node{ID: fmt.Sprintf("file%d", i), Type: "file"})
+	}
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("f%d", i)
+		add(Node{ID: id, Type: "function", Props: StringProps(map[string]string{"team": fmt.Sprintf("t%d", i%2)})})
+		link(id, "log", "calls")
+	}
+	link("f7", "parse", "calls")
+	for i := 0; i < 3; i++ {
+		link(fmt.Sprintf("file%d", i), "log", "imports")
+	}
+	return g
+}
+
+func explain(t *testing.T, g Graph, query Query) []string {
+	t.Helper()
+	query.Explain = true
+	result, err := g.Query(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(result.Rows) > 0 || len(result.Nodes) > 0 || len(result.Edges) > 0 {
+		t.Fatalf("Expected explain not to run the query, got %+v", result)
+	}
+	return result.Plan
+}
+
+func TestPlanner_Match(t *testing.T) {
+	ctx := context.Background()
+	g := newHubGraph(t)
+
+	tests := []struct {
+		name    string
+		pattern string
+		plan    []string // prefixes of the plan steps
+		rows    []string
+	}{
+		{
+			// Starting from the hub would walk all of its incoming calls
+			"avoids expanding a hub",
+			`(h {id: "log"})<-[:calls]-(f)-[:calls]->(p {id: "parse"})`,
+			[]string{`scan (p {id: "parse"}) using id lookup`, `expand (p)<-[:calls]-(f)`, `expand (f)-[:calls]->(h {id: "log"})`},
+			[]string{"h=log f=f7 p=parse"},
+		},
+		{
+			// Three files are cheaper to scan than the hub's 103 incoming edges
+			"scans a small type before a hub",
+			`(a:file)-->(h {id: "log"})`,
+			[]string{"scan (a:file) using type index file", `expand (a)-->(h {id: "log"})`},
+			[]string{"a=file0 h=log", "a=file1 h=log", "a=file2 h=log"},
+		},
+		{
+			// Patterns are expanded right to left when the right end is selective
+			"starts from the selective end",
+			`(f:function)-[:calls]->(p:function {id: "parse"})`,
+			[]string{`scan (p:function {id: "parse"}) using id lookup`, "expand (p)<-[:calls]-(f:function)"},
+			[]string{"f=f7 p=parse"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := explain(t, g, Query{Type: "match", Pattern: tt.pattern})
+			if len(plan) != len(tt.plan) {
+				t.Fatalf("Expected %d steps, got\n%s", len(tt.plan), strings.Join(plan, "\n"))
+			}
+			for i, prefix := range tt.plan {
+				if !strings.HasPrefix(plan[i], prefix) {
+					t.Fatalf("Expected step %d to start with %q, got\n%s", i+1, prefix, strings.Join(plan, "\n"))
+				}
+			}
+
+			result, err := g.Query(ctx, Query{Type: "match", Pattern: tt.pattern})
+			if err != nil {
+				t.Fatalf("Match failed: %v", err)
+			}
+			if got := matchRows(result); strings.Join(got, "\n") != strings.Join(tt.rows, "\n") {
+				t.Fatalf("Expected rows %v, got %v", tt.rows, got)
+			}
+		})
+	}
+}
+
+func TestPlanner_Find(t *testing.T) {
+	ctx := context.Background()
+	g := newHubGraph(t)
+	if err := g.CreatePropertyIndex("team"); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+
+	// A team has 50 functions, so the 3 files are the smaller starting set
+	plan := explain(t, g, Query{Type: "find", Filters: map[string]string{"type": "file", "team": "t1"}})
+	if len(plan) != 1 || !strings.HasPrefix(plan[0], "find using type index file") {
+		t.Fatalf("Expected the type index, got %v", plan)
+	}
+
+	if err := g.AddNode(ctx, Node{ID: "owner", Type: "function", Props: StringProps(map[string]string{"team": "core"})}); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+	plan = explain(t, g, Query{Type: "find", Filters: map[string]string{"type": "function", "team": "core"}})
+	if len(plan) != 1 || !strings.HasPrefix(plan[0], `find using property index team="core" (~1 candidates`) {
+		t.Fatalf("Expected the property index, got %v", plan)
+	}
+	result, err := g.Query(ctx, Query{Type: "find", Filters: map[string]string{"type": "function", "team": "core"}})
+	if err != nil || len(result.Nodes) != 1 || result.Nodes[0].ID != "owner" {
+		t.Fatalf("Expected owner, got %+v (%v)", result, err)
+	}
+
+	// find_edges starts from whichever endpoint has fewer edges
+	plan = explain(t, g, Query{Type: "find_edges", From: "f7", To: "log"})
+	if len(plan) != 1 || !strings.HasPrefix(plan[0], `find edges using outgoing edges of "f7"`) {
+		t.Fatalf("Expected to start from f7, got %v", plan)
+	}
+	plan = explain(t, g, Query{Type: "find_edges", From: "f7", To: "parse"})
+	if len(plan) != 1 || !strings.HasPrefix(plan[0], `find edges using incoming edges of "parse"`) {
+		t.Fatalf("Expected to start from parse, got %v", plan)
+	}
+	result, err = g.Query(ctx, Query{Type: "find_edges", From: "f7", To: "parse"})
+	if err != nil || len(result.Edges) != 1 {
+		t.Fatalf("Expected one edge, got %+v (%v)", result, err)
+	}
+
+	if _, err := g.Query(ctx, Query{Type: "neighbors", Node: "log", Explain: true}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("Expected explain of a neighbors query to fail, got %v", err)
+	}
+}
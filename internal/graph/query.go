@@ -3,6 +3,7 @@ package graph
 import (
 	"context"
 	"fmt"
+	"strings"
 )
 
 // QueryEngine handles complex graph queries
@@ -19,13 +20,27 @@ func NewQueryEngine(graph Graph) *QueryEngine {
 
 // Query executes a graph query and returns results
 func (qe *QueryEngine) Query(ctx context.Context, query Query) (*QueryResult, error) {
+	if query.Explain && query.Type != "find" && query.Type != "find_edges" && query.Type != "match" {
+		return nil, fmt.Errorf("%w: explain is only supported for find, find_edges and match queries", ErrInvalidQuery)
+	}
+
 	switch query.Type {
 	case "neighbors":
 		return qe.queryNeighbors(ctx, query)
 	case "paths":
 		return qe.queryPaths(ctx, query)
+	case "shortest_path":
+		return qe.queryShortestPath(ctx, query)
+	case "weighted_shortest_path":
+		return qe.queryWeightedShortestPath(ctx, query)
 	case "find":
 		return qe.queryFind(ctx, query)
+	case "find_edges":
+		return qe.queryFindEdges(ctx, query)
+	case "subgraph":
+		return qe.querySubgraph(ctx, query)
+	case "match":
+		return qe.queryMatch(ctx, query)
 	default:
 		return nil, fmt.Errorf("unknown query type: %s", query.Type)
 	}
@@ -42,22 +57,24 @@ func (qe *QueryEngine) queryNeighbors(ctx context.Context, query Query) (*QueryR
 		direction = "both" // default to both directions
 	}
 
-	neighbors, err := qe.graph.GetNeighbors(ctx, query.Node, direction)
+	edges, err := qe.graph.GetEdges(ctx, query.Node, direction)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get neighbors: %w", err)
 	}
 
 	// Filter by label if specified
 	if query.Label != "" {
-		filteredNeighbors, err := qe.filterNeighborsByLabel(ctx, query.Node, neighbors, query.Label, direction)
-		if err != nil {
-			return nil, fmt.Errorf("failed to filter neighbors by label: %w", err)
-		}
-		neighbors = filteredNeighbors
+		edges = qe.filterEdgesByLabel(edges, query.Label)
+	}
+
+	neighbors, err := qe.collectNeighbors(ctx, query.Node, edges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve neighbors: %w", err)
 	}
 
 	return &QueryResult{
 		Nodes: neighbors,
+		Edges: edges,
 	}, nil
 }
 
@@ -76,7 +93,12 @@ func (qe *QueryEngine) queryPaths(ctx context.Context, query Query) (*QueryResul
 		return nil, ErrMaxDepthExceeded
 	}
 
-	paths, err := qe.findPaths(ctx, query.From, query.To, maxDepth, query.Label)
+	direction := query.Direction
+	if direction == "" {
+		direction = "out" // paths follow edge direction by default
+	}
+
+	paths, err := qe.findPaths(ctx, query.From, query.To, maxDepth, direction, allowedLabels(query))
 	if err != nil {
 		return nil, fmt.Errorf("failed to find paths: %w", err)
 	}
@@ -88,43 +110,272 @@ func (qe *QueryEngine) queryPaths(ctx context.Context, query Query) (*QueryResul
 
 // queryFind handles property-based search queries
 func (qe *QueryEngine) queryFind(ctx context.Context, query Query) (*QueryResult, error) {
-	if len(query.Filters) == 0 {
-		return nil, fmt.Errorf("filters are required for find queries")
+	if len(query.Filters) == 0 && query.Where == nil {
+		return nil, fmt.Errorf("filters or a where expression are required for find queries")
 	}
 
-	var nodes []Node
-
-	// Check if searching by type
-	if nodeType, exists := query.Filters["type"]; exists {
-		typeNodes, err := qe.graph.GetNodesByType(ctx, nodeType)
+	var where *compiledFilter
+	if query.Where != nil {
+		compiled, err := compileFilter(*query.Where)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get nodes by type: %w", err)
+			return nil, err
 		}
-		nodes = typeNodes
-	} else {
-		// For now, we don't have a way to iterate all nodes
-		// This would need to be added to the Graph interface
-		return nil, fmt.Errorf("property-based search without type filter not yet implemented")
+		where = compiled
+	}
+
+	p := qe.newPlanner()
+	constraints := newFindConstraints(query)
+	access := p.access(constraints)
+	if query.Explain {
+		matched := access.estimate * p.selectivity(constraints, access)
+		return &QueryResult{Plan: []string{p.describe(
+			"find using "+access.String(),
+			fmt.Sprintf("~%s candidates, ~%s matches", formatEstimate(access.estimate), formatEstimate(matched)))}}, nil
+	}
+
+	nodes, err := qe.findCandidates(ctx, access)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply additional property filters
 	filteredNodes := qe.filterNodesByProperties(nodes, query.Filters)
 
+	// Apply the structured filter expression
+	if where != nil {
+		matched := filteredNodes[:0]
+		for _, node := range filteredNodes {
+			if where.match(nodeFields(node)) {
+				matched = append(matched, node)
+			}
+		}
+		filteredNodes = matched
+	}
+
 	return &QueryResult{
 		Nodes: filteredNodes,
 	}, nil
 }
 
-// filterNeighborsByLabel filters neighbors by edge label
-func (qe *QueryEngine) filterNeighborsByLabel(ctx context.Context, nodeID string, neighbors []Node, label, direction string) ([]Node, error) {
-	// This is a simplified implementation
-	// In a real system, we'd need access to the edges to filter properly
-	// For now, we'll return all neighbors (no filtering by label)
+// queryFindEdges handles edge search queries. Filters match edge properties;
+// Where may also reference "label", "from" and "to".
+func (qe *QueryEngine) queryFindEdges(ctx context.Context, query Query) (*QueryResult, error) {
+	var where *compiledFilter
+	if query.Where != nil {
+		compiled, err := compileFilter(*query.Where)
+		if err != nil {
+			return nil, err
+		}
+		where = compiled
+	}
+
+	p := qe.newPlanner()
+	access := p.edgeAccess(query)
+	if query.Explain {
+		return &QueryResult{Plan: []string{p.describe(
+			"find edges using "+access.String(),
+			fmt.Sprintf("~%s candidates", formatEstimate(access.estimate)))}}, nil
+	}
+
+	edges, err := qe.edgeCandidates(ctx, access)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := allowedLabels(query)
+	nodeTypes := make(map[string]string) // node_id -> type, cached across edges
+
+	filtered := make([]Edge, 0, len(edges))
+	for _, edge := range edges {
+		if labels != nil && !labels[edge.Label] {
+			continue
+		}
+		if query.From != "" && edge.From != query.From {
+			continue
+		}
+		if query.To != "" && edge.To != query.To {
+			continue
+		}
+		if !matchesProps(edge.Props, query.Filters) {
+			continue
+		}
+		if query.FromType != "" {
+			nodeType, err := qe.nodeType(ctx, edge.From, nodeTypes)
+			if err != nil {
+				return nil, err
+			}
+			if nodeType != query.FromType {
+				continue
+			}
+		}
+		if query.ToType != "" {
+			nodeType, err := qe.nodeType(ctx, edge.To, nodeTypes)
+			if err != nil {
+				return nil, err
+			}
+			if nodeType != query.ToType {
+				continue
+			}
+		}
+		if where != nil && !where.match(edgeFields(edge)) {
+			continue
+		}
+		filtered = append(filtered, edge)
+	}
+
+	return &QueryResult{
+		Edges: filtered,
+	}, nil
+}
+
+// edgeCandidates returns the edges an edge access path starts from
+func (qe *QueryEngine) edgeCandidates(ctx context.Context, access edgeAccessPath) ([]Edge, error) {
+	var (
+		edges []Edge
+		err   error
+	)
+
+	if access.node != "" {
+		edges, err = qe.graph.GetEdges(ctx, access.node, access.direction)
+	} else {
+		edges, err = qe.graph.GetAllEdges(ctx)
+	}
+
+	if err == ErrNodeNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get edges: %w", err)
+	}
+	return edges, nil
+}
+
+// nodeType returns the type of a node, memoizing lookups in cache
+func (qe *QueryEngine) nodeType(ctx context.Context, id string, cache map[string]string) (string, error) {
+	if nodeType, ok := cache[id]; ok {
+		return nodeType, nil
+	}
+
+	node, err := qe.graph.GetNode(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get node %s: %w", id, err)
+	}
+
+	cache[id] = node.Type
+	return node.Type, nil
+}
+
+// findConstraints are the equality conditions every find result must satisfy,
+// used to choose the starting index
+type findConstraints struct {
+	id       string
+	nodeType string
+	props    map[string]string
+}
+
+// newFindConstraints merges the flat filters with the equality conditions of
+// the where expression
+func newFindConstraints(query Query) findConstraints {
+	constraints := findConstraints{props: make(map[string]string)}
+
+	for key, value := range query.Filters {
+		if key == "type" {
+			constraints.nodeType = value
+		} else {
+			constraints.props[key] = value
+		}
+	}
+
+	for field, value := range equalityConstraints(query.Where) {
+		switch field {
+		case "id":
+			constraints.id = value
+		case "type":
+			if constraints.nodeType == "" {
+				constraints.nodeType = value
+			}
+		default:
+			key := strings.TrimPrefix(field, "props.")
+			if _, exists := constraints.props[key]; !exists {
+				constraints.props[key] = value
+			}
+		}
+	}
+
+	return constraints
+}
+
+// findCandidates returns the nodes an access path starts from
+func (qe *QueryEngine) findCandidates(ctx context.Context, access accessPath) ([]Node, error) {
+	switch access.kind {
+	case accessID:
+		node, err := qe.graph.GetNode(ctx, access.key)
+		if err == ErrNodeNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node: %w", err)
+		}
+		return []Node{*node}, nil
+
+	case accessProperty:
+		nodes, err := qe.graph.GetNodesByProperty(ctx, access.key, access.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nodes by property: %w", err)
+		}
+		return nodes, nil
+
+	case accessType:
+		nodes, err := qe.graph.GetNodesByType(ctx, access.key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nodes by type: %w", err)
+		}
+		return nodes, nil
+	}
+
+	nodes, err := qe.graph.GetAllNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	return nodes, nil
+}
+
+// filterEdgesByLabel returns the edges carrying the given label
+func (qe *QueryEngine) filterEdgesByLabel(edges []Edge, label string) []Edge {
+	var filtered []Edge
+	for _, edge := range edges {
+		if edge.Label == label {
+			filtered = append(filtered, edge)
+		}
+	}
+	return filtered
+}
+
+// collectNeighbors resolves the distinct nodes on the far side of the given edges
+func (qe *QueryEngine) collectNeighbors(ctx context.Context, nodeID string, edges []Edge) ([]Node, error) {
+	seen := make(map[string]bool)
+	neighbors := make([]Node, 0, len(edges))
+
+	for _, edge := range edges {
+		neighborID := otherEnd(edge, nodeID)
+		if seen[neighborID] {
+			continue
+		}
+		seen[neighborID] = true
+
+		node, err := qe.graph.GetNode(ctx, neighborID)
+		if err != nil {
+			return nil, err
+		}
+		neighbors = append(neighbors, *node)
+	}
+
 	return neighbors, nil
 }
 
-// findPaths finds all paths between two nodes using BFS
-func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth int, _ string) ([]Path, error) {
+// findPaths finds all simple paths between two nodes using BFS, following
+// edges in the given direction and restricted to the allowed labels (nil allows all)
+func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth int, direction string, labels map[string]bool) ([]Path, error) {
 	if !qe.graph.NodeExists(ctx, from) {
 		return nil, fmt.Errorf("from node '%s' does not exist", from)
 	}
@@ -138,7 +389,7 @@ func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth
 		if err != nil {
 			return nil, err
 		}
-		return []Path{{Nodes: []Node{*node}}}, nil
+		return []Path{{Nodes: []Node{*node}, Edges: []Edge{}}}, nil
 	}
 
 	var paths []Path
@@ -151,6 +402,11 @@ func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth
 	}}
 
 	for len(queue) > 0 {
+		// Path enumeration can be long running, so stop once the caller gives up
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		current := queue[0]
 		queue = queue[1:]
 
@@ -158,31 +414,43 @@ func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth
 			continue
 		}
 
-		neighbors, err := qe.graph.GetNeighbors(ctx, current.currentNode, "out")
+		edges, err := qe.graph.GetEdges(ctx, current.currentNode, direction)
 		if err != nil {
+			if err == ErrInvalidDirection {
+				return nil, err
+			}
 			continue
 		}
 
-		for _, neighbor := range neighbors {
+		for _, edge := range edges {
+			if labels != nil && !labels[edge.Label] {
+				continue
+			}
+
+			neighborID := otherEnd(edge, current.currentNode)
+
 			// Avoid cycles in current path
-			if contains(current.path, neighbor.ID) {
+			if contains(current.path, neighborID) {
 				continue
 			}
 
-			newPath := append(current.path, neighbor.ID)
+			// Copy so sibling branches never share a backing array
+			newPath := append(append([]string{}, current.path...), neighborID)
+			newEdges := append(append([]Edge{}, current.edges...), edge)
 
-			if neighbor.ID == to {
+			if neighborID == to {
 				// Found a path to target
 				pathNodes, err := qe.buildPathNodes(ctx, newPath)
 				if err != nil {
 					continue
 				}
-				paths = append(paths, Path{Nodes: pathNodes})
+				paths = append(paths, Path{Nodes: pathNodes, Edges: newEdges})
 			} else {
 				// Continue exploring
 				queue = append(queue, pathState{
-					currentNode: neighbor.ID,
+					currentNode: neighborID,
 					path:        newPath,
+					edges:       newEdges,
 					depth:       current.depth + 1,
 				})
 			}
@@ -196,6 +464,7 @@ func (qe *QueryEngine) findPaths(ctx context.Context, from, to string, maxDepth
 type pathState struct {
 	currentNode string
 	path        []string
+	edges       []Edge
 	depth       int
 }
 
@@ -214,6 +483,31 @@ func (qe *QueryEngine) buildPathNodes(ctx context.Context, nodeIDs []string) ([]
 	return nodes, nil
 }
 
+// allowedLabels builds the set of edge labels a query may traverse, merging
+// Label and Labels. A nil set means every label is allowed.
+func allowedLabels(query Query) map[string]bool {
+	if query.Label == "" && len(query.Labels) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]bool, len(query.Labels)+1)
+	if query.Label != "" {
+		labels[query.Label] = true
+	}
+	for _, label := range query.Labels {
+		labels[label] = true
+	}
+	return labels
+}
+
+// otherEnd returns the endpoint of an edge opposite to nodeID
+func otherEnd(edge Edge, nodeID string) string {
+	if edge.From == nodeID {
+		return edge.To
+	}
+	return edge.From
+}
+
 // filterNodesByProperties filters nodes based on property criteria
 func (qe *QueryEngine) filterNodesByProperties(nodes []Node, filters map[string]string) []Node {
 	var filtered []Node
@@ -228,7 +522,7 @@ func (qe *QueryEngine) filterNodesByProperties(nodes []Node, filters map[string]
 					break
 				}
 			} else {
-				if node.Props == nil || node.Props[key] != value {
+				if node.Props == nil || node.Props[key].String() != value {
 					matches = false
 					break
 				}
@@ -243,6 +537,17 @@ func (qe *QueryEngine) filterNodesByProperties(nodes []Node, filters map[string]
 	return filtered
 }
 
+// matchesProps reports whether props holds every key in filters with a value
+// whose text form is the filter value
+func matchesProps(props map[string]Value, filters map[string]string) bool {
+	for key, value := range filters {
+		if propValue, ok := props[key]; !ok || propValue.String() != value {
+			return false
+		}
+	}
+	return true
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -255,6 +560,10 @@ func contains(slice []string, item string) bool {
 
 // Implement the Query method for MemoryGraph to satisfy the Graph interface
 func (g *MemoryGraph) Query(ctx context.Context, query Query) (*QueryResult, error) {
+	if query.AsOf != nil {
+		return nil, ErrHistoryUnavailable
+	}
+
 	queryEngine := NewQueryEngine(g)
 	return queryEngine.Query(ctx, query)
 }
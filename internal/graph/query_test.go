@@ -0,0 +1,370 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestQueryEngine_NeighborsByLabel(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	nodes := []Node{
+		{ID: "func:main", Type: "function"},
+		{ID: "func:helper", Type: "function"},
+		{ID: "pkg:fmt", Type: "package"},
+		{ID: "func:caller", Type: "function"},
+	}
+
+	for _, node := range nodes {
+		g.AddNode(ctx, node)
+	}
+
+	edges := []Edge{
+		{From: "func:main", To: "func:helper", Label: "calls"},
+		{From: "func:main", To: "pkg:fmt", Label: "imports"},
+		{From: "func:caller", To: "func:main", Label: "calls"},
+	}
+
+	for _, edge := range edges {
+		g.AddEdge(ctx, edge)
+	}
+
+	// Outgoing "calls" edges only
+	result, err := g.Query(ctx, Query{Type: "neighbors", Node: "func:main", Direction: "out", Label: "calls"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 1 || result.Nodes[0].ID != "func:helper" {
+		t.Fatalf("Expected only func:helper, got %v", result.Nodes)
+	}
+
+	if len(result.Edges) != 1 || result.Edges[0].Label != "calls" || result.Edges[0].To != "func:helper" {
+		t.Fatalf("Expected the connecting calls edge, got %v", result.Edges)
+	}
+
+	// Both directions with label
+	result, err = g.Query(ctx, Query{Type: "neighbors", Node: "func:main", Direction: "both", Label: "calls"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 2 || len(result.Edges) != 2 {
+		t.Fatalf("Expected 2 neighbors and 2 edges, got %d and %d", len(result.Nodes), len(result.Edges))
+	}
+
+	// No label returns every connecting edge
+	result, err = g.Query(ctx, Query{Type: "neighbors", Node: "func:main"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 3 || len(result.Edges) != 3 {
+		t.Fatalf("Expected 3 neighbors and 3 edges, got %d and %d", len(result.Nodes), len(result.Edges))
+	}
+
+	// Unknown label yields nothing
+	result, err = g.Query(ctx, Query{Type: "neighbors", Node: "func:main", Label: "defined_in"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Nodes) != 0 || len(result.Edges) != 0 {
+		t.Fatalf("Expected no neighbors, got %v", result.Nodes)
+	}
+}
+
+func TestQueryEngine_PathsWithLabels(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	for _, id := range []string{"mod:a", "mod:b", "mod:c"} {
+		g.AddNode(ctx, Node{ID: id, Type: "module"})
+	}
+
+	edges := []Edge{
+		{From: "mod:a", To: "mod:b", Label: "calls"},
+		{From: "mod:a", To: "mod:b", Label: "imports"},
+		{From: "mod:b", To: "mod:c", Label: "calls"},
+	}
+
+	for _, edge := range edges {
+		g.AddEdge(ctx, edge)
+	}
+
+	// Every label: the two parallel a->b edges give two distinct paths
+	result, err := g.Query(ctx, Query{Type: "paths", From: "mod:a", To: "mod:c"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %d", len(result.Paths))
+	}
+
+	for _, path := range result.Paths {
+		if len(path.Edges) != len(path.Nodes)-1 {
+			t.Fatalf("Expected %d edges, got %d", len(path.Nodes)-1, len(path.Edges))
+		}
+	}
+
+	// Only calls edges
+	result, err = g.Query(ctx, Query{Type: "paths", From: "mod:a", To: "mod:c", Labels: []string{"calls"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 1 {
+		t.Fatalf("Expected 1 path, got %d", len(result.Paths))
+	}
+
+	for _, edge := range result.Paths[0].Edges {
+		if edge.Label != "calls" {
+			t.Fatalf("Expected only calls edges, got %s", edge.Label)
+		}
+	}
+
+	// Following edges forward from c finds nothing, backwards finds both paths
+	result, err = g.Query(ctx, Query{Type: "paths", From: "mod:c", To: "mod:a"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 0 {
+		t.Fatalf("Expected no paths, got %d", len(result.Paths))
+	}
+
+	result, err = g.Query(ctx, Query{Type: "paths", From: "mod:c", To: "mod:a", Direction: "in"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 2 {
+		t.Fatalf("Expected 2 paths, got %d", len(result.Paths))
+	}
+
+	if first := result.Paths[0].Edges[0]; first.From != "mod:b" || first.To != "mod:c" {
+		t.Fatalf("Expected first edge mod:b -> mod:c, got %s -> %s", first.From, first.To)
+	}
+
+	// Invalid direction is reported
+	if _, err := g.Query(ctx, Query{Type: "paths", From: "mod:a", To: "mod:c", Direction: "up"}); err == nil {
+		t.Fatalf("Expected error for invalid direction")
+	}
+}
+
+func TestQueryEngine_ShortestPath(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	// a -> b -> c -> d and a shortcut a -> d via "imports"
+	for _, id := range []string{"a", "b", "c", "d", "island"} {
+		g.AddNode(ctx, Node{ID: id, Type: "module"})
+	}
+
+	edges := []Edge{
+		{From: "a", To: "b", Label: "calls", Props: StringProps(map[string]string{"cost": "1"})},
+		{From: "b", To: "c", Label: "calls", Props: StringProps(map[string]string{"cost": "1"})},
+		{From: "c", To: "d", Label: "calls", Props: StringProps(map[string]string{"cost": "1"})},
+		{From: "a", To: "d", Label: "imports", Props: StringProps(map[string]string{"cost": "10"})},
+	}
+
+	for _, edge := range edges {
+		g.AddEdge(ctx, edge)
+	}
+
+	result, err := g.Query(ctx, Query{Type: "shortest_path", From: "a", To: "d"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 1 || len(result.Paths[0].Edges) != 1 || result.Paths[0].Edges[0].Label != "imports" {
+		t.Fatalf("Expected the one-hop imports path, got %v", result.Paths)
+	}
+
+	// Restricting to calls forces the long way round
+	result, err = g.Query(ctx, Query{Type: "shortest_path", From: "a", To: "d", Labels: []string{"calls"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 1 || len(result.Paths[0].Nodes) != 4 {
+		t.Fatalf("Expected a 4-node path, got %v", result.Paths)
+	}
+
+	// Depth limit cuts the long path off
+	result, err = g.Query(ctx, Query{Type: "shortest_path", From: "a", To: "d", Labels: []string{"calls"}, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 0 {
+		t.Fatalf("Expected no path within 2 hops, got %v", result.Paths)
+	}
+
+	// Unreachable target
+	result, err = g.Query(ctx, Query{Type: "shortest_path", From: "a", To: "island"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 0 {
+		t.Fatalf("Expected no path, got %v", result.Paths)
+	}
+}
+
+func TestQueryEngine_WeightedShortestPath(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		g.AddNode(ctx, Node{ID: id, Type: "module"})
+	}
+
+	edges := []Edge{
+		{From: "a", To: "b", Label: "calls", Props: StringProps(map[string]string{"cost": "1"})},
+		{From: "b", To: "c", Label: "calls", Props: StringProps(map[string]string{"cost": "1.5"})},
+		{From: "c", To: "d", Label: "calls"}, // defaults to a weight of 1
+		{From: "a", To: "d", Label: "imports", Props: StringProps(map[string]string{"cost": "10"})},
+	}
+
+	for _, edge := range edges {
+		g.AddEdge(ctx, edge)
+	}
+
+	result, err := g.Query(ctx, Query{Type: "weighted_shortest_path", From: "a", To: "d", WeightProp: "cost"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(result.Paths) != 1 {
+		t.Fatalf("Expected 1 path, got %d", len(result.Paths))
+	}
+
+	path := result.Paths[0]
+	if len(path.Edges) != 3 || path.Cost != 3.5 {
+		t.Fatalf("Expected the 3-hop path with cost 3.5, got %d hops with cost %v", len(path.Edges), path.Cost)
+	}
+
+	// Missing weight property is rejected
+	if _, err := g.Query(ctx, Query{Type: "weighted_shortest_path", From: "a", To: "d"}); err == nil {
+		t.Fatalf("Expected error without weight property")
+	}
+
+	// Weights that aren't finite non-negative numbers are reported
+	for _, cost := range []Value{StringValue("cheap"), FloatValue(-1), FloatValue(math.NaN()), FloatValue(math.Inf(1)), StringValue("-Inf")} {
+		g.AddEdge(ctx, Edge{From: "a", To: "c", Label: "uses", Props: map[string]Value{"cost": cost}})
+		if _, err := g.Query(ctx, Query{Type: "weighted_shortest_path", From: "a", To: "d", WeightProp: "cost"}); !errors.Is(err, ErrInvalidWeight) {
+			t.Fatalf("Expected ErrInvalidWeight for cost %s, got %v", cost, err)
+		}
+		g.DeleteEdge(ctx, "a", "c", "uses")
+	}
+}
+
+func TestQueryEngine_FindWithoutType(t *testing.T) {
+	ctx := context.Background()
+
+	for _, indexed := range []bool{false, true} {
+		g := NewMemoryGraph()
+		if indexed {
+			g.CreatePropertyIndex("path")
+		}
+
+		g.AddNode(ctx, Node{ID: "file:login", Type: "file", Props: StringProps(map[string]string{"path": "auth/login.go", "lang": "go"})})
+		g.AddNode(ctx, Node{ID: "func:login", Type: "function", Props: StringProps(map[string]string{"path": "auth/login.go", "lang": "go"})})
+		g.AddNode(ctx, Node{ID: "file:main", Type: "file", Props: StringProps(map[string]string{"path": "main.go", "lang": "go"})})
+
+		result, err := g.Query(ctx, Query{Type: "find", Filters: map[string]string{"path": "auth/login.go"}})
+		if err != nil {
+			t.Fatalf("Expected no error (indexed=%v), got %v", indexed, err)
+		}
+
+		if len(result.Nodes) != 2 {
+			t.Fatalf("Expected 2 nodes (indexed=%v), got %d", indexed, len(result.Nodes))
+		}
+
+		// Remaining filters still apply to indexed candidates
+		result, err = g.Query(ctx, Query{Type: "find", Filters: map[string]string{"path": "auth/login.go", "type": "file"}})
+		if err != nil {
+			t.Fatalf("Expected no error (indexed=%v), got %v", indexed, err)
+		}
+
+		if len(result.Nodes) != 1 || result.Nodes[0].ID != "file:login" {
+			t.Fatalf("Expected only file:login (indexed=%v), got %v", indexed, result.Nodes)
+		}
+	}
+}
+
+func TestQueryEngine_FindEdges(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.AddNode(ctx, Node{ID: "prompt:a", Type: "prompt"})
+	g.AddNode(ctx, Node{ID: "prompt:b", Type: "prompt"})
+	g.AddNode(ctx, Node{ID: "func:x", Type: "function"})
+	g.AddNode(ctx, Node{ID: "func:y", Type: "function"})
+	g.AddNode(ctx, Node{ID: "file:z", Type: "file"})
+
+	g.AddEdge(ctx, Edge{From: "func:x", To: "prompt:a", Label: "generated_from", Props: StringProps(map[string]string{"model": "m1"})})
+	g.AddEdge(ctx, Edge{From: "func:y", To: "prompt:a", Label: "generated_from", Props: StringProps(map[string]string{"model": "m2"})})
+	g.AddEdge(ctx, Edge{From: "file:z", To: "prompt:b", Label: "generated_from"})
+	g.AddEdge(ctx, Edge{From: "func:x", To: "func:y", Label: "calls"})
+
+	testCases := []struct {
+		name  string
+		query Query
+		want  int
+	}{
+		{"by label", Query{Label: "generated_from"}, 3},
+		{"by target", Query{Label: "generated_from", To: "prompt:a"}, 2},
+		{"by source", Query{From: "func:x"}, 2},
+		{"by source and target", Query{From: "func:x", To: "func:y"}, 1},
+		{"by source type", Query{Label: "generated_from", FromType: "function"}, 2},
+		{"by target type", Query{ToType: "function"}, 1},
+		{"by props", Query{Filters: map[string]string{"model": "m2"}}, 1},
+		{"by where", Query{Where: &Filter{Field: "to", Op: OpPrefix, Value: "prompt:"}}, 3},
+		{"unknown node", Query{From: "missing"}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.query.Type = "find_edges"
+			result, err := g.Query(ctx, tc.query)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(result.Edges) != tc.want {
+				t.Fatalf("Expected %d edges, got %v", tc.want, result.Edges)
+			}
+		})
+	}
+}
+
+func TestQueryEngine_Canceled(t *testing.T) {
+	g := NewMemoryGraph()
+	g.AddNode(context.Background(), Node{ID: "a"})
+	g.AddNode(context.Background(), Node{ID: "b"})
+	g.AddEdge(context.Background(), Edge{From: "a", To: "b", Label: "links", Props: StringProps(map[string]string{"w": "1"})})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	queries := []Query{
+		{Type: "paths", From: "a", To: "b", MaxDepth: 3},
+		{Type: "shortest_path", From: "a", To: "b"},
+		{Type: "weighted_shortest_path", From: "a", To: "b", WeightProp: "w"},
+	}
+	for _, query := range queries {
+		if _, err := g.Query(ctx, query); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected %s to stop with context.Canceled, got %v", query.Type, err)
+		}
+	}
+
+	if _, _, err := Neighborhood(ctx, g, "a", 2, "both"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected Neighborhood to stop with context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Schema modes
+const (
+	SchemaStrict = "strict" // writes that violate the schema are rejected
+	SchemaWarn   = "warn"   // writes that violate the schema are made and reported
+)
+
+// AnyNodeType matches every node type in an edge connection
+const AnyNodeType = "*"
+
+// Schema declares the conventions of a graph: its node types and their
+// properties, and which node types each edge label may connect. Unless Open
+// is set, node types and edge labels the schema doesn't declare are
+// violations too; nodes without a type are always allowed.
+type Schema struct {
+	Mode       string                     `json:"mode,omitempty"` // SchemaStrict (default) or SchemaWarn
+	Open       bool                       `json:"open,omitempty"` // allow undeclared node types and edge labels
+	NodeTypes  map[string]NodeTypeSchema  `json:"node_types,omitempty"`
+	EdgeLabels map[string]EdgeLabelSchema `json:"edge_labels,omitempty"`
+
+	patterns map[string]*regexp.Regexp // compiled property patterns, by source
+}
+
+// NodeTypeSchema declares the properties of a node type
+type NodeTypeSchema struct {
+	Description          string                    `json:"description,omitempty"`
+	Properties           map[string]PropertySchema `json:"properties,omitempty"`
+	AdditionalProperties bool                      `json:"additional_properties,omitempty"` // allow undeclared properties
+}
+
+// EdgeLabelSchema declares the node types an edge label may connect and its
+// properties. Without connections the label may connect any nodes.
+type EdgeLabelSchema struct {
+	Description          string                    `json:"description,omitempty"`
+	Connections          []EdgeConnection          `json:"connections,omitempty"`
+	Properties           map[string]PropertySchema `json:"properties,omitempty"`
+	AdditionalProperties bool                      `json:"additional_properties,omitempty"` // allow undeclared properties
+}
+
+// EdgeConnection is an allowed pair of source and target node types;
+// AnyNodeType matches every type
+type EdgeConnection struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// PropertySchema declares a property. Type is the name of the Kind its
+// values must have, where an int also satisfies "float"; Pattern is a
+// regular expression the whole text form of the value must match.
+type PropertySchema struct {
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Pattern     string `json:"pattern,omitempty"`
+}
+
+// SchemaProvider is implemented by graphs that keep a schema for Operations
+// to enforce
+type SchemaProvider interface {
+	// Schema returns the graph's schema, or nil if it has none
+	Schema() *Schema
+
+	// SetSchema replaces the graph's schema; nil removes it
+	SetSchema(schema *Schema) error
+}
+
+// ParseSchema decodes and validates a JSON schema definition
+func ParseSchema(data []byte) (*Schema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var schema Schema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// Validate checks the mode, connections and property patterns of a schema
+// and compiles the patterns
+func (s *Schema) Validate() error {
+	if s.Mode != "" && s.Mode != SchemaStrict && s.Mode != SchemaWarn {
+		return fmt.Errorf("%w: mode must be '%s' or '%s'", ErrInvalidSchema, SchemaStrict, SchemaWarn)
+	}
+
+	s.patterns = make(map[string]*regexp.Regexp)
+	compile := func(owner string, properties map[string]PropertySchema) error {
+		for key, prop := range properties {
+			if key == "" {
+				return fmt.Errorf("%w: %s declares an empty property key", ErrInvalidSchema, owner)
+			}
+			if prop.Type != "" {
+				if _, err := ParseKind(prop.Type); err != nil {
+					return fmt.Errorf("%w: %s property %s: %v", ErrInvalidSchema, owner, key, err)
+				}
+			}
+			if prop.Pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile("^(?:" + prop.Pattern + ")$")
+			if err != nil {
+				return fmt.Errorf("%w: %s property %s: %v", ErrInvalidSchema, owner, key, err)
+			}
+			s.patterns[prop.Pattern] = re
+		}
+		return nil
+	}
+
+	for name, nodeType := range s.NodeTypes {
+		if name == "" {
+			return fmt.Errorf("%w: node type names cannot be empty", ErrInvalidSchema)
+		}
+		if err := compile("node type "+name, nodeType.Properties); err != nil {
+			return err
+		}
+	}
+	for label, edgeLabel := range s.EdgeLabels {
+		if label == "" {
+			return fmt.Errorf("%w: edge labels cannot be empty", ErrInvalidSchema)
+		}
+		for _, conn := range edgeLabel.Connections {
+			if conn.From == "" || conn.To == "" {
+				return fmt.Errorf("%w: edge label %s has a connection without from or to", ErrInvalidSchema, label)
+			}
+		}
+		if err := compile("edge label "+label, edgeLabel.Properties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Strict reports whether violations should be rejected rather than reported
+func (s *Schema) Strict() bool {
+	return s.Mode != SchemaWarn
+}
+
+// CheckNode returns the ways a node violates the schema, in a stable order
+func (s *Schema) CheckNode(node Node) []string {
+	if node.Type == "" {
+		return nil
+	}
+
+	nodeType, declared := s.NodeTypes[node.Type]
+	if !declared {
+		if s.Open {
+			return nil
+		}
+		return []string{fmt.Sprintf("node %s: type %s is not declared", strconv.Quote(node.ID), strconv.Quote(node.Type))}
+	}
+
+	subject := fmt.Sprintf("node %s", strconv.Quote(node.ID))
+	return s.checkProps(subject, "type "+strconv.Quote(node.Type), node.Props, nodeType.Properties, nodeType.AdditionalProperties)
+}
+
+// CheckEdge returns the ways an edge between nodes of the given types
+// violates the schema, in a stable order
+func (s *Schema) CheckEdge(edge Edge, fromType, toType string) []string {
+	subject := fmt.Sprintf("edge %s -[%s]-> %s", strconv.Quote(edge.From), edge.Label, strconv.Quote(edge.To))
+
+	edgeLabel, declared := s.EdgeLabels[edge.Label]
+	if !declared {
+		if s.Open {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: label %s is not declared", subject, strconv.Quote(edge.Label))}
+	}
+
+	var violations []string
+	if len(edgeLabel.Connections) > 0 {
+		allowed := false
+		for _, conn := range edgeLabel.Connections {
+			if (conn.From == AnyNodeType || conn.From == fromType) && (conn.To == AnyNodeType || conn.To == toType) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("%s: label %s may not connect %s to %s",
+				subject, strconv.Quote(edge.Label), describeNodeType(fromType), describeNodeType(toType)))
+		}
+	}
+
+	return append(violations, s.checkProps(subject, "label "+strconv.Quote(edge.Label), edge.Props, edgeLabel.Properties, edgeLabel.AdditionalProperties)...)
+}
+
+// checkProps checks properties against their declarations
+func (s *Schema) checkProps(subject, owner string, props map[string]Value, declared map[string]PropertySchema, additional bool) []string {
+	var violations []string
+
+	keys := make([]string, 0, len(declared))
+	for key := range declared {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop := declared[key]
+		value, exists := props[key]
+		if !exists {
+			if prop.Required {
+				violations = append(violations, fmt.Sprintf("%s: required property %s is missing", subject, key))
+			}
+			continue
+		}
+		if prop.Type != "" && !kindSatisfies(value.Kind(), prop.Type) {
+			violations = append(violations, fmt.Sprintf("%s: property %s is a %s, expected %s",
+				subject, key, value.Kind(), prop.Type))
+		}
+		if prop.Pattern != "" && !s.matchPattern(prop.Pattern, value.String()) {
+			violations = append(violations, fmt.Sprintf("%s: property %s value %s does not match pattern %s",
+				subject, key, strconv.Quote(value.String()), prop.Pattern))
+		}
+	}
+
+	if !additional {
+		var undeclared []string
+		for key := range props {
+			if _, exists := declared[key]; !exists {
+				undeclared = append(undeclared, key)
+			}
+		}
+		sort.Strings(undeclared)
+		for _, key := range undeclared {
+			violations = append(violations, fmt.Sprintf("%s: property %s is not declared for %s", subject, key, owner))
+		}
+	}
+
+	return violations
+}
+
+// matchPattern matches a whole value against a property pattern, compiling
+// it if the schema wasn't validated; an invalid pattern matches nothing
+func (s *Schema) matchPattern(pattern, value string) bool {
+	re := s.patterns[pattern]
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile("^(?:" + pattern + ")$"); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(value)
+}
+
+// kindSatisfies reports whether a value of the given kind has the declared type
+func kindSatisfies(kind Kind, declared string) bool {
+	return kind.String() == declared || (kind == KindInt && declared == KindFloat.String())
+}
+
+// describeNodeType names a node type in a violation, including the empty type
+func describeNodeType(nodeType string) string {
+	if nodeType == "" {
+		return "an untyped node"
+	}
+	return strconv.Quote(nodeType)
+}
+
+// Schema returns the graph's schema, or nil if it has none. The schema is
+// shared and must not be modified.
+func (g *MemoryGraph) Schema() *Schema {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.schema
+}
+
+// SetSchema validates and replaces the graph's schema; nil removes it
+func (g *MemoryGraph) SetSchema(schema *Schema) error {
+	if schema != nil {
+		if err := schema.Validate(); err != nil {
+			return err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.schema = schema
+	return nil
+}
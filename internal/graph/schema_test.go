@@ -0,0 +1,183 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const codeSchema = `{
+	"node_types": {
+		"file": {"properties": {"path": {"required": true}}},
+		"function": {
+			"properties": {"language": {"pattern": "go|python"}, "lines": {"type": "int"}, "score": {"type": "float"}},
+			"additional_properties": true
+		}
+	},
+	"edge_labels": {
+		"calls": {"connections": [{"from": "function", "to": "function"}]},
+		"defined_in": {"connections": [{"from": "function", "to": "file"}, {"from": "*", "to": "file"}]}
+	}
+}`
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(codeSchema))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if !schema.Strict() || len(schema.NodeTypes) != 2 || len(schema.EdgeLabels) != 2 {
+		t.Fatalf("Unexpected schema: %+v", schema)
+	}
+
+	invalid := map[string]string{
+		"unknown field": `{"node_type": {}}`,
+		"mode":          `{"mode": "lenient"}`,
+		"pattern":       `{"node_types": {"file": {"properties": {"path": {"pattern": "("}}}}}`,
+		"connection":    `{"edge_labels": {"calls": {"connections": [{"from": "function"}]}}}`,
+		"empty type":    `{"node_types": {"": {}}}`,
+		"value type":    `{"node_types": {"file": {"properties": {"size": {"type": "number"}}}}}`,
+		"not json":      `node_types`,
+	}
+	for name, data := range invalid {
+		if _, err := ParseSchema([]byte(data)); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: expected ErrInvalidSchema, got %v", name, err)
+		}
+	}
+}
+
+func TestSchema_Check(t *testing.T) {
+	schema, err := ParseSchema([]byte(codeSchema))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		violations []string
+	}{
+		{"valid", schema.CheckNode(Node{ID: "main", Type: "function", Props: StringProps(map[string]string{"language": "go", "owner": "core"})})},
+		{"untyped", schema.CheckNode(Node{ID: "note", Props: StringProps(map[string]string{"text": "todo"})})},
+		{"value types", schema.CheckNode(Node{ID: "main", Type: "function", Props: map[string]Value{"lines": StringValue("10"), "score": IntValue(3)}})},
+		{"pattern", schema.CheckNode(Node{ID: "main", Type: "function", Props: StringProps(map[string]string{"language": "golang"})})},
+		{"required and undeclared", schema.CheckNode(Node{ID: "a.go", Type: "file", Props: StringProps(map[string]string{"size": "10"})})},
+		{"undeclared type", schema.CheckNode(Node{ID: "x", Type: "class"})},
+		{"connection", schema.CheckEdge(Edge{From: "a.go", To: "main", Label: "calls"}, "file", "function")},
+		{"wildcard", schema.CheckEdge(Edge{From: "x", To: "a.go", Label: "defined_in"}, "", "file")},
+		{"undeclared label", schema.CheckEdge(Edge{From: "main", To: "a.go", Label: "imports"}, "function", "file")},
+	}
+	want := map[string][]string{
+		"value types": {`node "main": property lines is a string, expected int`},
+		"pattern":     {`node "main": property language value "golang" does not match pattern go|python`},
+		"required and undeclared": {
+			`node "a.go": required property path is missing`,
+			`node "a.go": property size is not declared for type "file"`,
+		},
+		"undeclared type":  {`node "x": type "class" is not declared`},
+		"connection":       {`edge "a.go" -[calls]-> "main": label "calls" may not connect "file" to "function"`},
+		"undeclared label": {`edge "main" -[imports]-> "a.go": label "imports" is not declared`},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.violations, want[tt.name]) {
+			t.Errorf("%s: expected %q, got %q", tt.name, want[tt.name], tt.violations)
+		}
+	}
+
+	schema.Open = true
+	if violations := schema.CheckNode(Node{ID: "x", Type: "class"}); len(violations) != 0 {
+		t.Errorf("Expected an open schema to allow undeclared types, got %q", violations)
+	}
+}
+
+func TestOperations_Schema(t *testing.T) {
+	ctx := context.Background()
+	g := NewMemoryGraph()
+	schema, err := ParseSchema([]byte(codeSchema))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	// Existing data is kept when a schema is set, and reported by ValidateGraph
+	if err := g.AddNode(ctx, Node{ID: "legacy", Type: "class"}); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+	if err := g.SetSchema(schema); err != nil {
+		t.Fatalf("Failed to set schema: %v", err)
+	}
+	ops := NewOperations(g)
+	if err := ops.ValidateGraph(ctx); !errors.Is(err, ErrSchemaViolation) || !strings.Contains(err.Error(), `"legacy"`) {
+		t.Fatalf("Expected the legacy node to violate the schema, got %v", err)
+	}
+
+	// Strict mode rejects violations
+	if err := ops.CreateNode(ctx, Node{ID: "a.go", Type: "file"}); !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected a file without a path to be rejected, got %v", err)
+	}
+	if g.NodeExists(ctx, "a.go") {
+		t.Fatal("Expected the rejected node not to be added")
+	}
+	for _, node := range []Node{
+		{ID: "a.go", Type: "file", Props: StringProps(map[string]string{"path": "src/a.go"})},
+		{ID: "main", Type: "function"},
+	} {
+		if err := ops.CreateNode(ctx, node); err != nil {
+			t.Fatalf("Failed to create node: %v", err)
+		}
+	}
+	if err := ops.CreateEdge(ctx, Edge{From: "a.go", To: "main", Label: "calls"}); !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected a call from a file to be rejected, got %v", err)
+	}
+	if err := ops.CreateEdge(ctx, Edge{From: "main", To: "a.go", Label: "defined_in"}); err != nil {
+		t.Fatalf("Failed to create edge: %v", err)
+	}
+
+	// Updates and batches are checked too, and a rejected batch is rolled back
+	if err := ops.UpdateNode(ctx, "main", map[string]Value{"lines": StringValue("ten")}); !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("Expected a string line count to be rejected, got %v", err)
+	}
+	batch := []BatchOp{
+		{Op: OpUpsertNode, ID: "b.go", Type: "file", Props: StringProps(map[string]string{"path": "src/b.go"})},
+		{Op: OpUpsertEdge, From: "b.go", To: "main", Label: "calls"},
+	}
+	if _, err := ops.ApplyBatch(ctx, batch); !errors.Is(err, ErrSchemaViolation) || !strings.Contains(err.Error(), `may not connect "file" to "function"`) {
+		t.Fatalf("Expected a call from a file to be rejected, got %v", err)
+	}
+	if g.NodeExists(ctx, "b.go") {
+		t.Fatal("Expected the rejected batch to be rolled back")
+	}
+	batch[1].Label = "defined_in"
+	batch[1].From, batch[1].To = "main", "b.go"
+	if _, err := ops.ApplyBatch(ctx, batch); err != nil {
+		t.Fatalf("Failed to apply batch: %v", err)
+	}
+
+	// Warn mode makes the write and reports the violations
+	schema.Mode = SchemaWarn
+	var warnings []string
+	ops.OnSchemaWarning = func(violation string) {
+		warnings = append(warnings, violation)
+	}
+	if err := ops.CreateEdge(ctx, Edge{From: "a.go", To: "main", Label: "calls"}); err != nil {
+		t.Fatalf("Expected warn mode to allow the edge, got %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `may not connect "file" to "function"`) {
+		t.Fatalf("Expected one connection warning, got %q", warnings)
+	}
+
+	violations, err := ops.SchemaViolations(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get violations: %v", err)
+	}
+	if len(violations) != 2 || !strings.HasPrefix(violations[0], `node "legacy"`) || !strings.HasPrefix(violations[1], `edge "a.go"`) {
+		t.Fatalf("Expected the legacy node and the call, got %q", violations)
+	}
+
+	// Removing the schema allows anything
+	if err := g.SetSchema(nil); err != nil {
+		t.Fatalf("Failed to remove schema: %v", err)
+	}
+	if err := ops.ValidateGraph(ctx); err != nil {
+		t.Fatalf("Expected no violations without a schema, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package graph
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// defaultEdgeWeight is used for edges that don't carry the weight property
+const defaultEdgeWeight = 1.0
+
+// queryShortestPath handles unweighted shortest path queries
+func (qe *QueryEngine) queryShortestPath(ctx context.Context, query Query) (*QueryResult, error) {
+	direction, err := qe.validatePathEndpoints(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := qe.shortestPath(ctx, query.From, query.To, query.MaxDepth, direction, allowedLabels(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find shortest path: %w", err)
+	}
+
+	result := &QueryResult{}
+	if path != nil {
+		result.Paths = []Path{*path}
+	}
+	return result, nil
+}
+
+// queryWeightedShortestPath handles shortest path queries weighted by a numeric edge property
+func (qe *QueryEngine) queryWeightedShortestPath(ctx context.Context, query Query) (*QueryResult, error) {
+	if query.WeightProp == "" {
+		return nil, fmt.Errorf("weight property is required for weighted shortest path queries")
+	}
+
+	direction, err := qe.validatePathEndpoints(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := qe.weightedShortestPath(ctx, query.From, query.To, direction, allowedLabels(query), query.WeightProp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find weighted shortest path: %w", err)
+	}
+
+	result := &QueryResult{}
+	if path != nil {
+		result.Paths = []Path{*path}
+	}
+	return result, nil
+}
+
+// validatePathEndpoints checks the from/to nodes of a path query and returns
+// the effective traversal direction
+func (qe *QueryEngine) validatePathEndpoints(ctx context.Context, query Query) (string, error) {
+	if query.From == "" || query.To == "" {
+		return "", fmt.Errorf("both 'from' and 'to' nodes are required for path queries")
+	}
+	if !qe.graph.NodeExists(ctx, query.From) {
+		return "", fmt.Errorf("from node '%s' does not exist", query.From)
+	}
+	if !qe.graph.NodeExists(ctx, query.To) {
+		return "", fmt.Errorf("to node '%s' does not exist", query.To)
+	}
+
+	direction := query.Direction
+	if direction == "" {
+		direction = "out"
+	}
+	return direction, nil
+}
+
+// shortestPath runs a breadth-first search that stops as soon as the target
+// is reached. A maxDepth of zero means the search is unbounded. Returns nil
+// when no path exists.
+func (qe *QueryEngine) shortestPath(ctx context.Context, from, to string, maxDepth int, direction string, labels map[string]bool) (*Path, error) {
+	// parent records the edge used to first reach each visited node
+	parent := map[string]*Edge{from: nil}
+	frontier := []string{from}
+
+	for depth := 0; len(frontier) > 0 && from != to; depth++ {
+		if maxDepth > 0 && depth >= maxDepth {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var next []string
+		for _, nodeID := range frontier {
+			edges, err := qe.graph.GetEdges(ctx, nodeID, direction)
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range edges {
+				edge := edges[i]
+				if labels != nil && !labels[edge.Label] {
+					continue
+				}
+
+				neighborID := otherEnd(edge, nodeID)
+				if _, visited := parent[neighborID]; visited {
+					continue
+				}
+				parent[neighborID] = &edge
+
+				if neighborID == to {
+					return qe.buildPathFromParents(ctx, from, to, parent)
+				}
+				next = append(next, neighborID)
+			}
+		}
+		frontier = next
+	}
+
+	if from == to {
+		return qe.buildPathFromParents(ctx, from, to, parent)
+	}
+	return nil, nil
+}
+
+// weightedShortestPath runs Dijkstra's algorithm using the named numeric edge
+// property as the weight. Returns nil when no path exists.
+func (qe *QueryEngine) weightedShortestPath(ctx context.Context, from, to, direction string, labels map[string]bool, weightProp string) (*Path, error) {
+	dist := map[string]float64{from: 0}
+	parent := map[string]*Edge{from: nil}
+	settled := make(map[string]bool)
+
+	queue := &distanceQueue{{nodeID: from, dist: 0}}
+
+	for queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		current := heap.Pop(queue).(distanceItem)
+		if settled[current.nodeID] {
+			continue
+		}
+		settled[current.nodeID] = true
+
+		if current.nodeID == to {
+			path, err := qe.buildPathFromParents(ctx, from, to, parent)
+			if err != nil {
+				return nil, err
+			}
+			path.Cost = current.dist
+			return path, nil
+		}
+
+		edges, err := qe.graph.GetEdges(ctx, current.nodeID, direction)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range edges {
+			edge := edges[i]
+			if labels != nil && !labels[edge.Label] {
+				continue
+			}
+
+			neighborID := otherEnd(edge, current.nodeID)
+			if settled[neighborID] {
+				continue
+			}
+
+			weight, err := edgeWeight(edge, weightProp)
+			if err != nil {
+				return nil, err
+			}
+
+			candidate := current.dist + weight
+			if known, ok := dist[neighborID]; !ok || candidate < known {
+				dist[neighborID] = candidate
+				parent[neighborID] = &edge
+				heap.Push(queue, distanceItem{nodeID: neighborID, dist: candidate})
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// buildPathFromParents walks parent edges back from the target to assemble a Path
+func (qe *QueryEngine) buildPathFromParents(ctx context.Context, from, to string, parent map[string]*Edge) (*Path, error) {
+	nodeIDs := []string{to}
+	var edges []Edge
+
+	for current := to; current != from; {
+		edge := parent[current]
+		current = otherEnd(*edge, current)
+		nodeIDs = append(nodeIDs, current)
+		edges = append(edges, *edge)
+	}
+
+	// Reverse into from -> to order
+	for i, j := 0, len(nodeIDs)-1; i < j; i, j = i+1, j-1 {
+		nodeIDs[i], nodeIDs[j] = nodeIDs[j], nodeIDs[i]
+	}
+	for i, j := 0, len(edges)-1; i < j; i, j = i+1, j-1 {
+		edges[i], edges[j] = edges[j], edges[i]
+	}
+
+	nodes, err := qe.buildPathNodes(ctx, nodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if edges == nil {
+		edges = []Edge{}
+	}
+	return &Path{Nodes: nodes, Edges: edges}, nil
+}
+
+// edgeWeight reads the weight of an edge from the named property, falling
+// back to defaultEdgeWeight when the property is absent
+func edgeWeight(edge Edge, weightProp string) (float64, error) {
+	raw, ok := edge.Props[weightProp]
+	if !ok {
+		return defaultEdgeWeight, nil
+	}
+
+	weight, err := strconv.ParseFloat(raw.String(), 64)
+	if err != nil || weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0, fmt.Errorf("%w: edge %s -> %s (%s) has %s=%q", ErrInvalidWeight, edge.From, edge.To, edge.Label, weightProp, raw)
+	}
+	return weight, nil
+}
+
+// distanceItem is a node with its tentative distance in Dijkstra's algorithm
+type distanceItem struct {
+	nodeID string
+	dist   float64
+}
+
+// distanceQueue is a min-heap of distanceItems ordered by distance
+type distanceQueue []distanceItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(distanceItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
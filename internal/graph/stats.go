@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// StatisticsProvider is implemented by graphs that keep cardinality
+// statistics, which the query planner uses to choose where to start and in
+// which order to join
+type StatisticsProvider interface {
+	// Statistics returns a snapshot of the graph's cardinality statistics
+	Statistics() Statistics
+
+	// Degree returns the number of edges of a node in a direction ("in",
+	// "out" or "both"), counting only the given labels when any are given
+	Degree(nodeID, direction string, labels ...string) int
+
+	// PropertyCount returns the number of nodes with a property value and
+	// whether the property is indexed; unindexed properties report 0, false
+	PropertyCount(key, value string) (int, bool)
+}
+
+// Statistics summarizes the size and shape of a graph
+type Statistics struct {
+	Nodes       int                    `json:"nodes"`
+	NodesByType map[string]int         `json:"nodes_by_type"`
+	Edges       DegreeStats            `json:"edges"`  // all edges
+	Labels      map[string]DegreeStats `json:"labels"` // edges by label
+}
+
+// DegreeStats describes a set of edges and how they are spread over nodes.
+// The histograms count nodes by degree in powers of two: entry i counts the
+// nodes with a degree between 2^i and 2^(i+1)-1.
+type DegreeStats struct {
+	Count        int   `json:"count"`
+	Sources      int   `json:"sources"` // nodes with at least one outgoing edge
+	Targets      int   `json:"targets"` // nodes with at least one incoming edge
+	MaxOut       int   `json:"max_out"`
+	MaxIn        int   `json:"max_in"`
+	OutHistogram []int `json:"out_histogram"`
+	InHistogram  []int `json:"in_histogram"`
+}
+
+// AvgOut returns the average out-degree of the nodes with outgoing edges
+func (s DegreeStats) AvgOut() float64 {
+	if s.Sources == 0 {
+		return 0
+	}
+	return float64(s.Count) / float64(s.Sources)
+}
+
+// AvgIn returns the average in-degree of the nodes with incoming edges
+func (s DegreeStats) AvgIn() float64 {
+	if s.Targets == 0 {
+		return 0
+	}
+	return float64(s.Count) / float64(s.Targets)
+}
+
+// degreeCounter tracks per-node degrees for a set of edges, along with how
+// many nodes have each degree so the maximum and distribution are cheap
+type degreeCounter struct {
+	count   int
+	out     map[string]int // node_id -> out-degree
+	in      map[string]int // node_id -> in-degree
+	outHist map[int]int    // out-degree -> nodes
+	inHist  map[int]int    // in-degree -> nodes
+}
+
+func newDegreeCounter() *degreeCounter {
+	return &degreeCounter{
+		out:     make(map[string]int),
+		in:      make(map[string]int),
+		outHist: make(map[int]int),
+		inHist:  make(map[int]int),
+	}
+}
+
+// add counts an edge, or uncounts it when delta is -1
+func (d *degreeCounter) add(from, to string, delta int) {
+	d.count += delta
+	shiftDegree(d.out, d.outHist, from, delta)
+	shiftDegree(d.in, d.inHist, to, delta)
+}
+
+// shiftDegree changes a node's degree, moving it between histogram entries
+func shiftDegree(degrees map[string]int, hist map[int]int, id string, delta int) {
+	old := degrees[id]
+	if old > 0 {
+		hist[old]--
+		if hist[old] == 0 {
+			delete(hist, old)
+		}
+	}
+
+	degree := old + delta
+	if degree > 0 {
+		degrees[id] = degree
+		hist[degree]++
+	} else {
+		delete(degrees, id)
+	}
+}
+
+func (d *degreeCounter) stats() DegreeStats {
+	stats := DegreeStats{Count: d.count, Sources: len(d.out), Targets: len(d.in)}
+	stats.MaxOut, stats.OutHistogram = summarizeDegrees(d.outHist)
+	stats.MaxIn, stats.InHistogram = summarizeDegrees(d.inHist)
+	return stats
+}
+
+// summarizeDegrees returns the highest degree in a histogram and the
+// histogram bucketed by powers of two
+func summarizeDegrees(hist map[int]int) (int, []int) {
+	degrees := make([]int, 0, len(hist))
+	for degree := range hist {
+		degrees = append(degrees, degree)
+	}
+	if len(degrees) == 0 {
+		return 0, nil
+	}
+	sort.Ints(degrees)
+
+	maxDegree := degrees[len(degrees)-1]
+	buckets := make([]int, bits.Len(uint(maxDegree)))
+	for _, degree := range degrees {
+		buckets[bits.Len(uint(degree))-1] += hist[degree]
+	}
+	return maxDegree, buckets
+}
+
+// countEdgeLocked adds an edge to the degree statistics, or removes it when
+// delta is -1. Callers must hold the write lock.
+func (g *MemoryGraph) countEdgeLocked(edge *Edge, delta int) {
+	g.degrees.add(edge.From, edge.To, delta)
+
+	counter := g.labelDegrees[edge.Label]
+	if counter == nil {
+		counter = newDegreeCounter()
+		g.labelDegrees[edge.Label] = counter
+	}
+	counter.add(edge.From, edge.To, delta)
+	if counter.count == 0 {
+		delete(g.labelDegrees, edge.Label)
+	}
+}
+
+// Statistics returns a snapshot of the graph's cardinality statistics
+func (g *MemoryGraph) Statistics() Statistics {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	stats := Statistics{
+		Nodes:       len(g.nodes),
+		NodesByType: make(map[string]int, len(g.nodesByType)),
+		Edges:       g.degrees.stats(),
+		Labels:      make(map[string]DegreeStats, len(g.labelDegrees)),
+	}
+	for nodeType, nodes := range g.nodesByType {
+		stats.NodesByType[nodeType] = len(nodes)
+	}
+	for label, counter := range g.labelDegrees {
+		stats.Labels[label] = counter.stats()
+	}
+	return stats
+}
+
+// Degree returns the number of edges of a node in a direction, counting only
+// the given labels when any are given. A self-loop counts twice for "both".
+func (g *MemoryGraph) Degree(nodeID, direction string, labels ...string) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	counters := []*degreeCounter{g.degrees}
+	if len(labels) > 0 {
+		counters = counters[:0]
+		for _, label := range labels {
+			if counter := g.labelDegrees[label]; counter != nil {
+				counters = append(counters, counter)
+			}
+		}
+	}
+
+	degree := 0
+	for _, counter := range counters {
+		if direction != "in" {
+			degree += counter.out[nodeID]
+		}
+		if direction != "out" {
+			degree += counter.in[nodeID]
+		}
+	}
+	return degree
+}
+
+// PropertyCount returns the number of nodes with an indexed property value
+func (g *MemoryGraph) PropertyCount(key, value string) (int, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	index, ok := g.propIndex[key]
+	if !ok {
+		return 0, false
+	}
+	return len(index[value]), true
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryGraph_Statistics(t *testing.T) {
+	ctx := context.Background()
+	g := newCodeGraph(t)
+
+	stats := g.Statistics()
+	if stats.Nodes != 9 || stats.NodesByType["function"] != 5 || stats.NodesByType["file"] != 4 {
+		t.Fatalf("Unexpected node counts: %+v", stats)
+	}
+
+	want := DegreeStats{Count: 9, Sources: 5, Targets: 8, MaxOut: 3, MaxIn: 2, OutHistogram: []int{2, 3}, InHistogram: []int{7, 1}}
+	if !reflect.DeepEqual(stats.Edges, want) {
+		t.Fatalf("Expected edge stats %+v, got %+v", want, stats.Edges)
+	}
+	calls := stats.Labels["calls"]
+	if calls.Count != 4 || calls.Sources != 3 || calls.MaxOut != 2 || calls.AvgOut() != 4.0/3 {
+		t.Fatalf("Unexpected calls stats: %+v", calls)
+	}
+
+	if got := g.Degree("main", "out"); got != 3 {
+		t.Errorf("Expected main to have 3 outgoing edges, got %d", got)
+	}
+	if got := g.Degree("main", "out", "calls"); got != 2 {
+		t.Errorf("Expected main to make 2 calls, got %d", got)
+	}
+	if got := g.Degree("hash", "both", "calls", "defined_in"); got != 3 {
+		t.Errorf("Expected hash to have 3 edges, got %d", got)
+	}
+
+	if _, indexed := g.PropertyCount("language", "go"); indexed {
+		t.Error("Expected language to be unindexed")
+	}
+	if err := g.CreatePropertyIndex("language"); err != nil {
+		t.Fatalf("Failed to create index: %v", err)
+	}
+	if count, indexed := g.PropertyCount("language", "go"); !indexed || count != 3 {
+		t.Errorf("Expected 3 indexed go functions, got %d (indexed %v)", count, indexed)
+	}
+
+	// Deleting a node removes its edges from the statistics
+	if err := g.DeleteNode(ctx, "main"); err != nil {
+		t.Fatalf("Failed to delete node: %v", err)
+	}
+	if err := g.DeleteEdge(ctx, "encode", "codec.c", "defined_in"); err != nil {
+		t.Fatalf("Failed to delete edge: %v", err)
+	}
+	stats = g.Statistics()
+	if stats.Nodes != 8 || stats.Edges.Count != 5 || stats.Edges.MaxOut != 2 {
+		t.Fatalf("Unexpected stats after deletes: %+v", stats)
+	}
+	if calls := stats.Labels["calls"]; calls.Count != 2 || calls.Sources != 2 || calls.MaxOut != 1 {
+		t.Fatalf("Unexpected calls stats after deletes: %+v", calls)
+	}
+	if got := g.Degree("main", "both"); got != 0 {
+		t.Errorf("Expected deleted node to have no edges, got %d", got)
+	}
+
+	// A failed batch leaves the statistics as they were
+	_, err := g.ApplyBatch(ctx, []BatchOp{
+		{Op: OpAddEdge, From: "hash", To: "auth.py", Label: "defined_in"},
+		{Op: OpDeleteNode, ID: "hash"},
+		{Op: OpAddEdge, From: "missing", To: "hash", Label: "calls"},
+	}, nil)
+	if err == nil {
+		t.Fatal("Expected the batch to fail")
+	}
+	if after := g.Statistics(); !reflect.DeepEqual(after, stats) {
+		t.Fatalf("Expected rollback to restore stats %+v, got %+v", stats, after)
+	}
+
+	// Labels with no edges left are dropped
+	for _, edge := range []Edge{{From: "login", To: "hash", Label: "calls"}, {From: "hash", To: "encode", Label: "calls"}} {
+		if err := g.DeleteEdge(ctx, edge.From, edge.To, edge.Label); err != nil {
+			t.Fatalf("Failed to delete edge: %v", err)
+		}
+	}
+	if _, exists := g.Statistics().Labels["calls"]; exists {
+		t.Error("Expected no calls stats once every call is deleted")
+	}
+}
@@ -3,33 +3,45 @@ package graph
 import (
 	"context"
 	"encoding/json"
+	"time"
 )
 
 // Node represents a graph node with unique ID, optional type, and properties
 type Node struct {
-	ID    string            `json:"id"`
-	Type  string            `json:"type,omitempty"`
-	Props map[string]string `json:"props,omitempty"`
+	ID    string           `json:"id"`
+	Type  string           `json:"type,omitempty"`
+	Props map[string]Value `json:"props,omitempty"`
 }
 
 // Edge represents a directed, labeled edge between two nodes
 type Edge struct {
-	From  string            `json:"from"`
-	To    string            `json:"to"`
-	Label string            `json:"label"`
-	Props map[string]string `json:"props,omitempty"`
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Label string           `json:"label"`
+	Props map[string]Value `json:"props,omitempty"`
 }
 
 // Query represents a graph query with various parameters
 type Query struct {
-	Type      string            `json:"type"` // "neighbors", "paths", "find"
-	Node      string            `json:"node,omitempty"`
-	Label     string            `json:"label,omitempty"`
-	Direction string            `json:"direction,omitempty"` // "in", "out", "both"
-	MaxDepth  int               `json:"max_depth,omitempty"`
-	Filters   map[string]string `json:"filters,omitempty"`
-	From      string            `json:"from,omitempty"` // for path queries
-	To        string            `json:"to,omitempty"`   // for path queries
+	Type       string            `json:"type"`              // "neighbors", "paths", "shortest_path", "weighted_shortest_path", "find", "find_edges", "subgraph", "match"
+	Pattern    string            `json:"pattern,omitempty"` // pattern text for match queries, see Pattern
+	Node       string            `json:"node,omitempty"`
+	Seeds      []string          `json:"seeds,omitempty"` // additional start nodes for subgraph queries
+	Label      string            `json:"label,omitempty"`
+	Labels     []string          `json:"labels,omitempty"`    // allowed edge labels for path queries
+	Direction  string            `json:"direction,omitempty"` // "in", "out", "both"
+	MaxDepth   int               `json:"max_depth,omitempty"`
+	Filters    map[string]string `json:"filters,omitempty"`     // equality shorthand: "type" or property key -> value
+	Where      *Filter           `json:"where,omitempty"`       // structured filter expression for find queries
+	From       string            `json:"from,omitempty"`        // for path and find_edges queries
+	To         string            `json:"to,omitempty"`          // for path and find_edges queries
+	FromType   string            `json:"from_type,omitempty"`   // source node type for find_edges queries
+	ToType     string            `json:"to_type,omitempty"`     // target node type for find_edges queries
+	WeightProp string            `json:"weight_prop,omitempty"` // numeric edge property for weighted shortest paths
+	NodeTypes  []string          `json:"node_types,omitempty"`  // node types to include in subgraph queries
+	Limit      int               `json:"limit,omitempty"`       // maximum number of nodes returned by subgraph queries, or rows by match queries
+	AsOf       *time.Time        `json:"as_of,omitempty"`       // answer against the graph as it was at this time
+	Explain    bool              `json:"explain,omitempty"`     // return the plan for find, find_edges and match queries instead of running them
 }
 
 // QueryResult represents the result of a graph query
@@ -37,12 +49,33 @@ type QueryResult struct {
 	Nodes []Node `json:"nodes,omitempty"`
 	Edges []Edge `json:"edges,omitempty"`
 	Paths []Path `json:"paths,omitempty"`
+
+	// Columns and Rows hold the variable bindings of match queries; Columns
+	// lists the named variables in the order they appear in the pattern
+	Columns []string             `json:"columns,omitempty"`
+	Rows    []map[string]Binding `json:"rows,omitempty"`
+
+	// Truncated is set when a subgraph or match query stopped at its limit
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Plan describes the steps chosen for an explained query, in order, with
+	// estimated candidates and rows when the graph keeps statistics
+	Plan []string `json:"plan,omitempty"`
+}
+
+// Binding is the value of a pattern variable in a match row: a node, an edge,
+// or for a variable-length relationship the path it matched
+type Binding struct {
+	Node *Node `json:"node,omitempty"`
+	Edge *Edge `json:"edge,omitempty"`
+	Path *Path `json:"path,omitempty"`
 }
 
 // Path represents a path through the graph
 type Path struct {
-	Nodes []Node `json:"nodes"`
-	Edges []Edge `json:"edges"`
+	Nodes []Node  `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+	Cost  float64 `json:"cost,omitempty"` // total weight for weighted shortest paths
 }
 
 // Graph defines the interface for graph operations
@@ -50,11 +83,13 @@ type Graph interface {
 	// Node operations
 	AddNode(ctx context.Context, node Node) error
 	GetNode(ctx context.Context, id string) (*Node, error)
+	UpdateNode(ctx context.Context, id string, update Update) error
 	DeleteNode(ctx context.Context, id string) error
 
 	// Edge operations
 	AddEdge(ctx context.Context, edge Edge) error
 	GetEdge(ctx context.Context, from, to, label string) (*Edge, error)
+	UpdateEdge(ctx context.Context, from, to, label string, update Update) error
 	DeleteEdge(ctx context.Context, from, to, label string) error
 
 	// Query operations
@@ -63,11 +98,23 @@ type Graph interface {
 	// Utility operations
 	NodeExists(ctx context.Context, id string) bool
 	GetNodesByType(ctx context.Context, nodeType string) ([]Node, error)
+	GetNodesByProperty(ctx context.Context, key, value string) ([]Node, error)
 	GetNeighbors(ctx context.Context, nodeID, direction string) ([]Node, error)
+	GetEdges(ctx context.Context, nodeID, direction string) ([]Edge, error)
 	GetAllNodes(ctx context.Context) ([]Node, error)
 	GetAllEdges(ctx context.Context) ([]Edge, error)
 }
 
+// PropertyIndexer is implemented by graphs that can maintain secondary
+// indexes on node property keys
+type PropertyIndexer interface {
+	// CreatePropertyIndex indexes the given property key, including existing nodes
+	CreatePropertyIndex(key string) error
+
+	// PropertyIndexes returns the indexed property keys in sorted order
+	PropertyIndexes() []string
+}
+
 // Validate checks if a Node is valid
 func (n *Node) Validate() error {
 	if n.ID == "" {
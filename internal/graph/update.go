@@ -0,0 +1,162 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+)
+
+// Update modes
+const (
+	UpdateMerge   = "merge"   // keep existing properties and overwrite the given ones
+	UpdateReplace = "replace" // discard existing properties in favor of the given ones
+)
+
+// Update describes an in-place change to a node or edge. Props are merged
+// into or replace the existing properties depending on Mode, then the keys in
+// Remove are deleted. Type changes a node's type when set and is ignored for edges.
+type Update struct {
+	Type   string           `json:"type,omitempty"`
+	Props  map[string]Value `json:"props,omitempty"`
+	Remove []string         `json:"remove,omitempty"`
+	Mode   string           `json:"mode,omitempty"` // "merge" (default) or "replace"
+}
+
+// apply returns the properties that result from applying the update to props
+func (u Update) apply(props map[string]Value) (map[string]Value, error) {
+	var result map[string]Value
+	switch u.Mode {
+	case "", UpdateMerge:
+		result = copyProps(props)
+	case UpdateReplace:
+		result = make(map[string]Value, len(u.Props))
+	default:
+		return nil, fmt.Errorf("%w, got '%s'", ErrInvalidUpdateMode, u.Mode)
+	}
+
+	for key, value := range u.Props {
+		result[key] = value
+	}
+	for _, key := range u.Remove {
+		delete(result, key)
+	}
+
+	return result, nil
+}
+
+// UpdateNode changes a node in place, keeping its edges
+func (g *MemoryGraph) UpdateNode(ctx context.Context, id string, update Update) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return ErrGraphClosed
+	}
+
+	return g.applyLocked(BatchOp{Op: OpUpdateNode, ID: id, Type: update.Type, Props: update.Props, Remove: update.Remove, Mode: update.Mode})
+}
+
+// UpdateEdge changes an edge's properties in place
+func (g *MemoryGraph) UpdateEdge(ctx context.Context, from, to, label string, update Update) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return ErrGraphClosed
+	}
+
+	return g.applyLocked(BatchOp{Op: OpUpdateEdge, From: from, To: to, Label: label, Props: update.Props, Remove: update.Remove, Mode: update.Mode})
+}
+
+// updateNodeLocked applies an update to an existing node and returns its new
+// and previous state. Callers must hold the write lock.
+func (g *MemoryGraph) updateNodeLocked(id string, update Update) (Node, Node, error) {
+	existing, exists := g.nodes[id]
+	if !exists {
+		return Node{}, Node{}, ErrNodeNotFound
+	}
+
+	props, err := update.apply(existing.Props)
+	if err != nil {
+		return Node{}, Node{}, err
+	}
+
+	updated := Node{ID: id, Type: existing.Type, Props: props}
+	if update.Type != "" {
+		updated.Type = update.Type
+	}
+
+	previous := g.replaceNodeLocked(updated)
+	return updated, previous, nil
+}
+
+// updateEdgeLocked applies an update to an existing edge and returns its new
+// and previous state. Callers must hold the write lock.
+func (g *MemoryGraph) updateEdgeLocked(from, to, label string, update Update) (Edge, Edge, error) {
+	existing, exists := g.edges[g.makeEdgeKey(from, to, label)]
+	if !exists {
+		return Edge{}, Edge{}, ErrEdgeNotFound
+	}
+
+	props, err := update.apply(existing.Props)
+	if err != nil {
+		return Edge{}, Edge{}, err
+	}
+
+	updated := Edge{From: from, To: to, Label: label, Props: props}
+	previous := g.replaceEdgeLocked(updated)
+	return updated, previous, nil
+}
+
+// replaceNodeLocked swaps the stored state of an existing node in place,
+// keeping its edges, and returns the previous state. Callers must hold the
+// write lock and ensure the node exists.
+func (g *MemoryGraph) replaceNodeLocked(node Node) Node {
+	existing := g.nodes[node.ID]
+	previous := *existing
+
+	// Drop index entries for the old state
+	if existing.Type != "" {
+		if typeNodes, exists := g.nodesByType[existing.Type]; exists {
+			delete(typeNodes, node.ID)
+			if len(typeNodes) == 0 {
+				delete(g.nodesByType, existing.Type)
+			}
+		}
+	}
+	g.unindexNodeProps(existing)
+
+	// Update in place so every index sharing the pointer sees the new state
+	existing.Type = node.Type
+	existing.Props = copyProps(node.Props)
+
+	if existing.Type != "" {
+		if g.nodesByType[existing.Type] == nil {
+			g.nodesByType[existing.Type] = make(map[string]*Node)
+		}
+		g.nodesByType[existing.Type][existing.ID] = existing
+	}
+	g.indexNodeProps(existing)
+
+	return previous
+}
+
+// replaceEdgeLocked swaps the properties of an existing edge in place and
+// returns the previous state. Callers must hold the write lock and ensure the
+// edge exists.
+func (g *MemoryGraph) replaceEdgeLocked(edge Edge) Edge {
+	existing := g.edges[g.makeEdgeKey(edge.From, edge.To, edge.Label)]
+	previous := *existing
+
+	existing.Props = copyProps(edge.Props)
+
+	return previous
+}
+
+// copyProps returns a copy of a property map that is never nil
+func copyProps(props map[string]Value) map[string]Value {
+	copied := make(map[string]Value, len(props))
+	for key, value := range props {
+		copied[key] = value
+	}
+	return copied
+}
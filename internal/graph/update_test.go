@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryGraph_UpdateNode(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.CreatePropertyIndex("lang")
+	g.AddNode(ctx, Node{ID: "a", Type: "file", Props: StringProps(map[string]string{"lang": "go", "lines": "10", "owner": "bob"})})
+	g.AddNode(ctx, Node{ID: "b", Type: "file"})
+	g.AddEdge(ctx, Edge{From: "a", To: "b", Label: "imports"})
+
+	// Merge keeps existing properties and the node's edges
+	err := g.UpdateNode(ctx, "a", Update{Type: "module", Props: StringProps(map[string]string{"lines": "20"}), Remove: []string{"owner"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	node, _ := g.GetNode(ctx, "a")
+	if node.Type != "module" || node.Props["lang"].String() != "go" || node.Props["lines"].String() != "20" {
+		t.Fatalf("Expected merged update, got %+v", node)
+	}
+	if _, exists := node.Props["owner"]; exists {
+		t.Fatalf("Expected owner removed, got %v", node.Props)
+	}
+
+	if _, err := g.GetEdge(ctx, "a", "b", "imports"); err != nil {
+		t.Fatalf("Expected edge kept, got %v", err)
+	}
+
+	modules, _ := g.GetNodesByType(ctx, "module")
+	files, _ := g.GetNodesByType(ctx, "file")
+	if len(modules) != 1 || len(files) != 1 {
+		t.Fatalf("Expected type index updated, got %d modules and %d files", len(modules), len(files))
+	}
+
+	// Replace discards properties that are not given
+	if err := g.UpdateNode(ctx, "a", Update{Mode: UpdateReplace, Props: StringProps(map[string]string{"lang": "rust"})}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	node, _ = g.GetNode(ctx, "a")
+	if len(node.Props) != 1 || node.Props["lang"].String() != "rust" {
+		t.Fatalf("Expected replaced props, got %v", node.Props)
+	}
+
+	goNodes, _ := g.GetNodesByProperty(ctx, "lang", "go")
+	rustNodes, _ := g.GetNodesByProperty(ctx, "lang", "rust")
+	if len(goNodes) != 0 || len(rustNodes) != 1 {
+		t.Fatalf("Expected property index updated, got %v and %v", goNodes, rustNodes)
+	}
+
+	if err := g.UpdateNode(ctx, "a", Update{Mode: "patch"}); !errors.Is(err, ErrInvalidUpdateMode) {
+		t.Fatalf("Expected ErrInvalidUpdateMode, got %v", err)
+	}
+
+	if err := g.UpdateNode(ctx, "missing", Update{}); err != ErrNodeNotFound {
+		t.Fatalf("Expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestMemoryGraph_UpdateEdge(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	g.AddNode(ctx, Node{ID: "a"})
+	g.AddNode(ctx, Node{ID: "b"})
+	g.AddEdge(ctx, Edge{From: "a", To: "b", Label: "calls", Props: StringProps(map[string]string{"count": "1", "line": "4"})})
+
+	if err := g.UpdateEdge(ctx, "a", "b", "calls", Update{Props: StringProps(map[string]string{"count": "2"}), Remove: []string{"line"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The change is visible through every edge index
+	edges, _ := g.GetEdges(ctx, "b", "in")
+	if len(edges) != 1 || edges[0].Props["count"].String() != "2" || len(edges[0].Props) != 1 {
+		t.Fatalf("Expected updated edge, got %v", edges)
+	}
+
+	if err := g.UpdateEdge(ctx, "a", "b", "imports", Update{}); err != ErrEdgeNotFound {
+		t.Fatalf("Expected ErrEdgeNotFound, got %v", err)
+	}
+}
+
+func TestMemoryGraph_Upsert(t *testing.T) {
+	g := NewMemoryGraph()
+	ctx := context.Background()
+
+	ops := []BatchOp{
+		{Op: OpUpsertNode, ID: "a", Type: "file", Props: StringProps(map[string]string{"lang": "go"})},
+		{Op: OpUpsertNode, ID: "a", Props: StringProps(map[string]string{"lines": "5"})},
+		{Op: OpUpsertNode, ID: "b"},
+		{Op: OpUpsertEdge, From: "a", To: "b", Label: "imports"},
+		{Op: OpUpsertEdge, From: "a", To: "b", Label: "imports", Props: StringProps(map[string]string{"alias": "x"})},
+	}
+
+	results, err := g.ApplyBatch(ctx, ops, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantCreated := []bool{true, false, true, true, false}
+	for i, created := range wantCreated {
+		if results[i].Created != created {
+			t.Fatalf("Expected created=%v for operation %d, got %+v", created, i, results[i])
+		}
+	}
+
+	node, _ := g.GetNode(ctx, "a")
+	if node.Type != "file" || node.Props["lang"].String() != "go" || node.Props["lines"].String() != "5" {
+		t.Fatalf("Expected upsert to merge into existing node, got %+v", node)
+	}
+
+	edge, _ := g.GetEdge(ctx, "a", "b", "imports")
+	if edge.Props["alias"].String() != "x" {
+		t.Fatalf("Expected upsert to update existing edge, got %+v", edge)
+	}
+}
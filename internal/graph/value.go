@@ -0,0 +1,251 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a property value
+type Kind uint8
+
+// Property value kinds
+const (
+	KindString Kind = iota
+	KindInt
+	KindFloat
+	KindBool
+	KindTimestamp
+	KindList // list of strings
+)
+
+var kindNames = [...]string{"string", "int", "float", "bool", "timestamp", "list"}
+
+// String returns the name of the kind as used in schemas
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("kind(%d)", k)
+}
+
+// ParseKind returns the kind with the given name
+func ParseKind(name string) (Kind, error) {
+	for i, kindName := range kindNames {
+		if name == kindName {
+			return Kind(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown value type '%s', expected one of %s", name, strings.Join(kindNames[:], ", "))
+}
+
+// Value is a typed property value: a string, int, float, bool, timestamp or
+// list of strings. The zero Value is the empty string.
+//
+// Values are kept in a canonical text form, which is what filters, patterns
+// and property indexes compare, so the int 30 matches the filter "30" and
+// properties stored as strings before values were typed still match. The
+// kind decides how a value is encoded and how it orders in range filters.
+//
+// In JSON, strings, numbers, booleans and arrays of strings encode as
+// themselves; floats always carry a fraction or exponent so they decode as
+// floats, and timestamps encode as {"timestamp": "<RFC 3339>"}.
+type Value struct {
+	kind Kind
+	text string
+}
+
+// StringValue returns a string value
+func StringValue(s string) Value {
+	return Value{kind: KindString, text: s}
+}
+
+// IntValue returns an integer value
+func IntValue(i int64) Value {
+	return Value{kind: KindInt, text: strconv.FormatInt(i, 10)}
+}
+
+// FloatValue returns a floating-point value. NaN and infinities cannot be
+// encoded as JSON, so graphs holding them fail to save.
+func FloatValue(f float64) Value {
+	return Value{kind: KindFloat, text: strconv.FormatFloat(f, 'g', -1, 64)}
+}
+
+// BoolValue returns a boolean value
+func BoolValue(b bool) Value {
+	return Value{kind: KindBool, text: strconv.FormatBool(b)}
+}
+
+// TimeValue returns a timestamp value, kept in UTC
+func TimeValue(t time.Time) Value {
+	return Value{kind: KindTimestamp, text: t.UTC().Format(time.RFC3339Nano)}
+}
+
+// ListValue returns a list of strings value
+func ListValue(items ...string) Value {
+	if items == nil {
+		items = []string{}
+	}
+	data, _ := json.Marshal(items)
+	return Value{kind: KindList, text: string(data)}
+}
+
+// StringProps converts string properties to values
+func StringProps(props map[string]string) map[string]Value {
+	if props == nil {
+		return nil
+	}
+	values := make(map[string]Value, len(props))
+	for key, value := range props {
+		values[key] = StringValue(value)
+	}
+	return values
+}
+
+// Kind returns the type of the value
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// String returns the canonical text form of the value: integers in decimal,
+// floats in their shortest form, timestamps in RFC 3339 and lists as a JSON
+// array
+func (v Value) String() string {
+	return v.text
+}
+
+// Int returns the value of an int
+func (v Value) Int() (int64, bool) {
+	if v.kind != KindInt {
+		return 0, false
+	}
+	i, err := strconv.ParseInt(v.text, 10, 64)
+	return i, err == nil
+}
+
+// Float returns the value of an int or float
+func (v Value) Float() (float64, bool) {
+	if v.kind != KindInt && v.kind != KindFloat {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(v.text, 64)
+	return f, err == nil
+}
+
+// Bool returns the value of a bool
+func (v Value) Bool() (bool, bool) {
+	if v.kind != KindBool {
+		return false, false
+	}
+	return v.text == "true", true
+}
+
+// Time returns the value of a timestamp
+func (v Value) Time() (time.Time, bool) {
+	if v.kind != KindTimestamp {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, v.text)
+	return t, err == nil
+}
+
+// List returns the items of a list
+func (v Value) List() ([]string, bool) {
+	if v.kind != KindList {
+		return nil, false
+	}
+	var items []string
+	if err := json.Unmarshal([]byte(v.text), &items); err != nil {
+		return nil, false
+	}
+	return items, true
+}
+
+// MarshalJSON encodes the value as described on Value
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch v.kind {
+	case KindInt, KindBool, KindList:
+		return []byte(v.text), nil
+	case KindFloat:
+		if f, _ := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("unsupported float value %s", v.text)
+		}
+		if strings.ContainsAny(v.text, ".eE") {
+			return []byte(v.text), nil
+		}
+		return []byte(v.text + ".0"), nil
+	case KindTimestamp:
+		return json.Marshal(struct {
+			Timestamp string `json:"timestamp"`
+		}{v.text})
+	default:
+		return json.Marshal(v.text)
+	}
+}
+
+// UnmarshalJSON decodes a value encoded as described on Value. Numbers with a
+// fraction or exponent, or too large for an int, decode as floats.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty property value", ErrInvalidValue)
+	}
+
+	switch data[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*v = StringValue(s)
+	case 't', 'f':
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return err
+		}
+		*v = BoolValue(b)
+	case '[':
+		var items []string
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("%w: lists may only hold strings", ErrInvalidValue)
+		}
+		*v = ListValue(items...)
+	case '{':
+		var tagged struct {
+			Timestamp *string `json:"timestamp"`
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&tagged); err != nil || tagged.Timestamp == nil {
+			return fmt.Errorf("%w: objects must be {\"timestamp\": \"<RFC 3339 time>\"}", ErrInvalidValue)
+		}
+		t, err := time.Parse(time.RFC3339Nano, *tagged.Timestamp)
+		if err != nil {
+			return fmt.Errorf("%w: timestamp %q is not an RFC 3339 time", ErrInvalidValue, *tagged.Timestamp)
+		}
+		*v = TimeValue(t)
+	case 'n':
+		return fmt.Errorf("%w: property values cannot be null", ErrInvalidValue)
+	default:
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		if !strings.ContainsAny(number.String(), ".eE") {
+			if i, err := number.Int64(); err == nil {
+				*v = IntValue(i)
+				return nil
+			}
+		}
+		f, err := number.Float64()
+		if err != nil {
+			return fmt.Errorf("%w: number %s is out of range", ErrInvalidValue, number)
+		}
+		*v = FloatValue(f)
+	}
+	return nil
+}
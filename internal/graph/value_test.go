@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestValue_JSON(t *testing.T) {
+	joined := time.Date(2024, 3, 1, 10, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name  string
+		value Value
+		json  string
+		kind  Kind
+		text  string
+	}{
+		{"string", StringValue("30"), `"30"`, KindString, "30"},
+		{"int", IntValue(-42), `-42`, KindInt, "-42"},
+		{"float", FloatValue(2.5), `2.5`, KindFloat, "2.5"},
+		{"whole float", FloatValue(2), `2.0`, KindFloat, "2"},
+		{"large float", FloatValue(1e21), `1e+21`, KindFloat, "1e+21"},
+		{"bool", BoolValue(true), `true`, KindBool, "true"},
+		{"timestamp", TimeValue(joined), `{"timestamp":"2024-03-01T09:30:00Z"}`, KindTimestamp, "2024-03-01T09:30:00Z"},
+		{"list", ListValue("a", "b,c"), `["a","b,c"]`, KindList, `["a","b,c"]`},
+		{"empty list", ListValue(), `[]`, KindList, `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value.Kind() != tt.kind || tt.value.String() != tt.text {
+				t.Fatalf("Expected the %s %s, got the %s %s", tt.kind, tt.text, tt.value.Kind(), tt.value)
+			}
+
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Failed to marshal: %v", err)
+			}
+			if string(data) != tt.json {
+				t.Fatalf("Expected %s, got %s", tt.json, data)
+			}
+
+			var decoded Value
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if decoded != tt.value {
+				t.Fatalf("Expected %s to round-trip, got the %s %s", tt.json, decoded.Kind(), decoded)
+			}
+		})
+	}
+
+	// Integers too large for an int64 become floats
+	var big Value
+	if err := json.Unmarshal([]byte(`12345678901234567890`), &big); err != nil || big.Kind() != KindFloat {
+		t.Fatalf("Expected a float, got the %s %s (%v)", big.Kind(), big, err)
+	}
+
+	for _, data := range []string{`null`, `{"time": "2024-01-01T00:00:00Z"}`, `{"timestamp": "yesterday"}`, `[1, 2]`, `1e999`} {
+		var v Value
+		if err := json.Unmarshal([]byte(data), &v); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Expected ErrInvalidValue for %s, got %v", data, err)
+		}
+	}
+}
+
+func TestValue_Accessors(t *testing.T) {
+	if i, ok := IntValue(7).Int(); !ok || i != 7 {
+		t.Errorf("Expected 7, got %d (%v)", i, ok)
+	}
+	if f, ok := IntValue(7).Float(); !ok || f != 7 {
+		t.Errorf("Expected an int to read as a float, got %v (%v)", f, ok)
+	}
+	if _, ok := StringValue("7").Int(); ok {
+		t.Error("Expected a string not to read as an int")
+	}
+	if b, ok := BoolValue(false).Bool(); !ok || b {
+		t.Errorf("Expected false, got %v (%v)", b, ok)
+	}
+	now := time.Now()
+	if tm, ok := TimeValue(now).Time(); !ok || !tm.Equal(now) {
+		t.Errorf("Expected %v, got %v (%v)", now, tm, ok)
+	}
+	if items, ok := ListValue("a", "b").List(); !ok || len(items) != 2 || items[1] != "b" {
+		t.Errorf("Expected [a b], got %v (%v)", items, ok)
+	}
+
+	var zero Value
+	if zero != StringValue("") {
+		t.Error("Expected the zero value to be the empty string")
+	}
+	if _, err := json.Marshal(FloatValue(math.Inf(1))); err == nil {
+		t.Error("Expected an infinite float to fail to marshal")
+	}
+}
@@ -2,32 +2,74 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dshills/RelatixDB/internal/graph"
+	"github.com/dshills/RelatixDB/internal/storage"
 )
 
+// DefaultWorkers is how many requests a handler processes at once by default
+const DefaultWorkers = 8
+
 // Handler manages MCP protocol communication via stdio
 type Handler struct {
-	graph       graph.Graph
-	reader      *bufio.Scanner
-	writer      io.Writer
-	debug       bool
+	graph  graph.Graph
+	reader *bufio.Reader
+	writer io.Writer
+	debug  bool
+
+	// maxMessageSize bounds a single input line; longer lines are answered
+	// with an error and skipped
+	maxMessageSize int
+
+	// Workers bounds how many requests Run processes concurrently; with 1
+	// requests are processed one at a time in the order they arrive
+	Workers int
+
+	mu          sync.RWMutex // guards initialized, clientName and inFlight
 	initialized bool
+	clientName  string                      // default actor for changes, from initialize
+	inFlight    map[string]*inFlightRequest // running requests by encoded ID
+	writeMu     sync.Mutex
+
+	// Resource subscriptions. The graph watcher records updated resources
+	// and signals notify; the Run loop writes the notifications.
+	watchMu       sync.Mutex // guards unwatch; never held while taking subsMu
+	unwatch       func()
+	subsMu        sync.Mutex
+	subscriptions map[string]bool
+	updated       map[string]bool
+	notify        chan struct{}
+}
+
+// inFlightRequest is a running request that notifications/cancelled can stop
+type inFlightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool
 }
 
 // NewHandler creates a new MCP handler
 func NewHandler(g graph.Graph, reader io.Reader, writer io.Writer, debug bool) *Handler {
 	return &Handler{
-		graph:  g,
-		reader: bufio.NewScanner(reader),
-		writer: writer,
-		debug:  debug,
+		graph:          g,
+		reader:         bufio.NewReader(reader),
+		writer:         writer,
+		debug:          debug,
+		maxMessageSize: maxHTTPRequestSize,
+		Workers:        DefaultWorkers,
+		inFlight:       make(map[string]*inFlightRequest),
+		notify:         make(chan struct{}, 1),
 	}
 }
 
@@ -36,52 +78,321 @@ func NewStdioHandler(g graph.Graph, debug bool) *Handler {
 	return NewHandler(g, os.Stdin, os.Stdout, debug)
 }
 
-// Run starts the MCP handler loop, processing JSON-RPC requests from stdin
+// Run starts the MCP handler loop, processing JSON-RPC requests from stdin.
+// Up to Workers requests run at once and each response is written as soon
+// as it is ready, so responses may arrive out of order; clients match them
+// by ID. initialize always runs alone, after every earlier request. Run
+// returns when the input ends or, without waiting for more input, when ctx
+// is canceled.
 func (h *Handler) Run(ctx context.Context) error {
 	h.debugLog("Starting MCP server...")
 
-	for h.reader.Scan() {
+	// Notify subscribers of changes made by others between requests
+	defer h.stopWatching()
+	stop := make(chan struct{})
+	var notifier sync.WaitGroup
+	notifier.Add(1)
+	go h.notifyLoop(stop, &notifier)
+	defer notifier.Wait()
+	defer close(stop)
+
+	workers := h.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	slots := make(chan struct{}, workers)
+
+	var (
+		running  sync.WaitGroup
+		errMu    sync.Mutex
+		writeErr error
+	)
+	defer running.Wait()
+
+	// serve processes one message and writes its response, if any, followed
+	// by notifications for the changes it made
+	serve := func(line string) {
+		err := h.writeResponse(h.processMessage(ctx, line))
+		if err == nil {
+			err = h.flushNotifications()
+		}
+		if err != nil {
+			h.debugLog("Failed to write response: %v", err)
+			errMu.Lock()
+			if writeErr == nil {
+				writeErr = fmt.Errorf("failed to write response: %w", err)
+			}
+			errMu.Unlock()
+		}
+	}
+	failed := func() error {
+		errMu.Lock()
+		defer errMu.Unlock()
+		return writeErr
+	}
+
+	// Read in the background so cancellation stops the loop even while it
+	// waits for input
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	stopReading := make(chan struct{})
+	defer close(stopReading)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := h.readLine()
+			if errors.Is(err, errMessageTooLarge) {
+				// Answer here, as the ID of a message that was never read
+				// is unknown, and keep serving
+				h.debugLog("Skipped message: %v", err)
+				if err := h.writeResponse(NewJSONRPCErrorResponse(nil, InvalidRequest, "Request too large", err.Error())); err != nil {
+					readErr <- fmt.Errorf("failed to write response: %w", err)
+					return
+				}
+				continue
+			}
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- fmt.Errorf("read error: %w", err)
+				return
+			}
+			select {
+			case lines <- line:
+			case <-stopReading:
+				return
+			}
+		}
+	}()
+
+read:
+	for {
+		var line string
 		select {
 		case <-ctx.Done():
 			h.debugLog("Context canceled, stopping handler")
 			return ctx.Err()
-		default:
+		case next, ok := <-lines:
+			if !ok {
+				break read
+			}
+			line = next
+		}
+
+		if err := failed(); err != nil {
+			return err
 		}
 
-		line := h.reader.Text()
 		if line == "" {
 			continue
 		}
 
 		h.debugLog("Received request: %s", line)
 
-		response := h.processRequest(ctx, line)
+		// initialize sets up state every later request depends on
+		method := requestMethod(line)
+		if method == MethodInitialize {
+			running.Wait()
+			serve(line)
+			continue
+		}
 
-		if err := h.writeResponse(response); err != nil {
-			h.debugLog("Failed to write response: %v", err)
-			return fmt.Errorf("failed to write response: %w", err)
+		// Notifications are quick and never answered; handling them here
+		// lets a cancellation reach requests that hold every worker
+		if strings.HasPrefix(method, "notifications/") {
+			serve(line)
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			h.debugLog("Context canceled, stopping handler")
+			return ctx.Err()
 		}
+
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			defer func() { <-slots }()
+			serve(line)
+		}()
 	}
 
-	if err := h.reader.Err(); err != nil {
-		h.debugLog("Scanner error: %v", err)
-		return fmt.Errorf("scanner error: %w", err)
+	if err := <-readErr; err != nil {
+		h.debugLog("Input error: %v", err)
+		return err
+	}
+
+	running.Wait()
+	if err := failed(); err != nil {
+		return err
 	}
 
 	h.debugLog("MCP handler finished")
 	return nil
 }
 
-// processRequest processes a single JSON-RPC request and returns a response
-func (h *Handler) processRequest(ctx context.Context, requestLine string) *JSONRPCResponse {
+// errMessageTooLarge reports an input line longer than maxMessageSize
+var errMessageTooLarge = errors.New("message too large")
+
+// readLine reads the next newline-terminated message, without the line
+// ending. A line longer than maxMessageSize is consumed and discarded, and
+// reported as errMessageTooLarge. A final line without a newline is
+// returned before io.EOF.
+func (h *Handler) readLine() (string, error) {
+	var (
+		line    []byte
+		size    int
+		tooLong bool
+	)
+	for {
+		chunk, err := h.reader.ReadSlice('\n')
+		size += len(chunk)
+		if !tooLong {
+			line = append(line, chunk...)
+			if len(bytes.TrimRight(line, "\r\n")) > h.maxMessageSize {
+				tooLong, line = true, nil
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF && size > 0 {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if tooLong {
+			return "", fmt.Errorf("%w: line exceeds %d bytes", errMessageTooLarge, h.maxMessageSize)
+		}
+		return string(bytes.TrimRight(line, "\r\n")), nil
+	}
+}
+
+// requestMethod returns the method of a request line without fully parsing
+// it, or "" if it has none
+func requestMethod(line string) string {
+	var req struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal([]byte(line), &req)
+	return req.Method
+}
+
+// notifyLoop writes resource notifications whenever the graph watcher
+// records updates, until stop is closed
+func (h *Handler) notifyLoop(stop <-chan struct{}, done *sync.WaitGroup) {
+	defer done.Done()
+
+	for {
+		select {
+		case <-h.notify:
+			if err := h.flushNotifications(); err != nil {
+				h.debugLog("Failed to write notification: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// processMessage processes one incoming message, either a single request or
+// a batch, and returns what to send back: a *JSONRPCResponse for a request, a
+// []*JSONRPCResponse for a batch, or nil when nothing should be sent because
+// the message held only notifications
+func (h *Handler) processMessage(ctx context.Context, message string) interface{} {
+	data := []byte(message)
+	if !isBatch(data) {
+		if response := h.processRequest(ctx, data, false); response != nil {
+			return response
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil {
+		h.debugLog("Failed to parse JSON-RPC batch: %v", err)
+		return NewJSONRPCErrorResponse(nil, ParseError, "Parse error", err.Error())
+	}
+	if len(batch) == 0 {
+		return NewJSONRPCErrorResponse(nil, InvalidRequest, "Invalid Request", "batch is empty")
+	}
+
+	// Batched requests run in order, and their responses are returned together
+	responses := make([]*JSONRPCResponse, 0, len(batch))
+	for _, raw := range batch {
+		if response := h.processRequest(ctx, raw, true); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+// processRequest processes a single JSON-RPC request and returns a
+// response, or nil if none should be sent
+func (h *Handler) processRequest(ctx context.Context, data []byte, batched bool) *JSONRPCResponse {
 	// Parse the JSON-RPC request
-	req, err := ParseJSONRPCRequest([]byte(requestLine))
+	req, err := ParseJSONRPCRequest(data)
 	if err != nil {
 		h.debugLog("Failed to parse JSON-RPC request: %v", err)
+		var reqErr *RequestError
+		if errors.As(err, &reqErr) {
+			return reqErr.Response()
+		}
 		return NewJSONRPCErrorResponse(nil, ParseError, "Parse error", err.Error())
 	}
 
-	// Handle the request based on method
+	// MCP requires initialize to be sent on its own
+	if batched && req.Method == MethodInitialize {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Invalid Request", "initialize cannot be part of a batch")
+	}
+
+	if req.IsNotification() {
+		h.handleNotification(ctx, req)
+		return nil
+	}
+
+	// Requests can be cancelled while they run; a cancelled request gets no response
+	ctx, key, request := h.track(ctx, req.ID)
+	defer h.untrack(key, request)
+
+	response := h.dispatch(ctx, req)
+	if h.wasCancelled(request) {
+		h.debugLog("Request %s was cancelled", key)
+		return nil
+	}
+	return response
+}
+
+// handleNotification acts on a notification, which is never answered.
+// Unknown notifications are ignored; other methods sent as notifications
+// are carried out and their results dropped.
+func (h *Handler) handleNotification(ctx context.Context, req *JSONRPCRequest) {
+	switch req.Method {
+	case NotificationCancelled:
+		h.handleCancelled(req)
+	case NotificationInitialized:
+		h.debugLog("Client finished initialization")
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			h.debugLog("Ignoring notification: %s", req.Method)
+			return
+		}
+		if response := h.dispatch(ctx, req); response != nil && response.Error != nil {
+			h.debugLog("Notification %s failed: %s", req.Method, response.Error.Message)
+		}
+	}
+}
+
+// dispatch routes a parsed request to the handler for its method
+func (h *Handler) dispatch(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	switch req.Method {
 	case MethodInitialize:
 		return h.handleInitialize(ctx, req)
@@ -89,12 +400,100 @@ func (h *Handler) processRequest(ctx context.Context, requestLine string) *JSONR
 		return h.handleToolsList(ctx, req)
 	case MethodToolsCall:
 		return h.handleToolsCall(ctx, req)
+	case MethodResourcesList:
+		return h.handleResourcesList(ctx, req)
+	case MethodResourceTemplatesList:
+		return h.handleResourceTemplatesList(ctx, req)
+	case MethodResourcesRead:
+		return h.handleResourcesRead(ctx, req)
+	case MethodResourcesSubscribe:
+		return h.handleResourcesSubscribe(ctx, req)
+	case MethodResourcesUnsubscribe:
+		return h.handleResourcesUnsubscribe(ctx, req)
+	case MethodPromptsList:
+		return h.handlePromptsList(ctx, req)
+	case MethodPromptsGet:
+		return h.handlePromptsGet(ctx, req)
 	default:
 		h.debugLog("Unknown method: %s", req.Method)
 		return NewJSONRPCErrorResponse(req.ID, MethodNotFound, "Method not found", req.Method)
 	}
 }
 
+// track registers a running request under its ID and returns a context that
+// notifications/cancelled can cancel
+func (h *Handler) track(ctx context.Context, id interface{}) (context.Context, string, *inFlightRequest) {
+	key := requestKey(id)
+	ctx, cancel := context.WithCancel(ctx)
+	request := &inFlightRequest{cancel: cancel}
+
+	h.mu.Lock()
+	h.inFlight[key] = request
+	h.mu.Unlock()
+
+	return ctx, key, request
+}
+
+// untrack removes a finished request and releases its context
+func (h *Handler) untrack(key string, request *inFlightRequest) {
+	h.mu.Lock()
+	if h.inFlight[key] == request {
+		delete(h.inFlight, key)
+	}
+	h.mu.Unlock()
+
+	request.cancel()
+}
+
+// wasCancelled reports whether a request was cancelled by the client
+func (h *Handler) wasCancelled(request *inFlightRequest) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return request.cancelled
+}
+
+// handleCancelled cancels the running request named by a
+// notifications/cancelled message. Unknown or finished requests are ignored.
+func (h *Handler) handleCancelled(req *JSONRPCRequest) {
+	var params CancelledNotification
+	if err := decodeParams(req, &params); err != nil || params.RequestID == nil {
+		h.debugLog("Ignoring invalid cancellation: %v", req.Params)
+		return
+	}
+
+	key := requestKey(params.RequestID)
+
+	h.mu.Lock()
+	request := h.inFlight[key]
+	if request != nil {
+		request.cancelled = true
+	}
+	h.mu.Unlock()
+
+	if request == nil {
+		h.debugLog("Ignoring cancellation of unknown request %s", key)
+		return
+	}
+
+	h.debugLog("Cancelling request %s: %s", key, params.Reason)
+	request.cancel()
+}
+
+// requestKey encodes a request ID so numeric and string IDs stay distinct
+func requestKey(id interface{}) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+// isInitialized reports whether initialize has been received
+func (h *Handler) isInitialized() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.initialized
+}
+
 // handleInitialize handles the initialize request
 func (h *Handler) handleInitialize(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var initReq InitializeRequest
@@ -111,6 +510,9 @@ func (h *Handler) handleInitialize(_ context.Context, req *JSONRPCRequest) *JSON
 	h.debugLog("Initialize request: protocol=%s, client=%s %s",
 		initReq.ProtocolVersion, initReq.ClientInfo.Name, initReq.ClientInfo.Version)
 
+	// Resources can be subscribed to when the graph publishes its changes
+	_, subscribable := h.graph.(graph.ChangeFeed)
+
 	// Create response
 	response := InitializeResponse{
 		ProtocolVersion: "2024-11-05",
@@ -118,6 +520,10 @@ func (h *Handler) handleInitialize(_ context.Context, req *JSONRPCRequest) *JSON
 			Tools: &ToolsCapability{
 				ListChanged: false,
 			},
+			Resources: &ResourcesCapability{
+				Subscribe: subscribable,
+			},
+			Prompts: &PromptsCapability{},
 		},
 		ServerInfo: ServerInfo{
 			Name:    "RelatixDB",
@@ -125,7 +531,10 @@ func (h *Handler) handleInitialize(_ context.Context, req *JSONRPCRequest) *JSON
 		},
 	}
 
+	h.mu.Lock()
 	h.initialized = true
+	h.clientName = initReq.ClientInfo.Name
+	h.mu.Unlock()
 	h.debugLog("Server initialized successfully")
 
 	return NewJSONRPCResponse(req.ID, response)
@@ -133,7 +542,7 @@ func (h *Handler) handleInitialize(_ context.Context, req *JSONRPCRequest) *JSON
 
 // handleToolsList handles the tools/list request
 func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
-	if !h.initialized {
+	if !h.isInitialized() {
 		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
 	}
 
@@ -143,10 +552,11 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 	tools := []Tool{
 		{
 			Name:        "add_node",
-			Description: "Add a node to the graph with ID, optional type, and properties",
+			Description: "Add a node to the graph with ID, optional type, and properties. If the graph has a schema (see schema_get), the node must follow it.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"actor": actorSchema(),
 					"id": map[string]interface{}{
 						"type":        "string",
 						"description": "Unique identifier for the node",
@@ -156,11 +566,9 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 						"description": "Optional type of the node (e.g., 'file', 'function', 'module')",
 					},
 					"props": map[string]interface{}{
-						"type":        "object",
-						"description": "Optional key/value properties for the node",
-						"additionalProperties": map[string]interface{}{
-							"type": "string",
-						},
+						"type":                 "object",
+						"description":          "Optional key/value properties for the node",
+						"additionalProperties": propValueSchema(),
 					},
 				},
 				Required: []string{"id"},
@@ -168,10 +576,11 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 		},
 		{
 			Name:        "add_edge",
-			Description: "Add a directed, labeled edge between two nodes",
+			Description: "Add a directed, labeled edge between two nodes. If the graph has a schema (see schema_get), the edge must follow it.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"actor": actorSchema(),
 					"from": map[string]interface{}{
 						"type":        "string",
 						"description": "Source node ID",
@@ -185,22 +594,105 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 						"description": "Edge label/relationship type",
 					},
 					"props": map[string]interface{}{
-						"type":        "object",
-						"description": "Optional key/value properties for the edge",
-						"additionalProperties": map[string]interface{}{
-							"type": "string",
-						},
+						"type":                 "object",
+						"description":          "Optional key/value properties for the edge",
+						"additionalProperties": propValueSchema(),
 					},
 				},
 				Required: []string{"from", "to", "label"},
 			},
 		},
+		{
+			Name:        "update_node",
+			Description: "Update an existing node in place (keeping its edges): change its type, merge or replace properties, and remove properties",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: updateSchemaProperties(map[string]interface{}{
+					"actor": actorSchema(),
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of the node to update",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional new node type",
+					},
+				}),
+				Required: []string{"id"},
+			},
+		},
+		{
+			Name:        "upsert_node",
+			Description: "Create a node, or update it in place if it already exists",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: updateSchemaProperties(map[string]interface{}{
+					"actor": actorSchema(),
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Unique identifier for the node",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Node type (an existing node keeps its type when omitted)",
+					},
+				}),
+				Required: []string{"id"},
+			},
+		},
+		{
+			Name:        "update_edge",
+			Description: "Update an existing edge's properties in place: merge or replace properties, and remove properties",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: updateSchemaProperties(map[string]interface{}{
+					"actor": actorSchema(),
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Source node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Target node ID",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge label/relationship type",
+					},
+				}),
+				Required: []string{"from", "to", "label"},
+			},
+		},
+		{
+			Name:        "upsert_edge",
+			Description: "Create an edge between existing nodes, or update its properties if it already exists",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: updateSchemaProperties(map[string]interface{}{
+					"actor": actorSchema(),
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Source node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Target node ID",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge label/relationship type",
+					},
+				}),
+				Required: []string{"from", "to", "label"},
+			},
+		},
 		{
 			Name:        "delete_node",
 			Description: "Delete a node from the graph (and all connected edges)",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"actor": actorSchema(),
 					"id": map[string]interface{}{
 						"type":        "string",
 						"description": "ID of the node to delete",
@@ -215,6 +707,7 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"actor": actorSchema(),
 					"from": map[string]interface{}{
 						"type":        "string",
 						"description": "Source node ID",
@@ -231,12 +724,79 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 				Required: []string{"from", "to", "label"},
 			},
 		},
+		{
+			Name:        "batch",
+			Description: "Apply an ordered list of node and edge operations atomically: either all succeed or none are applied",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"actor": actorSchema(),
+					"operations": map[string]interface{}{
+						"type":        "array",
+						"description": "Operations to apply in order",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"op": map[string]interface{}{
+									"type":        "string",
+									"description": "Operation kind",
+									"enum": []string{"add_node", "update_node", "upsert_node", "delete_node",
+										"add_edge", "update_edge", "upsert_edge", "delete_edge"},
+								},
+								"id": map[string]interface{}{
+									"type":        "string",
+									"description": "Node ID (node operations)",
+								},
+								"type": map[string]interface{}{
+									"type":        "string",
+									"description": "Node type (add_node, update_node)",
+								},
+								"from": map[string]interface{}{
+									"type":        "string",
+									"description": "Source node ID (edge operations)",
+								},
+								"to": map[string]interface{}{
+									"type":        "string",
+									"description": "Target node ID (edge operations)",
+								},
+								"label": map[string]interface{}{
+									"type":        "string",
+									"description": "Edge label (edge operations)",
+								},
+								"props": map[string]interface{}{
+									"type":        "object",
+									"description": "Key/value properties",
+									"additionalProperties": map[string]interface{}{
+										"type": "string",
+									},
+								},
+								"remove": map[string]interface{}{
+									"type":        "array",
+									"description": "Property keys to remove (update and upsert)",
+									"items": map[string]interface{}{
+										"type": "string",
+									},
+								},
+								"mode": map[string]interface{}{
+									"type":        "string",
+									"description": "'merge' (default) or 'replace' existing properties (update and upsert)",
+									"enum":        []string{graph.UpdateMerge, graph.UpdateReplace},
+								},
+							},
+							"required": []string{"op"},
+						},
+					},
+				},
+				Required: []string{"operations"},
+			},
+		},
 		{
 			Name:        "query_neighbors",
 			Description: "Find neighboring nodes connected to a specific node",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
 					"node": map[string]interface{}{
 						"type":        "string",
 						"description": "Node ID to find neighbors for",
@@ -256,10 +816,11 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 		},
 		{
 			Name:        "query_paths",
-			Description: "Find paths between two nodes in the graph",
+			Description: "Find paths between two nodes in the graph, including the edges traversed",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
 					"from": map[string]interface{}{
 						"type":        "string",
 						"description": "Starting node ID",
@@ -268,6 +829,18 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 						"type":        "string",
 						"description": "Target node ID",
 					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional edge labels the path may traverse (default: any label)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of edges to follow: 'out' (default), 'in', or 'both'",
+						"enum":        []string{"in", "out", "both"},
+					},
 					"max_depth": map[string]interface{}{
 						"type":        "integer",
 						"description": "Maximum path depth to search (default: 4)",
@@ -278,30 +851,323 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 				Required: []string{"from", "to"},
 			},
 		},
+		{
+			Name:        "query_shortest_path",
+			Description: "Find the shortest path (fewest hops) between two nodes",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Starting node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Target node ID",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional edge labels the path may traverse (default: any label)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of edges to follow: 'out' (default), 'in', or 'both'",
+						"enum":        []string{"in", "out", "both"},
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Optional maximum number of hops to search (default: unlimited)",
+						"minimum":     1,
+					},
+				},
+				Required: []string{"from", "to"},
+			},
+		},
+		{
+			Name:        "query_weighted_shortest_path",
+			Description: "Find the lowest-cost path between two nodes using a numeric edge property as the weight",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Starting node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Target node ID",
+					},
+					"weight_prop": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge property holding a finite, non-negative numeric weight (edges without it weigh 1)",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional edge labels the path may traverse (default: any label)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of edges to follow: 'out' (default), 'in', or 'both'",
+						"enum":        []string{"in", "out", "both"},
+					},
+				},
+				Required: []string{"from", "to", "weight_prop"},
+			},
+		},
 		{
 			Name:        "query_find",
-			Description: "Find nodes matching specific criteria (type and/or properties)",
+			Description: "Find nodes matching specific criteria (type, properties and/or a filter expression)",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]interface{}{
+					"as_of":   asOfSchema(),
+					"explain": explainSchema(),
 					"type": map[string]interface{}{
 						"type":        "string",
 						"description": "Node type to search for",
 					},
 					"props": map[string]interface{}{
-						"type":        "object",
-						"description": "Key/value properties to match",
-						"additionalProperties": map[string]interface{}{
-							"type": "string",
-						},
+						"type":                 "object",
+						"description":          "Key/value properties to match exactly",
+						"additionalProperties": propValueSchema(),
+					},
+					"where": map[string]interface{}{
+						"type": "object",
+						"description": "Filter expression: either {\"and\": [...]}, {\"or\": [...]}, {\"not\": {...}} " +
+							"or a comparison {\"field\": \"id\"|\"type\"|<prop>, \"op\": ..., \"value\": ..., \"values\": [...]}. " +
+							"Operators: eq, ne, prefix, suffix, contains, regex, exists, not_exists, in (values), " +
+							"lt, lte, gt, gte (numbers, or RFC 3339 timestamps), between (values: [min, max]). " +
+							"Comparisons use the text form of property values; contains also matches an item of a list",
 					},
 				},
 			},
 		},
-	}
-
-	response := ListToolsResponse{
-		Tools: tools,
+		{
+			Name:        "query_find_edges",
+			Description: "Find edges matching criteria (label, endpoints, endpoint types, properties and/or a filter expression)",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of":   asOfSchema(),
+					"explain": explainSchema(),
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge label to match",
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Source node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Target node ID",
+					},
+					"from_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Type of the source node",
+					},
+					"to_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Type of the target node",
+					},
+					"props": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Key/value edge properties to match exactly",
+						"additionalProperties": propValueSchema(),
+					},
+					"where": map[string]interface{}{
+						"type":        "object",
+						"description": "Filter expression as in query_find; fields are \"label\", \"from\", \"to\" or an edge property",
+					},
+				},
+			},
+		},
+		{
+			Name:        "query_subgraph",
+			Description: "Retrieve the subgraph around one or more seed nodes in one call: every node within a number of hops and every edge between those nodes",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
+					"seeds": map[string]interface{}{
+						"type":        "array",
+						"description": "IDs of the nodes to expand from",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"hops": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of hops to expand from the seeds (default: 1)",
+						"minimum":     1,
+						"maximum":     10,
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of edges to follow (default: both)",
+						"enum":        []string{"in", "out", "both"},
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional edge labels to follow and return (default: any label)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"node_types": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional node types to include besides the seeds (default: any type)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum number of nodes to return (default: %d)", graph.DefaultSubgraphLimit),
+						"minimum":     1,
+						"maximum":     graph.MaxSubgraphLimit,
+					},
+				},
+				Required: []string{"seeds"},
+			},
+		},
+		{
+			Name: "query",
+			Description: "Match a graph pattern and return a row of variable bindings per match, e.g. " +
+				"(f:function {language: \"go\"})-[:calls*1..3]->(g:function)-[:defined_in]->(file). " +
+				"Nodes are (var:type {key: value}), with key id matching the node ID; relationships are " +
+				"-[var:label|label *min..max {key: value}]-> or <-[...]- or -[...]- for either direction. " +
+				"Comma-separated paths sharing variables must all match.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of":   asOfSchema(),
+					"explain": explainSchema(),
+					"pattern": map[string]interface{}{
+						"type":        "string",
+						"description": "Pattern to match",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum number of rows to return (default: %d)", graph.DefaultMatchLimit),
+						"minimum":     1,
+						"maximum":     graph.MaxMatchLimit,
+					},
+				},
+				Required: []string{"pattern"},
+			},
+		},
+		{
+			Name:        "render_dot",
+			Description: "Render the graph, the neighborhood of a node, or the paths between two nodes as Graphviz DOT text, with nodes grouped and colored by type and edges labeled",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"as_of": asOfSchema(),
+					"node": map[string]interface{}{
+						"type":        "string",
+						"description": "Render the neighborhood of this node",
+					},
+					"hops": map[string]interface{}{
+						"type":        "integer",
+						"description": "Neighborhood radius around node (default: 1)",
+						"minimum":     0,
+						"maximum":     10,
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Render the paths from this node (requires to)",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Render the paths to this node (requires from)",
+					},
+					"max_depth": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum path length for from/to (default: 4)",
+						"minimum":     1,
+						"maximum":     10,
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional edge labels paths may traverse (default: any label)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"direction": map[string]interface{}{
+						"type":        "string",
+						"description": "Direction of edges to follow: 'both' (default for node), 'out' (default for paths), or 'in'",
+						"enum":        []string{"in", "out", "both"},
+					},
+				},
+			},
+		},
+		{
+			Name:        "get_history",
+			Description: "List every recorded version of a node (by id) or an edge (by from, to and label): when it was created, updated or deleted, by whom, and what it looked like",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Node ID",
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge source node ID",
+					},
+					"to": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge target node ID",
+					},
+					"label": map[string]interface{}{
+						"type":        "string",
+						"description": "Edge label",
+					},
+				},
+			},
+		},
+		{
+			Name:        "schema_get",
+			Description: "Show the graph's schema: its declared node types and properties, which node types each edge label may connect, and whether violations are rejected (strict) or reported (warn)",
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]interface{}{},
+			},
+		},
+		{
+			Name: "schema_set",
+			Description: "Replace or remove the graph's schema, which every write tool enforces, and report existing nodes and edges that don't follow it. " +
+				"A schema is {\"mode\": \"strict\"|\"warn\", \"open\": bool, \"node_types\": {type: {\"properties\": {key: {\"required\": bool, \"type\": \"string\"|\"int\"|\"float\"|\"bool\"|\"timestamp\"|\"list\", \"pattern\": regex}}, \"additional_properties\": bool}}, " +
+				"\"edge_labels\": {label: {\"connections\": [{\"from\": type, \"to\": type}], \"properties\": {...}, \"additional_properties\": bool}}}. " +
+				"Undeclared node types and edge labels are violations unless open is true; \"*\" in a connection matches any type.",
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]interface{}{
+					"schema": map[string]interface{}{
+						"type":        "object",
+						"description": "The new schema",
+					},
+					"remove": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Remove the schema instead of setting one",
+					},
+				},
+			},
+		},
+	}
+
+	response := ListToolsResponse{
+		Tools: tools,
 	}
 
 	h.debugLog("Returning %d tools", len(tools))
@@ -310,7 +1176,7 @@ func (h *Handler) handleToolsList(_ context.Context, req *JSONRPCRequest) *JSONR
 
 // handleToolsCall handles the tools/call request
 func (h *Handler) handleToolsCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
-	if !h.initialized {
+	if !h.isInitialized() {
 		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
 	}
 
@@ -327,6 +1193,17 @@ func (h *Handler) handleToolsCall(ctx context.Context, req *JSONRPCRequest) *JSO
 
 	h.debugLog("Calling tool: %s with args: %v", callReq.Name, callReq.Arguments)
 
+	// Attribute changes to the named actor, or else to the client
+	actor, _ := callReq.Arguments["actor"].(string)
+	if actor == "" {
+		h.mu.RLock()
+		actor = h.clientName
+		h.mu.RUnlock()
+	}
+	if actor != "" {
+		ctx = graph.WithActor(ctx, actor)
+	}
+
 	// Execute the tool
 	result, err := h.executeTool(ctx, callReq.Name, callReq.Arguments)
 	if err != nil {
@@ -353,16 +1230,44 @@ func (h *Handler) executeTool(ctx context.Context, toolName string, args map[str
 		return h.executeAddNode(ctx, args)
 	case "add_edge":
 		return h.executeAddEdge(ctx, args)
+	case "update_node":
+		return h.executeUpdateNode(ctx, args)
+	case "upsert_node":
+		return h.executeUpsertNode(ctx, args)
+	case "update_edge":
+		return h.executeUpdateEdge(ctx, args)
+	case "upsert_edge":
+		return h.executeUpsertEdge(ctx, args)
 	case "delete_node":
 		return h.executeDeleteNode(ctx, args)
 	case "delete_edge":
 		return h.executeDeleteEdge(ctx, args)
+	case "batch":
+		return h.executeBatch(ctx, args)
 	case "query_neighbors":
 		return h.executeQueryNeighbors(ctx, args)
 	case "query_paths":
 		return h.executeQueryPaths(ctx, args)
+	case "query_shortest_path":
+		return h.executeQueryShortestPath(ctx, args)
+	case "query_weighted_shortest_path":
+		return h.executeQueryWeightedShortestPath(ctx, args)
 	case "query_find":
 		return h.executeQueryFind(ctx, args)
+	case "query_find_edges":
+		return h.executeQueryFindEdges(ctx, args)
+	case "query_subgraph":
+		return h.executeQuerySubgraph(ctx, args)
+	case "query":
+		return h.executeQuery(ctx, args)
+	case "render_dot":
+		return h.executeRenderDOT(ctx, args)
+	case "get_history":
+		return h.executeGetHistory(ctx, args)
+	case "schema_get":
+		return h.executeSchemaGet(ctx, args)
+	case "schema_set":
+		return h.executeSchemaSet(ctx, args)
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", toolName)
 	}
@@ -377,13 +1282,9 @@ func (h *Handler) executeAddNode(ctx context.Context, args map[string]interface{
 
 	nodeType, _ := args["type"].(string)
 
-	props := make(map[string]string)
-	if propsRaw, ok := args["props"].(map[string]interface{}); ok {
-		for k, v := range propsRaw {
-			if strVal, ok := v.(string); ok {
-				props[k] = strVal
-			}
-		}
+	props, err := propsArg(args, "props")
+	if err != nil {
+		return nil, err
 	}
 
 	node := graph.Node{
@@ -392,7 +1293,8 @@ func (h *Handler) executeAddNode(ctx context.Context, args map[string]interface{
 		Props: props,
 	}
 
-	if err := h.graph.AddNode(ctx, node); err != nil {
+	ops, warnings := h.operations()
+	if err := ops.CreateNode(ctx, node); err != nil {
 		return nil, err
 	}
 
@@ -400,7 +1302,7 @@ func (h *Handler) executeAddNode(ctx context.Context, args map[string]interface{
 		Content: []ContentItem{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("Successfully added node '%s' with type '%s'", id, nodeType),
+				Text: fmt.Sprintf("Successfully added node '%s' with type '%s'", id, nodeType) + formatSchemaWarnings(*warnings),
 			},
 		},
 	}, nil
@@ -423,13 +1325,9 @@ func (h *Handler) executeAddEdge(ctx context.Context, args map[string]interface{
 		return nil, fmt.Errorf("label is required and must be a string")
 	}
 
-	props := make(map[string]string)
-	if propsRaw, ok := args["props"].(map[string]interface{}); ok {
-		for k, v := range propsRaw {
-			if strVal, ok := v.(string); ok {
-				props[k] = strVal
-			}
-		}
+	props, err := propsArg(args, "props")
+	if err != nil {
+		return nil, err
 	}
 
 	edge := graph.Edge{
@@ -439,7 +1337,158 @@ func (h *Handler) executeAddEdge(ctx context.Context, args map[string]interface{
 		Props: props,
 	}
 
-	if err := h.graph.AddEdge(ctx, edge); err != nil {
+	ops, warnings := h.operations()
+	if err := ops.CreateEdge(ctx, edge); err != nil {
+		return nil, err
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Successfully added edge '%s' -> '%s' with label '%s'", from, to, label) + formatSchemaWarnings(*warnings),
+			},
+		},
+	}, nil
+}
+
+// executeUpdateNode executes the update_node tool
+func (h *Handler) executeUpdateNode(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("id is required and must be a string")
+	}
+
+	update, err := updateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	if update.Type == "" && len(update.Props) == 0 && len(update.Remove) == 0 && update.Mode != graph.UpdateReplace {
+		return nil, fmt.Errorf("nothing to update: provide type, props, remove or mode 'replace'")
+	}
+
+	_, warnings, err := h.applyOp(ctx, graph.BatchOp{
+		Op: graph.OpUpdateNode, ID: id, Type: update.Type, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.nodeResponse(ctx, "Successfully updated", id, warnings)
+}
+
+// executeUpsertNode executes the upsert_node tool
+func (h *Handler) executeUpsertNode(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	id, ok := args["id"].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("id is required and must be a string")
+	}
+
+	update, err := updateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	created, warnings, err := h.applyOp(ctx, graph.BatchOp{
+		Op: graph.OpUpsertNode, ID: id, Type: update.Type, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		return h.nodeResponse(ctx, "Successfully created", id, warnings)
+	}
+	return h.nodeResponse(ctx, "Successfully updated", id, warnings)
+}
+
+// executeUpdateEdge executes the update_edge tool
+func (h *Handler) executeUpdateEdge(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	from, to, label, err := edgeKeyArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := updateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(update.Props) == 0 && len(update.Remove) == 0 && update.Mode != graph.UpdateReplace {
+		return nil, fmt.Errorf("nothing to update: provide props, remove or mode 'replace'")
+	}
+
+	_, warnings, err := h.applyOp(ctx, graph.BatchOp{
+		Op: graph.OpUpdateEdge, From: from, To: to, Label: label, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return h.edgeResponse(ctx, "Successfully updated", from, to, label, warnings)
+}
+
+// executeUpsertEdge executes the upsert_edge tool
+func (h *Handler) executeUpsertEdge(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	from, to, label, err := edgeKeyArgs(args)
+	if err != nil {
+		return nil, err
+	}
+
+	update, err := updateArg(args)
+	if err != nil {
+		return nil, err
+	}
+	created, warnings, err := h.applyOp(ctx, graph.BatchOp{
+		Op: graph.OpUpsertEdge, From: from, To: to, Label: label, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		return h.edgeResponse(ctx, "Successfully created", from, to, label, warnings)
+	}
+	return h.edgeResponse(ctx, "Successfully updated", from, to, label, warnings)
+}
+
+// applyOp applies a single update or upsert atomically, checking the result
+// against the schema, and reports whether it created a new element along
+// with any schema warnings
+func (h *Handler) applyOp(ctx context.Context, op graph.BatchOp) (bool, []string, error) {
+	ops, warnings := h.operations()
+	results, err := ops.ApplyBatch(ctx, []graph.BatchOp{op})
+	if err != nil {
+		if len(results) == 1 && results[0].Error != "" {
+			return false, nil, errors.New(results[0].Error)
+		}
+		return false, nil, err
+	}
+
+	return results[0].Created, *warnings, nil
+}
+
+// nodeResponse reports a node mutation along with the node's resulting state
+// and any schema warnings
+func (h *Handler) nodeResponse(ctx context.Context, verb, id string, warnings []string) (*CallToolResponse, error) {
+	node, err := h.graph.GetNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("%s node '%s' (type: %s)%s", verb, node.ID, node.Type, formatProps(node.Props)) + formatSchemaWarnings(warnings),
+			},
+		},
+	}, nil
+}
+
+// edgeResponse reports an edge mutation along with the edge's resulting state
+// and any schema warnings
+func (h *Handler) edgeResponse(ctx context.Context, verb, from, to, label string, warnings []string) (*CallToolResponse, error) {
+	edge, err := h.graph.GetEdge(ctx, from, to, label)
+	if err != nil {
 		return nil, err
 	}
 
@@ -447,7 +1496,7 @@ func (h *Handler) executeAddEdge(ctx context.Context, args map[string]interface{
 		Content: []ContentItem{
 			{
 				Type: "text",
-				Text: fmt.Sprintf("Successfully added edge '%s' -> '%s' with label '%s'", from, to, label),
+				Text: fmt.Sprintf("%s edge '%s' -> '%s' with label '%s'%s", verb, edge.From, edge.To, edge.Label, formatProps(edge.Props)) + formatSchemaWarnings(warnings),
 			},
 		},
 	}, nil
@@ -505,6 +1554,35 @@ func (h *Handler) executeDeleteEdge(ctx context.Context, args map[string]interfa
 	}, nil
 }
 
+// executeBatch executes the batch tool
+func (h *Handler) executeBatch(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	var ops []graph.BatchOp
+	if err := decodeArg(args, "operations", &ops); err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("operations is required and must be a non-empty array")
+	}
+
+	operations, warnings := h.operations()
+	results, err := operations.ApplyBatch(ctx, ops)
+	if err != nil {
+		if results == nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w\n%s", err, formatBatchResults(ops, results))
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: fmt.Sprintf("Applied %d operations atomically:\n%s", len(ops), formatBatchResults(ops, results)) + formatSchemaWarnings(*warnings),
+			},
+		},
+	}, nil
+}
+
 // executeQueryNeighbors executes the query_neighbors tool
 func (h *Handler) executeQueryNeighbors(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
 	node, ok := args["node"].(string)
@@ -519,11 +1597,17 @@ func (h *Handler) executeQueryNeighbors(ctx context.Context, args map[string]int
 
 	label, _ := args["label"].(string)
 
-	query := graph.Query{
-		Type:      "neighbors",
-		Node:      node,
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:      "neighbors",
+		Node:      node,
 		Direction: direction,
 		Label:     label,
+		AsOf:      asOf,
 	}
 
 	result, err := h.graph.Query(ctx, query)
@@ -573,11 +1657,21 @@ func (h *Handler) executeQueryPaths(ctx context.Context, args map[string]interfa
 		}
 	}
 
+	direction, _ := args["direction"].(string)
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
 	query := graph.Query{
-		Type:     "paths",
-		From:     from,
-		To:       to,
-		MaxDepth: maxDepth,
+		Type:      "paths",
+		From:      from,
+		To:        to,
+		MaxDepth:  maxDepth,
+		Direction: direction,
+		Labels:    stringSliceArg(args, "labels"),
+		AsOf:      asOf,
 	}
 
 	result, err := h.graph.Query(ctx, query)
@@ -588,14 +1682,122 @@ func (h *Handler) executeQueryPaths(ctx context.Context, args map[string]interfa
 	// Format the result
 	resultText := fmt.Sprintf("Found %d paths from '%s' to '%s':\n", len(result.Paths), from, to)
 	for i, path := range result.Paths {
-		resultText += fmt.Sprintf("Path %d: ", i+1)
-		for j, node := range path.Nodes {
-			if j > 0 {
-				resultText += " -> "
-			}
-			resultText += node.ID
-		}
-		resultText += "\n"
+		resultText += fmt.Sprintf("Path %d: %s\n", i+1, formatPath(path))
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeQueryShortestPath executes the query_shortest_path tool
+func (h *Handler) executeQueryShortestPath(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	from, ok := args["from"].(string)
+	if !ok || from == "" {
+		return nil, fmt.Errorf("from is required and must be a string")
+	}
+
+	to, ok := args["to"].(string)
+	if !ok || to == "" {
+		return nil, fmt.Errorf("to is required and must be a string")
+	}
+
+	maxDepth := 0
+	if maxDepthFloat, ok := args["max_depth"].(float64); ok {
+		maxDepth = int(maxDepthFloat)
+	}
+
+	direction, _ := args["direction"].(string)
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:      "shortest_path",
+		From:      from,
+		To:        to,
+		MaxDepth:  maxDepth,
+		Direction: direction,
+		Labels:    stringSliceArg(args, "labels"),
+		AsOf:      asOf,
+	}
+
+	result, err := h.graph.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultText string
+	if len(result.Paths) == 0 {
+		resultText = fmt.Sprintf("No path found from '%s' to '%s'\n", from, to)
+	} else {
+		path := result.Paths[0]
+		resultText = fmt.Sprintf("Shortest path from '%s' to '%s' (%d hops):\n%s\n", from, to, len(path.Edges), formatPath(path))
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeQueryWeightedShortestPath executes the query_weighted_shortest_path tool
+func (h *Handler) executeQueryWeightedShortestPath(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	from, ok := args["from"].(string)
+	if !ok || from == "" {
+		return nil, fmt.Errorf("from is required and must be a string")
+	}
+
+	to, ok := args["to"].(string)
+	if !ok || to == "" {
+		return nil, fmt.Errorf("to is required and must be a string")
+	}
+
+	weightProp, ok := args["weight_prop"].(string)
+	if !ok || weightProp == "" {
+		return nil, fmt.Errorf("weight_prop is required and must be a string")
+	}
+
+	direction, _ := args["direction"].(string)
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:       "weighted_shortest_path",
+		From:       from,
+		To:         to,
+		Direction:  direction,
+		Labels:     stringSliceArg(args, "labels"),
+		WeightProp: weightProp,
+		AsOf:       asOf,
+	}
+
+	result, err := h.graph.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resultText string
+	if len(result.Paths) == 0 {
+		resultText = fmt.Sprintf("No path found from '%s' to '%s'\n", from, to)
+	} else {
+		path := result.Paths[0]
+		resultText = fmt.Sprintf("Lowest-cost path from '%s' to '%s' (cost %g by '%s', %d hops):\n%s\n",
+			from, to, path.Cost, weightProp, len(path.Edges), formatPath(path))
 	}
 
 	return &CallToolResponse{
@@ -612,13 +1814,9 @@ func (h *Handler) executeQueryPaths(ctx context.Context, args map[string]interfa
 func (h *Handler) executeQueryFind(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
 	nodeType, _ := args["type"].(string)
 
-	filters := make(map[string]string)
-	if propsRaw, ok := args["props"].(map[string]interface{}); ok {
-		for k, v := range propsRaw {
-			if strVal, ok := v.(string); ok {
-				filters[k] = strVal
-			}
-		}
+	filters, err := filtersArg(args, "props")
+	if err != nil {
+		return nil, err
 	}
 
 	// Add type to filters if specified
@@ -626,38 +1824,332 @@ func (h *Handler) executeQueryFind(ctx context.Context, args map[string]interfac
 		filters["type"] = nodeType
 	}
 
-	if len(filters) == 0 {
-		return nil, fmt.Errorf("at least one filter (type or props) is required")
+	var where *graph.Filter
+	if _, ok := args["where"]; ok {
+		where = &graph.Filter{}
+		if err := decodeArg(args, "where", where); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(filters) == 0 && where == nil {
+		return nil, fmt.Errorf("at least one filter (type, props or where) is required")
+	}
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
 	}
 
 	query := graph.Query{
 		Type:    "find",
 		Filters: filters,
+		Where:   where,
+		AsOf:    asOf,
 	}
+	query.Explain, _ = args["explain"].(bool)
 
 	result, err := h.graph.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	if query.Explain {
+		return planResponse(result.Plan), nil
+	}
 
 	// Format the result
 	resultText := fmt.Sprintf("Found %d nodes matching criteria:\n", len(result.Nodes))
 	for _, n := range result.Nodes {
 		resultText += fmt.Sprintf("- %s (type: %s)", n.ID, n.Type)
-		if len(n.Props) > 0 {
-			resultText += " {"
-			first := true
-			for k, v := range n.Props {
-				if !first {
-					resultText += ", "
-				}
-				resultText += fmt.Sprintf("%s: %s", k, v)
-				first = false
-			}
-			resultText += "}"
+		resultText += formatProps(n.Props)
+		resultText += "\n"
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeQueryFindEdges executes the query_find_edges tool
+func (h *Handler) executeQueryFindEdges(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	label, _ := args["label"].(string)
+	from, _ := args["from"].(string)
+	to, _ := args["to"].(string)
+	fromType, _ := args["from_type"].(string)
+	toType, _ := args["to_type"].(string)
+
+	filters, err := filtersArg(args, "props")
+	if err != nil {
+		return nil, err
+	}
+
+	var where *graph.Filter
+	if _, ok := args["where"]; ok {
+		where = &graph.Filter{}
+		if err := decodeArg(args, "where", where); err != nil {
+			return nil, err
+		}
+	}
+
+	if label == "" && from == "" && to == "" && fromType == "" && toType == "" && len(filters) == 0 && where == nil {
+		return nil, fmt.Errorf("at least one criterion (label, from, to, from_type, to_type, props or where) is required")
+	}
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:     "find_edges",
+		Label:    label,
+		From:     from,
+		To:       to,
+		FromType: fromType,
+		ToType:   toType,
+		Filters:  filters,
+		Where:    where,
+		AsOf:     asOf,
+	}
+	query.Explain, _ = args["explain"].(bool)
+
+	result, err := h.graph.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Explain {
+		return planResponse(result.Plan), nil
+	}
+
+	// Format the result
+	resultText := fmt.Sprintf("Found %d edges matching criteria:\n", len(result.Edges))
+	for _, e := range result.Edges {
+		resultText += fmt.Sprintf("- %s -> %s (%s)", e.From, e.To, e.Label)
+		resultText += formatProps(e.Props)
+		resultText += "\n"
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeQuerySubgraph executes the query_subgraph tool
+func (h *Handler) executeQuerySubgraph(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	seeds := stringSliceArg(args, "seeds")
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("seeds is required and must be a non-empty array of node IDs")
+	}
+
+	hops := 1
+	if hopsFloat, ok := args["hops"].(float64); ok {
+		hops = int(hopsFloat)
+		if hops < 1 {
+			return nil, fmt.Errorf("hops must be at least 1")
+		}
+	}
+
+	limit := 0
+	if limitFloat, ok := args["limit"].(float64); ok {
+		limit = int(limitFloat)
+		if limit < 1 {
+			return nil, fmt.Errorf("limit must be at least 1")
+		}
+	}
+
+	direction, _ := args["direction"].(string)
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:      "subgraph",
+		Seeds:     seeds,
+		MaxDepth:  hops,
+		Direction: direction,
+		Labels:    stringSliceArg(args, "labels"),
+		NodeTypes: stringSliceArg(args, "node_types"),
+		Limit:     limit,
+		AsOf:      asOf,
+	}
+
+	result, err := h.graph.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Format the result
+	resultText := fmt.Sprintf("Subgraph within %d hops of %s: %d nodes, %d edges\n",
+		hops, strings.Join(seeds, ", "), len(result.Nodes), len(result.Edges))
+	if result.Truncated {
+		if limit == 0 {
+			limit = graph.DefaultSubgraphLimit
 		}
+		resultText += fmt.Sprintf("Stopped at the limit of %d nodes; narrow the query or raise limit to see more\n", limit)
+	}
+	resultText += "Nodes:\n"
+	for _, n := range result.Nodes {
+		resultText += fmt.Sprintf("- %s (type: %s)", n.ID, n.Type)
+		resultText += formatProps(n.Props)
 		resultText += "\n"
 	}
+	resultText += "Edges:\n"
+	for _, e := range result.Edges {
+		resultText += fmt.Sprintf("- %s -> %s (%s)", e.From, e.To, e.Label)
+		resultText += formatProps(e.Props)
+		resultText += "\n"
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeQuery executes the query tool
+func (h *Handler) executeQuery(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("pattern is required and must be a string")
+	}
+
+	limit := 0
+	if limitFloat, ok := args["limit"].(float64); ok {
+		limit = int(limitFloat)
+		if limit < 1 {
+			return nil, fmt.Errorf("limit must be at least 1")
+		}
+	}
+
+	asOf, err := asOfArg(args)
+	if err != nil {
+		return nil, err
+	}
+
+	query := graph.Query{
+		Type:    "match",
+		Pattern: pattern,
+		Limit:   limit,
+		AsOf:    asOf,
+	}
+	query.Explain, _ = args["explain"].(bool)
+
+	result, err := h.graph.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if query.Explain {
+		return planResponse(result.Plan), nil
+	}
+
+	// Format the result
+	resultText := fmt.Sprintf("Found %d matches:\n", len(result.Rows))
+	if result.Truncated {
+		if limit == 0 {
+			limit = graph.DefaultMatchLimit
+		}
+		resultText = fmt.Sprintf("Found more than %d matches; showing the first %d:\n", limit, len(result.Rows))
+	}
+	for i, row := range result.Rows {
+		parts := make([]string, len(result.Columns))
+		for j, name := range result.Columns {
+			parts[j] = name + " = " + formatBinding(row[name])
+		}
+		resultText += fmt.Sprintf("%d. %s\n", i+1, strings.Join(parts, "; "))
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeRenderDOT executes the render_dot tool
+func (h *Handler) executeRenderDOT(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	opts := storage.DOTOptions{Hops: 1}
+	opts.Node, _ = args["node"].(string)
+	opts.From, _ = args["from"].(string)
+	opts.To, _ = args["to"].(string)
+	opts.Direction, _ = args["direction"].(string)
+	opts.Labels = stringSliceArg(args, "labels")
+	if hops, ok := args["hops"].(float64); ok {
+		opts.Hops = int(hops)
+	}
+	if maxDepth, ok := args["max_depth"].(float64); ok {
+		opts.MaxDepth = int(maxDepth)
+	}
+
+	g, err := h.graphAsOf(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf strings.Builder
+	if err := storage.NewDOTExporter(opts).Export(ctx, g, &buf); err != nil {
+		return nil, err
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: buf.String(),
+			},
+		},
+	}, nil
+}
+
+// executeGetHistory executes the get_history tool
+func (h *Handler) executeGetHistory(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	provider, ok := h.graph.(graph.HistoryProvider)
+	if !ok {
+		return nil, graph.ErrHistoryUnavailable
+	}
+
+	var (
+		subject  string
+		versions []graph.Version
+		err      error
+	)
+	if id, _ := args["id"].(string); id != "" {
+		subject = fmt.Sprintf("node '%s'", id)
+		versions, err = provider.NodeHistory(ctx, id)
+	} else {
+		from, to, label, keyErr := edgeKeyArgs(args)
+		if keyErr != nil {
+			return nil, fmt.Errorf("either id or from, to and label are required")
+		}
+		subject = fmt.Sprintf("edge %s -[%s]-> %s", from, label, to)
+		versions, err = provider.EdgeHistory(ctx, from, to, label)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resultText := fmt.Sprintf("Found %d versions of %s:\n", len(versions), subject)
+	for _, v := range versions {
+		resultText += formatVersion(v) + "\n"
+	}
 
 	return &CallToolResponse{
 		Content: []ContentItem{
@@ -669,22 +2161,482 @@ func (h *Handler) executeQueryFind(ctx context.Context, args map[string]interfac
 	}, nil
 }
 
-// writeResponse writes a JSON-RPC response to the output stream
-func (h *Handler) writeResponse(response *JSONRPCResponse) error {
-	data, err := response.ToJSON()
+// maxListedViolations bounds how many existing schema violations schema_set lists
+const maxListedViolations = 20
+
+// executeSchemaGet executes the schema_get tool
+func (h *Handler) executeSchemaGet(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	provider, ok := h.graph.(graph.SchemaProvider)
+	if !ok {
+		return nil, fmt.Errorf("graph does not support schemas")
+	}
+
+	resultText := "No schema is set: any node types, edge labels and properties are accepted."
+	if schema := provider.Schema(); schema != nil {
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schema: %w", err)
+		}
+		resultText = string(data)
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// executeSchemaSet executes the schema_set tool
+func (h *Handler) executeSchemaSet(ctx context.Context, args map[string]interface{}) (*CallToolResponse, error) {
+	provider, ok := h.graph.(graph.SchemaProvider)
+	if !ok {
+		return nil, fmt.Errorf("graph does not support schemas")
+	}
+
+	if remove, _ := args["remove"].(bool); remove {
+		if err := provider.SetSchema(nil); err != nil {
+			return nil, err
+		}
+		return &CallToolResponse{
+			Content: []ContentItem{
+				{
+					Type: "text",
+					Text: "Schema removed",
+				},
+			},
+		}, nil
+	}
+
+	if _, ok := args["schema"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("schema is required and must be an object, or set remove to true")
+	}
+	data, err := json.Marshal(args["schema"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	schema, err := graph.ParseSchema(data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal response: %w", err)
+		return nil, err
+	}
+	if err := provider.SetSchema(schema); err != nil {
+		return nil, err
+	}
+
+	violations, err := graph.NewOperations(h.graph).SchemaViolations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := schema.Mode
+	if mode == "" {
+		mode = graph.SchemaStrict
+	}
+	resultText := fmt.Sprintf("Schema set (%s mode): %d node types, %d edge labels\n", mode, len(schema.NodeTypes), len(schema.EdgeLabels))
+	if len(violations) == 0 {
+		resultText += "All existing nodes and edges follow it.\n"
+	} else {
+		resultText += fmt.Sprintf("Existing data has %d violations:\n", len(violations))
+		for i, violation := range violations {
+			if i == maxListedViolations {
+				resultText += fmt.Sprintf("... and %d more\n", len(violations)-i)
+				break
+			}
+			resultText += "- " + violation + "\n"
+		}
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: resultText,
+			},
+		},
+	}, nil
+}
+
+// operations returns graph operations for one tool call, collecting the
+// schema warnings of writes allowed in warn mode
+func (h *Handler) operations() (*graph.Operations, *[]string) {
+	warnings := &[]string{}
+	ops := graph.NewOperations(h.graph)
+	ops.OnSchemaWarning = func(violation string) {
+		*warnings = append(*warnings, violation)
+	}
+	return ops, warnings
+}
+
+// formatSchemaWarnings lists the schema violations of a write allowed in warn mode
+func formatSchemaWarnings(warnings []string) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+
+	text := "\nSchema warnings:"
+	for _, warning := range warnings {
+		text += "\n- " + warning
+	}
+	return text
+}
+
+// graphAsOf returns the graph as of the optional as_of argument, or the
+// current graph when it is absent
+func (h *Handler) graphAsOf(ctx context.Context, args map[string]interface{}) (graph.Graph, error) {
+	asOf, err := asOfArg(args)
+	if err != nil || asOf == nil {
+		return h.graph, err
+	}
+
+	provider, ok := h.graph.(graph.HistoryProvider)
+	if !ok {
+		return nil, graph.ErrHistoryUnavailable
+	}
+	return provider.AsOf(ctx, *asOf)
+}
+
+// formatVersion renders a version as "#seq time action [by actor]" followed
+// by the element's properties, e.g. "#3 2025-01-02T15:04:05Z updated by agent (type: file) {lang: go}"
+func formatVersion(v graph.Version) string {
+	text := fmt.Sprintf("#%d %s %s", v.Seq, v.Time.UTC().Format(time.RFC3339Nano), v.Action)
+	if v.Actor != "" {
+		text += " by " + v.Actor
+	}
+
+	switch {
+	case v.Node != nil:
+		if v.Node.Type != "" {
+			text += fmt.Sprintf(" (type: %s)", v.Node.Type)
+		}
+		text += formatProps(v.Node.Props)
+	case v.Edge != nil:
+		text += formatProps(v.Edge.Props)
+	}
+	return text
+}
+
+// formatBinding renders a match binding: a node as "id (type: t) {k: v}", an
+// edge as "from -[label]-> to {k: v}" and a path as formatPath does
+func formatBinding(binding graph.Binding) string {
+	switch {
+	case binding.Node != nil:
+		text := binding.Node.ID
+		if binding.Node.Type != "" {
+			text += fmt.Sprintf(" (type: %s)", binding.Node.Type)
+		}
+		return text + formatProps(binding.Node.Props)
+	case binding.Edge != nil:
+		edge := binding.Edge
+		return fmt.Sprintf("%s -[%s]-> %s%s", edge.From, edge.Label, edge.To, formatProps(edge.Props))
+	case binding.Path != nil:
+		return formatPath(*binding.Path)
+	default:
+		return ""
+	}
+}
+
+// formatPath renders a path as a chain of node IDs joined by labeled edges,
+// e.g. "a -[calls]-> b <-[imports]- c"
+func formatPath(path graph.Path) string {
+	if len(path.Nodes) == 0 {
+		return ""
+	}
+
+	text := path.Nodes[0].ID
+	for i := 1; i < len(path.Nodes); i++ {
+		if i-1 < len(path.Edges) {
+			edge := path.Edges[i-1]
+			if edge.From == path.Nodes[i-1].ID && edge.To == path.Nodes[i].ID {
+				text += fmt.Sprintf(" -[%s]-> ", edge.Label)
+			} else {
+				text += fmt.Sprintf(" <-[%s]- ", edge.Label)
+			}
+		} else {
+			text += " -> "
+		}
+		text += path.Nodes[i].ID
+	}
+
+	return text
+}
+
+// formatBatchResults renders one line per batch operation with its status
+func formatBatchResults(ops []graph.BatchOp, results []graph.BatchResult) string {
+	text := ""
+	for _, result := range results {
+		text += fmt.Sprintf("%d. %s: %s", result.Index+1, ops[result.Index], result.Status)
+		if result.Error != "" {
+			text += fmt.Sprintf(" (%s)", result.Error)
+		}
+		text += "\n"
+	}
+	return text
+}
+
+// formatProps renders properties as " {k: v, ...}" in key order, or an empty
+// string when there are none
+func formatProps(props map[string]graph.Value) string {
+	if len(props) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	text := " {"
+	for i, k := range keys {
+		if i > 0 {
+			text += ", "
+		}
+		text += fmt.Sprintf("%s: %s", k, props[k])
+	}
+	return text + "}"
+}
+
+// decodeArg decodes a structured tool argument into target via its JSON form
+func decodeArg(args map[string]interface{}, key string, target interface{}) error {
+	data, err := json.Marshal(args[key])
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return nil
+}
+
+// actorSchema describes the optional actor argument of tools that change the graph
+func actorSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Who is making the change, recorded in history (default: the client name)",
+	}
+}
+
+// asOfSchema describes the optional as_of argument of query tools
+func asOfSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "string",
+		"description": "Answer against the graph as it was at this RFC 3339 time, e.g. 2025-01-02T15:04:05Z (persistent databases only)",
+	}
+}
+
+// explainSchema describes the optional explain argument of planned query tools
+func explainSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": "Return the plan chosen for the query, with estimated candidates and rows, instead of running it",
+	}
+}
+
+// planResponse lists the steps of an explained query's plan
+func planResponse(plan []string) *CallToolResponse {
+	text := "Query plan:\n"
+	for i, step := range plan {
+		text += fmt.Sprintf("%d. %s\n", i+1, step)
+	}
+
+	return &CallToolResponse{
+		Content: []ContentItem{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+}
+
+// asOfArg parses the optional as_of argument, returning nil when it is absent
+func asOfArg(args map[string]interface{}) (*time.Time, error) {
+	raw, _ := args["as_of"].(string)
+	if raw == "" {
+		return nil, nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, fmt.Errorf("as_of must be an RFC 3339 time such as 2025-01-02T15:04:05Z")
+	}
+	return &asOf, nil
+}
+
+// updateSchemaProperties adds the props, remove and mode arguments shared by
+// the update and upsert tools to a tool's input schema properties
+func updateSchemaProperties(properties map[string]interface{}) map[string]interface{} {
+	properties["props"] = map[string]interface{}{
+		"type":                 "object",
+		"description":          "Key/value properties to set",
+		"additionalProperties": propValueSchema(),
+	}
+	properties["remove"] = map[string]interface{}{
+		"type":        "array",
+		"description": "Property keys to remove",
+		"items": map[string]interface{}{
+			"type": "string",
+		},
+	}
+	properties["mode"] = map[string]interface{}{
+		"type":        "string",
+		"description": "'merge' (default) keeps existing properties; 'replace' discards them in favor of props",
+		"enum":        []string{graph.UpdateMerge, graph.UpdateReplace},
+	}
+	return properties
+}
+
+// updateArg builds a property update from the type, props, remove and mode arguments
+func updateArg(args map[string]interface{}) (graph.Update, error) {
+	nodeType, _ := args["type"].(string)
+	mode, _ := args["mode"].(string)
+
+	props, err := propsArg(args, "props")
+	if err != nil {
+		return graph.Update{}, err
+	}
+
+	return graph.Update{
+		Type:   nodeType,
+		Props:  props,
+		Remove: stringSliceArg(args, "remove"),
+		Mode:   mode,
+	}, nil
+}
+
+// propsArg decodes an object argument of typed property values. Whole
+// numbers arrive as JSON numbers without a fraction and become ints.
+func propsArg(args map[string]interface{}, key string) (map[string]graph.Value, error) {
+	if _, ok := args[key]; !ok {
+		return nil, nil
+	}
+
+	var props map[string]graph.Value
+	if err := decodeArg(args, key, &props); err != nil {
+		return nil, err
+	}
+	return props, nil
+}
+
+// filtersArg decodes an object argument of property values to match,
+// returning their text forms
+func filtersArg(args map[string]interface{}, key string) (map[string]string, error) {
+	props, err := propsArg(args, key)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make(map[string]string, len(props))
+	for k, v := range props {
+		filters[k] = v.String()
+	}
+	return filters, nil
+}
+
+// propValueSchema describes a typed property value
+func propValueSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"description": "A string, number, boolean, array of strings, or {\"timestamp\": \"<RFC 3339 time>\"}",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "number"},
+			map[string]interface{}{"type": "boolean"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			map[string]interface{}{
+				"type":                 "object",
+				"properties":           map[string]interface{}{"timestamp": map[string]interface{}{"type": "string", "format": "date-time"}},
+				"required":             []string{"timestamp"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
+
+// edgeKeyArgs extracts the required from, to and label arguments that identify an edge
+func edgeKeyArgs(args map[string]interface{}) (string, string, string, error) {
+	from, ok := args["from"].(string)
+	if !ok || from == "" {
+		return "", "", "", fmt.Errorf("from is required and must be a string")
+	}
+
+	to, ok := args["to"].(string)
+	if !ok || to == "" {
+		return "", "", "", fmt.Errorf("to is required and must be a string")
+	}
+
+	label, ok := args["label"].(string)
+	if !ok || label == "" {
+		return "", "", "", fmt.Errorf("label is required and must be a string")
+	}
+
+	return from, to, label, nil
+}
+
+// stringSliceArg extracts a list of strings from a tool argument, accepting
+// either a JSON array or a single string
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	var values []string
+	switch raw := args[key].(type) {
+	case []interface{}:
+		for _, v := range raw {
+			if strVal, ok := v.(string); ok && strVal != "" {
+				values = append(values, strVal)
+			}
+		}
+	case string:
+		if raw != "" {
+			values = append(values, raw)
+		}
+	}
+	return values
+}
+
+// writeResponse writes a response or batch of responses from
+// processMessage to the output stream; nil writes nothing
+func (h *Handler) writeResponse(response interface{}) error {
+	if response == nil {
+		return nil
+	}
+	return h.writeMessage(response)
+}
+
+// errNoStream is returned when a message is written with nowhere to send it
+var errNoStream = errors.New("no stream attached")
+
+// writeMessage writes one JSON-RPC message per line. Responses and
+// notifications may be written concurrently, so writes are serialized.
+func (h *Handler) writeMessage(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
 	data = append(data, '\n')
 
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	if h.writer == nil {
+		return errNoStream
+	}
 	if _, err := h.writer.Write(data); err != nil {
-		return fmt.Errorf("failed to write response: %w", err)
+		return fmt.Errorf("failed to write message: %w", err)
 	}
 
 	return nil
 }
 
+// setWriter replaces the stream messages are written to; nil detaches it
+func (h *Handler) setWriter(w io.Writer) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	h.writer = w
+}
+
 // debugLog logs debug messages to stderr if debug mode is enabled
 func (h *Handler) debugLog(format string, args ...interface{}) {
 	if h.debug {
@@ -694,8 +2646,12 @@ func (h *Handler) debugLog(format string, args ...interface{}) {
 
 // ProcessSingleRequest processes a single JSON-RPC request and returns the response as JSON
 // This is useful for testing and non-interactive usage
+// Requests that get no response, such as notifications, return ""
 func (h *Handler) ProcessSingleRequest(ctx context.Context, requestJSON string) (string, error) {
-	response := h.processRequest(ctx, requestJSON)
+	response := h.processMessage(ctx, requestJSON)
+	if response == nil {
+		return "", nil
+	}
 
 	data, err := json.Marshal(response)
 	if err != nil {
@@ -1,9 +1,14 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dshills/RelatixDB/internal/graph"
 )
@@ -95,7 +100,7 @@ func TestHandler_ToolsList(t *testing.T) {
 	}
 
 	// Check for expected tools
-	expectedTools := []string{"add_node", "add_edge", "delete_node", "delete_edge", "query_neighbors", "query_paths", "query_find"}
+	expectedTools := []string{"add_node", "add_edge", "update_node", "upsert_node", "update_edge", "upsert_edge", "delete_node", "delete_edge", "batch", "query_neighbors", "query_paths", "query_shortest_path", "query_weighted_shortest_path", "query_find", "query_find_edges", "query_subgraph", "query", "render_dot", "get_history", "schema_get", "schema_set"}
 	for _, tool := range expectedTools {
 		if !strings.Contains(response, tool) {
 			t.Fatalf("Expected tool '%s' in response, got %s", tool, response)
@@ -206,3 +211,443 @@ func TestHandler_ErrorHandling(t *testing.T) {
 		t.Fatalf("Expected error response for invalid arguments, got %s", response)
 	}
 }
+
+// callTool calls a tool on an initialized handler, returning the text of the
+// result and whether it is an error
+func callTool(t *testing.T, handler *Handler, name, arguments string) (string, bool) {
+	t.Helper()
+	request := fmt.Sprintf(`{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": %q, "arguments": %s}}`, name, arguments)
+	response, err := handler.ProcessSingleRequest(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var resp struct {
+		Result CallToolResponse `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(response), &resp); err != nil || len(resp.Result.Content) == 0 {
+		t.Fatalf("Unexpected response: %s", response)
+	}
+	return resp.Result.Content[0].Text, resp.Result.IsError
+}
+
+func TestHandler_TypedProps(t *testing.T) {
+	g := graph.NewMemoryGraph()
+	handler := NewHandler(g, nil, nil, false)
+	ctx := context.Background()
+
+	initReq := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`
+	if _, err := handler.ProcessSingleRequest(ctx, initReq); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	props := `{"name": "Alice", "age": 30, "score": 7.5, "admin": true, "tags": ["ops", "db"], "joined": {"timestamp": "2024-03-01T10:30:00+01:00"}}`
+	if text, isError := callTool(t, handler, "add_node", `{"id": "user:1", "type": "user", "props": `+props+`}`); isError {
+		t.Fatalf("Failed to add node: %s", text)
+	}
+	node, err := g.GetNode(ctx, "user:1")
+	if err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	want := map[string]graph.Value{
+		"name":   graph.StringValue("Alice"),
+		"age":    graph.IntValue(30),
+		"score":  graph.FloatValue(7.5),
+		"admin":  graph.BoolValue(true),
+		"tags":   graph.ListValue("ops", "db"),
+		"joined": graph.TimeValue(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)),
+	}
+	for key, value := range want {
+		if node.Props[key] != value {
+			t.Errorf("Expected %s to be the %s %s, got the %s %s", key, value.Kind(), value, node.Props[key].Kind(), node.Props[key])
+		}
+	}
+
+	tests := []struct {
+		name string
+		args string
+	}{
+		{"number", `{"props": {"age": 30}}`},
+		{"boolean", `{"props": {"admin": true}}`},
+		{"timestamp range", `{"where": {"field": "joined", "op": "lt", "value": {"timestamp": "2024-06-01T00:00:00Z"}}}`},
+		{"list item", `{"where": {"field": "tags", "op": "contains", "value": "db"}}`},
+	}
+	for _, tt := range tests {
+		if text, _ := callTool(t, handler, "query_find", tt.args); !strings.Contains(text, "Found 1 nodes") {
+			t.Errorf("%s: expected user:1 to be found, got %s", tt.name, text)
+		}
+	}
+
+	if text, isError := callTool(t, handler, "update_node", `{"id": "user:1", "props": {"age": null}}`); !isError || !strings.Contains(text, "cannot be null") {
+		t.Fatalf("Expected a null value to be rejected, got %s", text)
+	}
+}
+
+func TestHandler_Schema(t *testing.T) {
+	g := graph.NewMemoryGraph()
+	handler := NewHandler(g, nil, nil, false)
+	ctx := context.Background()
+
+	g.AddNode(ctx, graph.Node{ID: "legacy", Type: "class"})
+	initReq := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`
+	if _, err := handler.ProcessSingleRequest(ctx, initReq); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	callTool := func(name, arguments string) (string, bool) {
+		t.Helper()
+		return callTool(t, handler, name, arguments)
+	}
+
+	if text, _ := callTool("schema_get", `{}`); !strings.Contains(text, "No schema is set") {
+		t.Fatalf("Expected no schema, got %s", text)
+	}
+
+	schema := `{"node_types": {"user": {"properties": {"name": {"required": true}}}}, "edge_labels": {"follows": {"connections": [{"from": "user", "to": "user"}]}}}`
+	text, isError := callTool("schema_set", `{"schema": `+schema+`}`)
+	if isError || !strings.Contains(text, "Schema set (strict mode): 1 node types, 1 edge labels") || !strings.Contains(text, `- node "legacy": type "class" is not declared`) {
+		t.Fatalf("Expected the schema to be set and the legacy node reported, got %s", text)
+	}
+	if text, _ := callTool("schema_get", `{}`); !strings.Contains(text, `"follows"`) {
+		t.Fatalf("Expected the schema, got %s", text)
+	}
+
+	// Strict mode rejects violating writes
+	if text, isError := callTool("add_node", `{"id": "user:1", "type": "user"}`); !isError || !strings.Contains(text, "required property name is missing") {
+		t.Fatalf("Expected a missing name to be rejected, got %s", text)
+	}
+	callTool("add_node", `{"id": "user:1", "type": "user", "props": {"name": "Alice"}}`)
+	if text, isError := callTool("add_edge", `{"from": "user:1", "to": "legacy", "label": "follows"}`); !isError || !strings.Contains(text, "may not connect") {
+		t.Fatalf("Expected the connection to be rejected, got %s", text)
+	}
+
+	// Every write path is checked, not just add_node and add_edge
+	rejected := []struct{ tool, arguments string }{
+		{"upsert_node", `{"id": "user:2", "type": "user"}`},
+		{"update_node", `{"id": "user:1", "remove": ["name"]}`},
+		{"upsert_edge", `{"from": "user:1", "to": "legacy", "label": "follows"}`},
+		{"batch", `{"operations": [{"op": "add_node", "id": "user:3", "type": "user", "props": {"name": "Carol"}}, {"op": "add_node", "id": "user:4", "type": "user"}]}`},
+	}
+	for _, tt := range rejected {
+		if text, isError := callTool(tt.tool, tt.arguments); !isError || !strings.Contains(text, "schema violation") {
+			t.Fatalf("Expected %s %s to be rejected, got %s", tt.tool, tt.arguments, text)
+		}
+	}
+	for _, id := range []string{"user:2", "user:3", "user:4"} {
+		if g.NodeExists(ctx, id) {
+			t.Fatalf("Expected rejected node %s not to be added", id)
+		}
+	}
+	if node, _ := g.GetNode(ctx, "user:1"); node.Props["name"].String() != "Alice" {
+		t.Fatalf("Expected the rejected update to leave user:1 unchanged, got %+v", node)
+	}
+
+	// A batch is checked as a whole, so an element only needs to be valid at the end
+	if text, isError := callTool("batch", `{"operations": [{"op": "add_node", "id": "user:3", "type": "user"}, {"op": "update_node", "id": "user:3", "props": {"name": "Carol"}}, {"op": "add_edge", "from": "user:3", "to": "user:1", "label": "follows"}]}`); isError {
+		t.Fatalf("Expected the batch to be applied, got %s", text)
+	}
+
+	// Warn mode makes the write and reports the violations
+	callTool("schema_set", `{"schema": {"mode": "warn", "open": true, "node_types": {"user": {}}}}`)
+	text, isError = callTool("add_node", `{"id": "user:2", "type": "user", "props": {"name": "Bob"}}`)
+	if isError || !strings.Contains(text, "Schema warnings:\n- node \"user:2\": property name is not declared") {
+		t.Fatalf("Expected the node to be added with a warning, got %s", text)
+	}
+	text, isError = callTool("upsert_node", `{"id": "user:2", "props": {"age": 30}}`)
+	if isError || !strings.Contains(text, "Schema warnings:") || !strings.Contains(text, "property age is not declared") {
+		t.Fatalf("Expected the upsert to be made with a warning, got %s", text)
+	}
+
+	if text, isError := callTool("schema_set", `{"schema": {"mode": "lenient"}}`); !isError || !strings.Contains(text, "invalid schema") {
+		t.Fatalf("Expected an invalid schema to be rejected, got %s", text)
+	}
+	if text, _ := callTool("schema_set", `{"remove": true}`); text != "Schema removed" || g.Schema() != nil {
+		t.Fatalf("Expected the schema to be removed, got %s", text)
+	}
+}
+
+func TestHandler_BatchesAndNotifications(t *testing.T) {
+	handler := NewHandler(graph.NewMemoryGraph(), nil, nil, false)
+	ctx := context.Background()
+
+	initReq := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`
+	if _, err := handler.ProcessSingleRequest(ctx, initReq); err != nil {
+		t.Fatalf("Expected no error for initialization, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		request string
+		codes   []int // error code of each expected response, 0 for success
+		batch   bool
+	}{
+		{"initialized notification", `{"jsonrpc": "2.0", "method": "notifications/initialized"}`, nil, false},
+		{"request as notification", `{"jsonrpc": "2.0", "method": "tools/call", "params": {"name": "add_node", "arguments": {"id": "quiet"}}}`, nil, false},
+		{"null id", `{"jsonrpc": "2.0", "id": null, "method": "tools/list"}`, []int{0}, false},
+		{"invalid JSON", `{"jsonrpc": "2.0", "method":}`, []int{ParseError}, false},
+		{"not an object", `42`, []int{InvalidRequest}, false},
+		{"invalid version", `{"jsonrpc": "1.0", "id": 2, "method": "tools/list"}`, []int{InvalidRequest}, false},
+		{"invalid id", `{"jsonrpc": "2.0", "id": {"a": 1}, "method": "tools/list"}`, []int{InvalidRequest}, false},
+		{"invalid params", `{"jsonrpc": "2.0", "id": 3, "method": "tools/list", "params": "x"}`, []int{InvalidRequest}, false},
+		{"empty batch", `[]`, []int{InvalidRequest}, false},
+		{"invalid batch JSON", `[{"jsonrpc": "2.0", "id": 4, "method": "tools/list"},`, []int{ParseError}, false},
+		{"batch of invalid requests", `[1, 2]`, []int{InvalidRequest, InvalidRequest}, true},
+		{"batch", `[
+			{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "add_node", "arguments": {"id": "a"}}},
+			{"jsonrpc": "2.0", "method": "notifications/initialized"},
+			{"jsonrpc": "2.0", "id": 6, "method": "unknown/method"},
+			{"jsonrpc": "2.0", "id": 7, "method": "initialize"}
+		]`, []int{0, MethodNotFound, InvalidRequest}, true},
+		{"batch of notifications", `[{"jsonrpc": "2.0", "method": "notifications/initialized"}, {"jsonrpc": "2.0", "method": "notifications/unknown"}]`, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := handler.ProcessSingleRequest(ctx, tt.request)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.codes == nil {
+				if response != "" {
+					t.Fatalf("Expected no response, got %s", response)
+				}
+				return
+			}
+
+			var responses []JSONRPCResponse
+			if tt.batch {
+				err = json.Unmarshal([]byte(response), &responses)
+			} else {
+				responses = make([]JSONRPCResponse, 1)
+				err = json.Unmarshal([]byte(response), &responses[0])
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse response %s: %v", response, err)
+			}
+
+			if len(responses) != len(tt.codes) {
+				t.Fatalf("Expected %d responses, got %s", len(tt.codes), response)
+			}
+			for i, code := range tt.codes {
+				got := 0
+				if responses[i].Error != nil {
+					got = responses[i].Error.Code
+				}
+				if got != code {
+					t.Fatalf("Expected error code %d for response %d, got %s", code, i, response)
+				}
+			}
+		})
+	}
+
+	// Requests sent as notifications still take effect
+	if _, err := handler.graph.GetNode(ctx, "quiet"); err != nil {
+		t.Fatalf("Expected the notification to add its node: %v", err)
+	}
+	if _, err := handler.graph.GetNode(ctx, "a"); err != nil {
+		t.Fatalf("Expected the batch to add its node: %v", err)
+	}
+}
+
+// blockingGraph is a graph whose queries run until their context is canceled
+type blockingGraph struct {
+	*graph.MemoryGraph
+	started chan struct{}
+	stopped chan error
+}
+
+func (g *blockingGraph) Query(ctx context.Context, query graph.Query) (*graph.QueryResult, error) {
+	g.started <- struct{}{}
+	<-ctx.Done()
+	g.stopped <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestHandler_ErrorResponseIDs(t *testing.T) {
+	handler := NewHandler(graph.NewMemoryGraph(), nil, nil, false)
+	ctx := context.Background()
+
+	// Errors for requests whose id can't be read carry "id": null, as JSON-RPC requires
+	notObject := `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"request must be a JSON object"}}`
+	tests := []struct {
+		name     string
+		request  string
+		response string
+	}{
+		{"parse error", `{bad`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error","data":"failed to parse JSON-RPC request: invalid character 'b' looking for beginning of object key string"}}`},
+		{"empty batch", `[]`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"batch is empty"}}`},
+		{"batch of non-objects", `[1, 2]`, "[" + notObject + "," + notObject + "]"},
+	}
+
+	for _, tt := range tests {
+		response, err := handler.ProcessSingleRequest(ctx, tt.request)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", tt.name, err)
+		}
+		if response != tt.response {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.response, response)
+		}
+	}
+}
+
+func TestHandler_RunStopsWhileWaitingForInput(t *testing.T) {
+	input, send := io.Pipe()
+	defer send.Close()
+	handler := NewHandler(graph.NewMemoryGraph(), input, io.Discard, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Run(ctx)
+	}()
+
+	// No input arrives, so Run is blocked reading when the context is canceled
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("Expected Run to return context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected Run to stop without waiting for input")
+	}
+}
+
+func TestHandler_MessageTooLarge(t *testing.T) {
+	var output strings.Builder
+	input := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "tools/list", "params": {"padding": "` + strings.Repeat("x", 8192) + `"}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`,
+	}, "\n") + "\n"
+	handler := NewHandler(graph.NewMemoryGraph(), strings.NewReader(input), &output, false)
+	handler.maxMessageSize = 4096
+
+	if err := handler.Run(context.Background()); err != nil {
+		t.Fatalf("Expected Run to keep serving after an oversized line, got %v", err)
+	}
+
+	var responses []JSONRPCResponse
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var resp JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("Failed to parse response %q: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("Expected 2 responses, got %d: %s", len(responses), output.String())
+	}
+	if responses[0].ID != nil || responses[0].Error == nil || responses[0].Error.Code != InvalidRequest {
+		t.Errorf("Expected an InvalidRequest error with a null id, got %+v", responses[0])
+	}
+	if responses[1].Error != nil || fmt.Sprint(responses[1].ID) != "2" {
+		t.Errorf("Expected the next message to be served, got %+v", responses[1])
+	}
+}
+
+func TestHandler_CancelWithAllWorkersBusy(t *testing.T) {
+	g := &blockingGraph{MemoryGraph: graph.NewMemoryGraph(), started: make(chan struct{}, 1), stopped: make(chan error, 1)}
+	g.AddNode(context.Background(), graph.Node{ID: "a"})
+	g.AddNode(context.Background(), graph.Node{ID: "b"})
+
+	input, send := io.Pipe()
+	handler := NewHandler(g, input, io.Discard, false)
+	handler.Workers = 1
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Run(context.Background())
+	}()
+
+	// The query takes the only worker, and the cancellation must not wait for it
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`)
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "id": "slow", "method": "tools/call", "params": {"name": "query_paths", "arguments": {"from": "a", "to": "b"}}}`)
+	select {
+	case <-g.started:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the query to start")
+	}
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": "slow"}}`)
+	select {
+	case err := <-g.stopped:
+		if err != context.Canceled {
+			t.Fatalf("Expected the query context to be canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the query to be cancelled while it held the only worker")
+	}
+
+	send.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
+func TestHandler_ConcurrentRequests(t *testing.T) {
+	g := &blockingGraph{MemoryGraph: graph.NewMemoryGraph(), started: make(chan struct{}, 1), stopped: make(chan error, 1)}
+	g.AddNode(context.Background(), graph.Node{ID: "a"})
+	g.AddNode(context.Background(), graph.Node{ID: "b"})
+
+	input, send := io.Pipe()
+	output, results := io.Pipe()
+	handler := NewHandler(g, input, results, false)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Run(context.Background())
+		results.Close()
+	}()
+
+	responses := make(chan JSONRPCResponse)
+	go func() {
+		scanner := bufio.NewScanner(output)
+		for scanner.Scan() {
+			var resp JSONRPCResponse
+			json.Unmarshal(scanner.Bytes(), &resp)
+			responses <- resp
+		}
+		close(responses)
+	}()
+
+	nextResponse := func() JSONRPCResponse {
+		t.Helper()
+		select {
+		case resp := <-responses:
+			return resp
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for a response")
+		}
+		return JSONRPCResponse{}
+	}
+
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`)
+	if resp := nextResponse(); resp.ID != float64(1) {
+		t.Fatalf("Expected the initialize response, got %+v", resp)
+	}
+
+	// A slow query does not hold up the requests behind it
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "id": "slow", "method": "tools/call", "params": {"name": "query_paths", "arguments": {"from": "a", "to": "b"}}}`)
+	<-g.started
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "id": 3, "method": "tools/list"}`)
+	if resp := nextResponse(); resp.ID != float64(3) {
+		t.Fatalf("Expected tools/list to answer while the query runs, got %+v", resp)
+	}
+
+	// Cancelling the query stops it through its context and suppresses its response
+	fmt.Fprintln(send, `{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": {"requestId": "slow", "reason": "user gave up"}}`)
+	select {
+	case err := <-g.stopped:
+		if err != context.Canceled {
+			t.Fatalf("Expected the query context to be canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the query to be cancelled")
+	}
+
+	send.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if resp, ok := <-responses; ok {
+		t.Fatalf("Expected no response for the cancelled request, got %+v", resp)
+	}
+}
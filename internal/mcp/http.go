@@ -0,0 +1,443 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// HTTPEndpoint is the path the streamable HTTP transport is served on
+const HTTPEndpoint = "/mcp"
+
+// HeaderSessionID carries the session assigned by initialize
+const HeaderSessionID = "Mcp-Session-Id"
+
+// maxHTTPRequestSize bounds the body of a single POSTed message
+const maxHTTPRequestSize = 32 << 20
+
+// DefaultSessionIdleTimeout is how long a session may go unused before it is
+// ended by default
+const DefaultSessionIdleTimeout = 30 * time.Minute
+
+// HTTPServer serves the MCP streamable HTTP transport. Each client gets a
+// session with its own Handler over the shared graph: messages are POSTed to
+// HTTPEndpoint and answered with JSON, and server-initiated messages such as
+// resource notifications are sent over an SSE stream opened with GET.
+type HTTPServer struct {
+	graph graph.Graph
+	debug bool
+
+	// IdleTimeout ends sessions that have had no request in flight and no
+	// stream attached for this long; 0 keeps sessions until they are deleted
+	IdleTimeout time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+// httpSession is one client's connection state
+type httpSession struct {
+	handler *Handler
+	closed  chan struct{} // closed when the session is terminated
+
+	// Guarded by HTTPServer.mu
+	stream   bool      // an SSE stream is attached
+	requests int       // POSTs in progress
+	lastUsed time.Time // when the last request or stream ended
+}
+
+// NewHTTPServer creates an HTTP transport serving g
+func NewHTTPServer(g graph.Graph, debug bool) *HTTPServer {
+	return &HTTPServer{
+		graph:       g,
+		debug:       debug,
+		IdleTimeout: DefaultSessionIdleTimeout,
+		sessions:    make(map[string]*httpSession),
+	}
+}
+
+// ListenAndServe serves on addr until ctx is canceled, then shuts down
+// gracefully and ends every session
+func (s *HTTPServer) ListenAndServe(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	// Request contexts derive from ctx so open SSE streams end on shutdown
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Serve(listener)
+	}()
+
+	if s.IdleTimeout > 0 {
+		stopExpiring := make(chan struct{})
+		defer close(stopExpiring)
+		go s.expireLoop(stopExpiring)
+	}
+
+	select {
+	case err := <-errChan:
+		s.Close()
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.Close()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down HTTP server: %w", err)
+	}
+	return nil
+}
+
+// Close ends every session
+func (s *HTTPServer) Close() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = make(map[string]*httpSession)
+	s.mu.Unlock()
+
+	for _, sess := range sessions {
+		sess.end()
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != HTTPEndpoint {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Reject cross-origin browser requests so other websites can't reach a local server
+	if !localOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "Forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		s.handlePost(w, r)
+	case http.MethodGet:
+		s.handleStream(w, r)
+	case http.MethodDelete:
+		s.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost processes one JSON-RPC message or batch. initialize starts a
+// new session; every other message must name an existing one.
+func (s *HTTPServer) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPRequestSize))
+	if err != nil {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, InvalidRequest, "Request too large", err.Error())
+		return
+	}
+
+	if !json.Valid(body) {
+		writeHTTPError(w, http.StatusBadRequest, ParseError, "Parse error", "request body is not valid JSON")
+		return
+	}
+
+	var (
+		sessionID = r.Header.Get(HeaderSessionID)
+		sess      *httpSession
+		created   bool
+	)
+	switch {
+	case requestMethod(string(body)) == MethodInitialize && sessionID == "":
+		sessionID, sess, err = s.newSession()
+		if err != nil {
+			writeHTTPError(w, http.StatusInternalServerError, InternalError, "Failed to create session", err.Error())
+			return
+		}
+		created = true
+	case sessionID == "":
+		writeHTTPError(w, http.StatusBadRequest, InvalidRequest, "Missing "+HeaderSessionID+" header", nil)
+		return
+	default:
+		if sess = s.session(sessionID); sess == nil {
+			writeHTTPError(w, http.StatusNotFound, InvalidRequest, "Unknown session", sessionID)
+			return
+		}
+	}
+
+	// Requests of a session run concurrently, each in its own HTTP request
+	response := sess.handler.processMessage(r.Context(), string(body))
+	s.release(sess)
+
+	// A failed initialize leaves no session behind
+	if created {
+		if resp, ok := response.(*JSONRPCResponse); !ok || resp.Error != nil {
+			s.endSession(sessionID)
+			sessionID = ""
+		}
+	}
+
+	// Notifications and cancelled requests get no response
+	if response == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, InternalError, "Failed to marshal response", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if sessionID != "" {
+		w.Header().Set(HeaderSessionID, sessionID)
+	}
+	w.Write(data)
+}
+
+// handleStream attaches an SSE stream to a session and sends it the
+// session's notifications until the client disconnects or the session ends.
+// Updates recorded while no stream is attached are sent when one attaches.
+func (s *HTTPServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "Expected Accept: text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sessionID := r.Header.Get(HeaderSessionID)
+
+	s.mu.Lock()
+	sess := s.sessions[sessionID]
+	if sess == nil {
+		s.mu.Unlock()
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+	if sess.stream {
+		s.mu.Unlock()
+		http.Error(w, "Session already has a stream", http.StatusConflict)
+		return
+	}
+	sess.stream = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		sess.stream = false
+		sess.lastUsed = time.Now()
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set(HeaderSessionID, sessionID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	h := sess.handler
+	h.setWriter(&sseWriter{w: w, flusher: flusher})
+	defer h.setWriter(nil)
+
+	if err := h.flushNotifications(); err != nil {
+		h.debugLog("Failed to write notification: %v", err)
+		return
+	}
+
+	stop := make(chan struct{})
+	var notifier sync.WaitGroup
+	notifier.Add(1)
+	go h.notifyLoop(stop, &notifier)
+
+	select {
+	case <-r.Context().Done():
+	case <-sess.closed:
+	}
+
+	close(stop)
+	notifier.Wait()
+}
+
+// handleDelete terminates a session
+func (s *HTTPServer) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(HeaderSessionID)
+	if !s.endSession(sessionID) {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// endSession removes and ends a session, reporting whether it existed
+func (s *HTTPServer) endSession(sessionID string) bool {
+	s.mu.Lock()
+	sess := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+
+	if sess == nil {
+		return false
+	}
+
+	sess.end()
+	sess.handler.debugLog("Session %s terminated", sessionID)
+	return true
+}
+
+// expireLoop ends idle sessions until stop is closed
+func (s *HTTPServer) expireLoop(stop <-chan struct{}) {
+	interval := s.IdleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.expireIdle(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// expireIdle ends the sessions that have been idle for IdleTimeout as of now
+func (s *HTTPServer) expireIdle(now time.Time) {
+	expired := make(map[string]*httpSession)
+	s.mu.Lock()
+	for id, sess := range s.sessions {
+		if !sess.stream && sess.requests == 0 && now.Sub(sess.lastUsed) >= s.IdleTimeout {
+			expired[id] = sess
+			delete(s.sessions, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for id, sess := range expired {
+		sess.end()
+		sess.handler.debugLog("Session %s expired after %v idle", id, s.IdleTimeout)
+	}
+}
+
+// newSession creates and registers a session with a fresh handler
+func (s *HTTPServer) newSession() (string, *httpSession, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", nil, err
+	}
+	sessionID := hex.EncodeToString(id[:])
+
+	sess := &httpSession{
+		handler:  NewHandler(s.graph, nil, nil, s.debug),
+		closed:   make(chan struct{}),
+		requests: 1, // the initialize request, released by handlePost
+	}
+
+	s.mu.Lock()
+	s.sessions[sessionID] = sess
+	s.mu.Unlock()
+
+	sess.handler.debugLog("Session %s started", sessionID)
+	return sessionID, sess, nil
+}
+
+// session returns the session with the given ID, or nil, counting a request
+// in progress until release is called
+func (s *HTTPServer) session(id string) *httpSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := s.sessions[id]
+	if sess != nil {
+		sess.requests++
+	}
+	return sess
+}
+
+// release marks the end of a request to a session
+func (s *HTTPServer) release(sess *httpSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess.requests--
+	sess.lastUsed = time.Now()
+}
+
+// end stops the session's graph watcher and closes its stream
+func (sess *httpSession) end() {
+	sess.handler.stopWatching()
+	close(sess.closed)
+}
+
+// sseWriter turns each line-delimited JSON-RPC message written to it into an
+// SSE event and flushes it to the client
+type sseWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (sw *sseWriter) Write(p []byte) (int, error) {
+	data := strings.TrimSuffix(string(p), "\n")
+	if _, err := fmt.Fprintf(sw.w, "event: message\ndata: %s\n\n", data); err != nil {
+		return 0, err
+	}
+	sw.flusher.Flush()
+	return len(p), nil
+}
+
+// writeHTTPError answers a POST with a JSON-RPC error and an HTTP status
+func writeHTTPError(w http.ResponseWriter, status, code int, message string, data interface{}) {
+	body, _ := NewJSONRPCErrorResponse(nil, code, message, data).ToJSON()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// localOrigin reports whether a request's Origin header, if any, is a
+// loopback address
+func localOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
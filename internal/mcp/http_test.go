@@ -0,0 +1,245 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// postMCP posts a JSON-RPC message to the server, with the session ID if given
+func postMCP(t *testing.T, serverURL, sessionID, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, serverURL+HTTPEndpoint, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(HeaderSessionID, sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to post: %v", err)
+	}
+	return resp
+}
+
+// initializeSession starts a session and returns its ID
+func initializeSession(t *testing.T, serverURL, client string) string {
+	t.Helper()
+
+	resp := postMCP(t, serverURL, "", `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "`+client+`", "version": "1.0.0"}}}`)
+	defer resp.Body.Close()
+
+	sessionID := resp.Header.Get(HeaderSessionID)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected a session from initialize, got %d %q", resp.StatusCode, sessionID)
+	}
+	return sessionID
+}
+
+func TestHTTPServer_Sessions(t *testing.T) {
+	server := NewHTTPServer(graph.NewMemoryGraph(), false)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	defer server.Close()
+
+	sessionID := initializeSession(t, ts.URL, "agent-1")
+
+	resp := postMCP(t, ts.URL, sessionID, `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "add_node", "arguments": {"id": "a"}}}`)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Successfully added node 'a'") {
+		t.Fatalf("Expected the tool call to succeed, got %d %s", resp.StatusCode, body)
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		sessionID string
+		body      string
+		origin    string
+		status    int
+	}{
+		{"notification", http.MethodPost, sessionID, `{"jsonrpc": "2.0", "method": "notifications/initialized"}`, "", http.StatusAccepted},
+		{"missing session", http.MethodPost, "", `{"jsonrpc": "2.0", "id": 3, "method": "tools/list"}`, "", http.StatusBadRequest},
+		{"unknown session", http.MethodPost, "nope", `{"jsonrpc": "2.0", "id": 4, "method": "tools/list"}`, "", http.StatusNotFound},
+		{"invalid JSON", http.MethodPost, sessionID, `{"jsonrpc":`, "", http.StatusBadRequest},
+		{"batch", http.MethodPost, sessionID, `[{"jsonrpc": "2.0", "id": 8, "method": "tools/list"}, {"jsonrpc": "2.0", "method": "notifications/initialized"}]`, "", http.StatusOK},
+		{"batch of notifications", http.MethodPost, sessionID, `[{"jsonrpc": "2.0", "method": "notifications/initialized"}]`, "", http.StatusAccepted},
+		{"local origin", http.MethodPost, sessionID, `{"jsonrpc": "2.0", "id": 5, "method": "tools/list"}`, "http://localhost:3000", http.StatusOK},
+		{"foreign origin", http.MethodPost, sessionID, `{"jsonrpc": "2.0", "id": 6, "method": "tools/list"}`, "https://example.com", http.StatusForbidden},
+		{"unsupported method", http.MethodPut, sessionID, "", "", http.StatusMethodNotAllowed},
+		{"stream without event-stream", http.MethodGet, sessionID, "", "", http.StatusNotAcceptable},
+		{"terminate", http.MethodDelete, sessionID, "", "", http.StatusNoContent},
+		{"terminated session", http.MethodPost, sessionID, `{"jsonrpc": "2.0", "id": 7, "method": "tools/list"}`, "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+HTTPEndpoint, strings.NewReader(tt.body))
+			if tt.sessionID != "" {
+				req.Header.Set(HeaderSessionID, tt.sessionID)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHTTPServer_SessionLifetime(t *testing.T) {
+	server := NewHTTPServer(graph.NewMemoryGraph(), false)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	defer server.Close()
+
+	// A failed initialize gets no session
+	resp := postMCP(t, ts.URL, "", `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": []}`)
+	resp.Body.Close()
+	if resp.Header.Get(HeaderSessionID) != "" {
+		t.Errorf("Expected no session ID after a failed initialize")
+	}
+	if n := len(server.sessions); n != 0 {
+		t.Fatalf("Expected a failed initialize to leave no session, got %d", n)
+	}
+
+	idle := initializeSession(t, ts.URL, "idle")
+	busy := initializeSession(t, ts.URL, "busy")
+
+	// A session with a request in flight is kept however long it runs
+	if server.session(busy) == nil {
+		t.Fatalf("Expected session %s", busy)
+	}
+	server.expireIdle(time.Now().Add(server.IdleTimeout - time.Minute))
+	if len(server.sessions) != 2 {
+		t.Fatalf("Expected no session to expire before the idle timeout")
+	}
+
+	server.expireIdle(time.Now().Add(server.IdleTimeout))
+	if server.sessions[idle] != nil || server.sessions[busy] == nil {
+		t.Fatalf("Expected only the idle session to expire")
+	}
+
+	resp = postMCP(t, ts.URL, idle, `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an expired session to be unknown, got %d", resp.StatusCode)
+	}
+}
+
+func TestHTTPServer_StreamNotifications(t *testing.T) {
+	g := graph.NewMemoryGraph()
+	server := NewHTTPServer(g, false)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := initializeSession(t, ts.URL, "watcher")
+	writer := initializeSession(t, ts.URL, "writer")
+
+	resp := postMCP(t, ts.URL, watcher, `{"jsonrpc": "2.0", "id": 2, "method": "resources/subscribe", "params": {"uri": "relatix://node/shared"}}`)
+	resp.Body.Close()
+
+	// An update recorded before the stream opens is delivered once it does
+	g.AddNode(ctx, graph.Node{ID: "shared"})
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+HTTPEndpoint, nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(HeaderSessionID, watcher)
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer stream.Body.Close()
+	if stream.StatusCode != http.StatusOK || stream.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", stream.StatusCode, stream.Header.Get("Content-Type"))
+	}
+
+	events := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(stream.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				events <- data
+			}
+		}
+		close(events)
+	}()
+
+	nextEvent := func() JSONRPCRequest {
+		t.Helper()
+		select {
+		case data, ok := <-events:
+			if !ok {
+				t.Fatalf("Stream closed before an event arrived")
+			}
+			var message JSONRPCRequest
+			if err := json.Unmarshal([]byte(data), &message); err != nil {
+				t.Fatalf("Failed to parse event %s: %v", data, err)
+			}
+			return message
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for a notification")
+		}
+		return JSONRPCRequest{}
+	}
+
+	if message := nextEvent(); message.Method != NotificationResourcesUpdated {
+		t.Fatalf("Expected the pending update, got %+v", message)
+	}
+
+	// A change made by another session reaches the subscriber
+	resp = postMCP(t, ts.URL, writer, `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "update_node", "arguments": {"id": "shared", "props": {"status": "done"}}}}`)
+	resp.Body.Close()
+
+	message := nextEvent()
+	params, _ := message.Params.(map[string]interface{})
+	if message.Method != NotificationResourcesUpdated || params["uri"] != "relatix://node/shared" {
+		t.Fatalf("Expected an update of relatix://node/shared, got %+v", message)
+	}
+
+	// Only one stream per session
+	second, err := http.DefaultClient.Do(req.Clone(ctx))
+	if err != nil {
+		t.Fatalf("Failed to open second stream: %v", err)
+	}
+	second.Body.Close()
+	if second.StatusCode != http.StatusConflict {
+		t.Fatalf("Expected a second stream to be refused, got %d", second.StatusCode)
+	}
+
+	// Ending the session closes its stream
+	server.Close()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatalf("Expected no more events after the session ended")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected the stream to close with the session")
+	}
+}
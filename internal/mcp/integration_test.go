@@ -343,6 +343,44 @@ func testComplexScenarios(ctx context.Context, t *testing.T, handler *Handler) {
 	if !strings.Contains(responseStr, "company:acme") || !strings.Contains(responseStr, "user:charlie") {
 		t.Fatalf("Expected path from company to user in response: %s", responseStr)
 	}
+
+	// Verify the traversed edge labels are reported
+	if !strings.Contains(responseStr, "-[has_department]-") || !strings.Contains(responseStr, "-[employs]-") {
+		t.Fatalf("Expected edge labels in path response: %s", responseStr)
+	}
+
+	// Restricting labels excludes the path entirely
+	labeledPathsReq := `{"jsonrpc": "2.0", "id": 26, "method": "tools/call", "params": {"name": "query_paths", "arguments": {"from": "company:acme", "to": "user:charlie", "labels": ["colleagues"]}}}`
+	response, err = handler.ProcessSingleRequest(ctx, labeledPathsReq)
+	if err != nil {
+		t.Fatalf("Failed to process labeled paths query: %v", err)
+	}
+
+	if !strings.Contains(response, "Found 0 paths") {
+		t.Fatalf("Expected no paths restricted to colleagues edges: %s", response)
+	}
+
+	// Shortest path between the two users ignoring direction
+	shortestReq := `{"jsonrpc": "2.0", "id": 27, "method": "tools/call", "params": {"name": "query_shortest_path", "arguments": {"from": "user:diana", "to": "user:charlie", "direction": "both"}}}`
+	response, err = handler.ProcessSingleRequest(ctx, shortestReq)
+	if err != nil {
+		t.Fatalf("Failed to process shortest path query: %v", err)
+	}
+
+	if !strings.Contains(response, "(1 hops)") || !strings.Contains(response, "[colleagues]") {
+		t.Fatalf("Expected one-hop colleagues path: %s", response)
+	}
+
+	// Weighted shortest path requires a weight property
+	weightedReq := `{"jsonrpc": "2.0", "id": 28, "method": "tools/call", "params": {"name": "query_weighted_shortest_path", "arguments": {"from": "company:acme", "to": "user:diana"}}}`
+	response, err = handler.ProcessSingleRequest(ctx, weightedReq)
+	if err != nil {
+		t.Fatalf("Failed to process weighted shortest path query: %v", err)
+	}
+
+	if !strings.Contains(response, `"isError":true`) {
+		t.Fatalf("Expected error without weight_prop: %s", response)
+	}
 }
 
 // TestMCPCommandValidation tests command validation and error handling
@@ -387,6 +425,138 @@ func TestMCPCommandValidation(t *testing.T) {
 			expectError: true,
 			errorType:   "tool_error",
 		},
+		{
+			name:        "query_find props without type",
+			request:     `{"jsonrpc": "2.0", "id": 7, "method": "tools/call", "params": {"name": "query_find", "arguments": {"props": {"name": "missing"}}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query_find where expression",
+			request:     `{"jsonrpc": "2.0", "id": 8, "method": "tools/call", "params": {"name": "query_find", "arguments": {"where": {"or": [{"field": "id", "op": "prefix", "value": "test:"}, {"field": "size", "op": "gt", "value": 10}]}}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query_find invalid where operator",
+			request:     `{"jsonrpc": "2.0", "id": 9, "method": "tools/call", "params": {"name": "query_find", "arguments": {"where": {"field": "id", "op": "like", "value": "test"}}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query_find_edges by label",
+			request:     `{"jsonrpc": "2.0", "id": 10, "method": "tools/call", "params": {"name": "query_find_edges", "arguments": {"label": "calls", "from_type": "function"}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query_find_edges no criteria",
+			request:     `{"jsonrpc": "2.0", "id": 11, "method": "tools/call", "params": {"name": "query_find_edges", "arguments": {}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "batch applies all operations",
+			request:     `{"jsonrpc": "2.0", "id": 12, "method": "tools/call", "params": {"name": "batch", "arguments": {"operations": [{"op": "add_node", "id": "batch:a"}, {"op": "add_node", "id": "batch:b"}, {"op": "add_edge", "from": "batch:a", "to": "batch:b", "label": "links"}]}}}`,
+			expectError: false,
+		},
+		{
+			name:        "batch rolls back on failure",
+			request:     `{"jsonrpc": "2.0", "id": 13, "method": "tools/call", "params": {"name": "batch", "arguments": {"operations": [{"op": "add_node", "id": "batch:c"}, {"op": "delete_node", "id": "batch:missing"}]}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "batch rolled back node is absent",
+			request:     `{"jsonrpc": "2.0", "id": 14, "method": "tools/call", "params": {"name": "batch", "arguments": {"operations": [{"op": "delete_node", "id": "batch:c"}]}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "upsert_node creates",
+			request:     `{"jsonrpc": "2.0", "id": 15, "method": "tools/call", "params": {"name": "upsert_node", "arguments": {"id": "test:upsert", "type": "test", "props": {"a": "1"}}}}`,
+			expectError: false,
+		},
+		{
+			name:        "update_node merges and removes",
+			request:     `{"jsonrpc": "2.0", "id": 16, "method": "tools/call", "params": {"name": "update_node", "arguments": {"id": "test:upsert", "props": {"b": "2"}, "remove": ["a"]}}}`,
+			expectError: false,
+		},
+		{
+			name:        "update_node missing node",
+			request:     `{"jsonrpc": "2.0", "id": 17, "method": "tools/call", "params": {"name": "update_node", "arguments": {"id": "test:missing", "props": {"b": "2"}}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "upsert_edge creates",
+			request:     `{"jsonrpc": "2.0", "id": 18, "method": "tools/call", "params": {"name": "upsert_edge", "arguments": {"from": "test:upsert", "to": "test:valid", "label": "links"}}}`,
+			expectError: false,
+		},
+		{
+			name:        "update_edge invalid mode",
+			request:     `{"jsonrpc": "2.0", "id": 19, "method": "tools/call", "params": {"name": "update_edge", "arguments": {"from": "test:upsert", "to": "test:valid", "label": "links", "props": {"w": "1"}, "mode": "patch"}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "render_dot neighborhood",
+			request:     `{"jsonrpc": "2.0", "id": 20, "method": "tools/call", "params": {"name": "render_dot", "arguments": {"node": "test:upsert", "hops": 2}}}`,
+			expectError: false,
+		},
+		{
+			name:        "render_dot node and path",
+			request:     `{"jsonrpc": "2.0", "id": 21, "method": "tools/call", "params": {"name": "render_dot", "arguments": {"node": "test:upsert", "from": "test:upsert", "to": "test:valid"}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "get_history without history",
+			request:     `{"jsonrpc": "2.0", "id": 22, "method": "tools/call", "params": {"name": "get_history", "arguments": {"id": "test:upsert"}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query_neighbors invalid as_of",
+			request:     `{"jsonrpc": "2.0", "id": 23, "method": "tools/call", "params": {"name": "query_neighbors", "arguments": {"node": "test:upsert", "as_of": "yesterday"}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query_subgraph",
+			request:     `{"jsonrpc": "2.0", "id": 24, "method": "tools/call", "params": {"name": "query_subgraph", "arguments": {"seeds": ["test:upsert", "test:valid"], "hops": 2, "labels": ["links"], "limit": 10}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query_subgraph missing seeds",
+			request:     `{"jsonrpc": "2.0", "id": 25, "method": "tools/call", "params": {"name": "query_subgraph", "arguments": {"hops": 2}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query_subgraph unknown seed",
+			request:     `{"jsonrpc": "2.0", "id": 26, "method": "tools/call", "params": {"name": "query_subgraph", "arguments": {"seeds": ["test:missing"]}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query pattern",
+			request:     `{"jsonrpc": "2.0", "id": 27, "method": "tools/call", "params": {"name": "query", "arguments": {"pattern": "(a {id: \"test:upsert\"})-[r:links]->(b)", "limit": 5}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query invalid pattern",
+			request:     `{"jsonrpc": "2.0", "id": 28, "method": "tools/call", "params": {"name": "query", "arguments": {"pattern": "(a)-[:links->(b)"}}}`,
+			expectError: true,
+			errorType:   "tool_error",
+		},
+		{
+			name:        "query explain",
+			request:     `{"jsonrpc": "2.0", "id": 29, "method": "tools/call", "params": {"name": "query", "arguments": {"pattern": "(a)-[:links]->(b {id: \"test:upsert\"})", "explain": true}}}`,
+			expectError: false,
+		},
+		{
+			name:        "query_find explain",
+			request:     `{"jsonrpc": "2.0", "id": 30, "method": "tools/call", "params": {"name": "query_find", "arguments": {"type": "test", "explain": true}}}`,
+			expectError: false,
+		},
 		{
 			name:        "query_find no criteria",
 			request:     `{"jsonrpc": "2.0", "id": 6, "method": "tools/call", "params": {"name": "query_find", "arguments": {}}}`,
@@ -0,0 +1,321 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+	"github.com/dshills/RelatixDB/internal/storage"
+)
+
+// Built-in prompt names
+const (
+	PromptSummarizeNode = "summarize_node"
+	PromptExplainPath   = "explain_path"
+	PromptChangesSince  = "changes_since"
+)
+
+// defaultPromptHops is how far summarize_node looks around the node
+const defaultPromptHops = 1
+
+// maxPromptChanges bounds the change log included by changes_since; older
+// changes beyond it are left out
+const maxPromptChanges = 500
+
+// maxPromptNodes bounds the neighborhood included by summarize_node; nodes
+// beyond it are left out along with their edges
+const maxPromptNodes = graph.DefaultSubgraphLimit
+
+// prompts returns the prompt templates the graph supports
+func (h *Handler) prompts() []Prompt {
+	prompts := []Prompt{
+		{
+			Name:        PromptSummarizeNode,
+			Description: "Summarize a node and its neighborhood from the facts in the graph",
+			Arguments: []PromptArgument{
+				{Name: "id", Description: "ID of the node to summarize", Required: true},
+				{Name: "hops", Description: fmt.Sprintf("How many hops of neighbors to include (default: %d)", defaultPromptHops)},
+			},
+		},
+		{
+			Name:        PromptExplainPath,
+			Description: "Explain how two nodes are related along the shortest path between them",
+			Arguments: []PromptArgument{
+				{Name: "from", Description: "ID of the first node", Required: true},
+				{Name: "to", Description: "ID of the second node", Required: true},
+				{Name: "max_depth", Description: "Maximum number of hops to search (default: unlimited)"},
+			},
+		},
+	}
+
+	if _, ok := h.graph.(graph.HistoryProvider); ok {
+		prompts = append(prompts, Prompt{
+			Name:        PromptChangesSince,
+			Description: "Summarize what changed in the graph since a point in time",
+			Arguments: []PromptArgument{
+				{Name: "since", Description: "RFC 3339 time such as 2025-01-02T15:04:05Z", Required: true},
+			},
+		})
+	}
+	return prompts
+}
+
+// handlePromptsList lists the built-in prompt templates
+func (h *Handler) handlePromptsList(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	return NewJSONRPCResponse(req.ID, ListPromptsResponse{Prompts: h.prompts()})
+}
+
+// handlePromptsGet fills a prompt template with data read from the graph
+func (h *Handler) handlePromptsGet(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	var getReq GetPromptRequest
+	if err := decodeParams(req, &getReq); err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid get prompt params", err.Error())
+	}
+
+	var (
+		description string
+		text        string
+		err         error
+	)
+	switch getReq.Name {
+	case PromptSummarizeNode:
+		description, text, err = h.summarizeNodePrompt(ctx, getReq.Arguments)
+	case PromptExplainPath:
+		description, text, err = h.explainPathPrompt(ctx, getReq.Arguments)
+	case PromptChangesSince:
+		description, text, err = h.changesSincePrompt(ctx, getReq.Arguments)
+	default:
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Unknown prompt", getReq.Name)
+	}
+	if err != nil {
+		h.debugLog("Prompt %s failed: %v", getReq.Name, err)
+		return NewJSONRPCErrorResponse(req.ID, promptErrorCode(err), "Failed to get prompt", err.Error())
+	}
+
+	return NewJSONRPCResponse(req.ID, GetPromptResponse{
+		Description: description,
+		Messages: []PromptMessage{
+			{Role: "user", Content: ContentItem{Type: "text", Text: text}},
+		},
+	})
+}
+
+// promptErrorCode maps errors caused by the prompt arguments to InvalidParams
+func promptErrorCode(err error) int {
+	var argErr *promptArgError
+	switch {
+	case errors.As(err, &argErr),
+		errors.Is(err, graph.ErrNodeNotFound),
+		errors.Is(err, graph.ErrInvalidQuery),
+		errors.Is(err, graph.ErrMaxDepthExceeded),
+		errors.Is(err, graph.ErrHistoryUnavailable):
+		return InvalidParams
+	default:
+		return InternalError
+	}
+}
+
+// promptArgError reports a missing or malformed prompt argument
+type promptArgError struct {
+	msg string
+}
+
+func (e *promptArgError) Error() string {
+	return e.msg
+}
+
+// summarizeNodePrompt asks for a summary of a node, listing the nodes and
+// edges within the requested number of hops
+func (h *Handler) summarizeNodePrompt(ctx context.Context, args map[string]string) (string, string, error) {
+	id := args["id"]
+	if id == "" {
+		return "", "", &promptArgError{"id is required"}
+	}
+	hops, err := promptIntArg(args, "hops", defaultPromptHops)
+	if err != nil {
+		return "", "", err
+	}
+
+	nodes, edges, truncated, err := graph.Subgraph(ctx, h.graph, graph.SubgraphOptions{
+		Seeds: []string{id},
+		Hops:  hops,
+		Limit: maxPromptNodes,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	storage.SortEdges(edges)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Summarize the node '%s' and its neighborhood within %d hops in the RelatixDB graph. ", id, hops)
+	b.WriteString("Describe what the node is, how it relates to the nodes around it, and anything notable. ")
+	b.WriteString("Use only the facts below.\n\n")
+	if truncated {
+		fmt.Fprintf(&b, "The neighborhood is larger than %d nodes; only the %d nearest are listed, with the edges between them.\n\n", maxPromptNodes, len(nodes))
+	}
+	b.WriteString("Nodes:\n")
+	for _, node := range nodes {
+		b.WriteString(formatPromptNode(node) + "\n")
+	}
+	b.WriteString("\nEdges:\n")
+	for _, edge := range edges {
+		b.WriteString(formatPromptEdge(edge) + "\n")
+	}
+	if len(edges) == 0 {
+		b.WriteString("(none)\n")
+	}
+
+	return fmt.Sprintf("Summary of '%s' and its %d-hop neighborhood", id, hops), b.String(), nil
+}
+
+// explainPathPrompt asks for an explanation of the shortest path between two
+// nodes, following edges in either direction
+func (h *Handler) explainPathPrompt(ctx context.Context, args map[string]string) (string, string, error) {
+	from, to := args["from"], args["to"]
+	if from == "" || to == "" {
+		return "", "", &promptArgError{"from and to are required"}
+	}
+	maxDepth, err := promptIntArg(args, "max_depth", 0)
+	if err != nil {
+		return "", "", err
+	}
+
+	result, err := h.graph.Query(ctx, graph.Query{
+		Type:      "shortest_path",
+		From:      from,
+		To:        to,
+		MaxDepth:  maxDepth,
+		Direction: "both",
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	description := fmt.Sprintf("How '%s' relates to '%s'", from, to)
+
+	var b strings.Builder
+	if len(result.Paths) == 0 {
+		fmt.Fprintf(&b, "No path connects '%s' and '%s' in the RelatixDB graph", from, to)
+		if maxDepth > 0 {
+			fmt.Fprintf(&b, " within %d hops", maxDepth)
+		}
+		b.WriteString(". Explain what that suggests about how they relate, using only the facts below.\n\nNodes:\n")
+		for _, id := range []string{from, to} {
+			node, err := h.graph.GetNode(ctx, id)
+			if err != nil {
+				return "", "", err
+			}
+			b.WriteString(formatPromptNode(*node) + "\n")
+		}
+		return description, b.String(), nil
+	}
+
+	path := result.Paths[0]
+	fmt.Fprintf(&b, "Explain how '%s' relates to '%s' in the RelatixDB graph, step by step along the path below. ", from, to)
+	b.WriteString("Use only these facts.\n\n")
+	fmt.Fprintf(&b, "Path (%d hops):\n%s\n\nNodes on the path:\n", len(path.Edges), formatPath(path))
+	for _, node := range path.Nodes {
+		b.WriteString(formatPromptNode(node) + "\n")
+	}
+	b.WriteString("\nEdges on the path:\n")
+	for _, edge := range path.Edges {
+		b.WriteString(formatPromptEdge(edge) + "\n")
+	}
+
+	return description, b.String(), nil
+}
+
+// changesSincePrompt asks for a summary of the changes recorded after a time
+func (h *Handler) changesSincePrompt(ctx context.Context, args map[string]string) (string, string, error) {
+	provider, ok := h.graph.(graph.HistoryProvider)
+	if !ok {
+		return "", "", graph.ErrHistoryUnavailable
+	}
+
+	raw := args["since"]
+	if raw == "" {
+		return "", "", &promptArgError{"since is required"}
+	}
+	since, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return "", "", &promptArgError{"since must be an RFC 3339 time such as 2025-01-02T15:04:05Z"}
+	}
+
+	versions, err := provider.Changes(ctx, since)
+	if err != nil {
+		return "", "", err
+	}
+
+	var b strings.Builder
+	stamp := since.UTC().Format(time.RFC3339Nano)
+	fmt.Fprintf(&b, "Summarize what changed in the RelatixDB graph since %s: what was created, updated and deleted, and by whom. ", stamp)
+	b.WriteString("Use only the change log below.\n\n")
+
+	if len(versions) == 0 {
+		b.WriteString("No changes were recorded.\n")
+	} else {
+		fmt.Fprintf(&b, "Changes recorded: %d\n", len(versions))
+		if omitted := len(versions) - maxPromptChanges; omitted > 0 {
+			fmt.Fprintf(&b, "The %d earliest are left out.\n", omitted)
+			versions = versions[omitted:]
+		}
+		b.WriteString("\nChange log:\n")
+		for _, v := range versions {
+			fmt.Fprintf(&b, "- %s: %s\n", versionSubject(v), formatVersion(v))
+		}
+	}
+
+	return fmt.Sprintf("Changes since %s", stamp), b.String(), nil
+}
+
+// versionSubject names the node or edge a version belongs to
+func versionSubject(v graph.Version) string {
+	if v.Edge != nil {
+		return fmt.Sprintf("edge %s -[%s]-> %s", v.Edge.From, v.Edge.Label, v.Edge.To)
+	}
+	if v.Node != nil {
+		return fmt.Sprintf("node '%s'", v.Node.ID)
+	}
+	return "unknown"
+}
+
+// formatPromptNode renders a node as "- id (type: t) {k: v}"
+func formatPromptNode(node graph.Node) string {
+	text := "- " + node.ID
+	if node.Type != "" {
+		text += fmt.Sprintf(" (type: %s)", node.Type)
+	}
+	return text + formatProps(node.Props)
+}
+
+// formatPromptEdge renders an edge as "- from -[label]-> to {k: v}"
+func formatPromptEdge(edge graph.Edge) string {
+	return fmt.Sprintf("- %s -[%s]-> %s%s", edge.From, edge.Label, edge.To, formatProps(edge.Props))
+}
+
+// promptIntArg parses an optional non-negative integer argument. Prompt
+// arguments are always strings.
+func promptIntArg(args map[string]string, key string, fallback int) (int, error) {
+	raw, ok := args[key]
+	if !ok || raw == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, &promptArgError{fmt.Sprintf("%s must be a non-negative integer", key)}
+	}
+	return n, nil
+}
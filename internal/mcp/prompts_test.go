@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+	"github.com/dshills/RelatixDB/internal/storage"
+)
+
+func TestHandler_Prompts(t *testing.T) {
+	ctx := context.Background()
+	backend := storage.NewBoltBackend()
+	if err := backend.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	pg := storage.NewPersistentGraph(backend, false, 0)
+	if err := pg.Load(ctx); err != nil {
+		t.Fatalf("Failed to load graph: %v", err)
+	}
+	defer pg.Close()
+
+	pg.AddNode(ctx, graph.Node{ID: "user:alice", Type: "user", Props: graph.StringProps(map[string]string{"name": "Alice"})})
+	pg.AddNode(ctx, graph.Node{ID: "team:core", Type: "team"})
+	pg.AddNode(ctx, graph.Node{ID: "repo:db", Type: "repo"})
+	pg.AddNode(ctx, graph.Node{ID: "user:bob", Type: "user"})
+	pg.AddEdge(ctx, graph.Edge{From: "user:alice", To: "team:core", Label: "member_of"})
+	pg.AddEdge(ctx, graph.Edge{From: "team:core", To: "repo:db", Label: "owns"})
+	since := time.Now()
+	time.Sleep(time.Millisecond)
+	pg.UpdateNode(ctx, "user:bob", graph.Update{Props: graph.StringProps(map[string]string{"status": "away"})})
+
+	handler := NewHandler(pg, nil, nil, false)
+
+	initReq := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`
+	response, _ := handler.ProcessSingleRequest(ctx, initReq)
+	if !strings.Contains(response, `"prompts":{}`) {
+		t.Fatalf("Expected the prompts capability to be advertised, got %s", response)
+	}
+
+	response, _ = handler.ProcessSingleRequest(ctx, `{"jsonrpc": "2.0", "id": 2, "method": "prompts/list"}`)
+	for _, name := range []string{PromptSummarizeNode, PromptExplainPath, PromptChangesSince} {
+		if !strings.Contains(response, `"name":"`+name+`"`) {
+			t.Fatalf("Expected prompt %s to be listed, got %s", name, response)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		prompt    string
+		arguments map[string]string
+		contains  []string
+		code      int
+	}{
+		{"summarize node", PromptSummarizeNode, map[string]string{"id": "team:core"},
+			[]string{"- team:core (type: team)", "- user:alice (type: user) {name: Alice}", "- team:core -[owns]-> repo:db"}, 0},
+		{"summarize with hops", PromptSummarizeNode, map[string]string{"id": "user:alice", "hops": "2"},
+			[]string{"within 2 hops", "- repo:db (type: repo)"}, 0},
+		{"explain path", PromptExplainPath, map[string]string{"from": "repo:db", "to": "user:alice"},
+			[]string{"Path (2 hops):", "repo:db <-[owns]- team:core <-[member_of]- user:alice"}, 0},
+		{"no path", PromptExplainPath, map[string]string{"from": "user:alice", "to": "user:bob"},
+			[]string{"No path connects 'user:alice' and 'user:bob'", "- user:bob (type: user) {status: away}"}, 0},
+		{"changes since", PromptChangesSince, map[string]string{"since": since.UTC().Format(time.RFC3339Nano)},
+			[]string{"Changes recorded: 1", "- node 'user:bob': #7"}, 0},
+		{"missing node", PromptSummarizeNode, map[string]string{"id": "user:carol"}, nil, InvalidParams},
+		{"missing argument", PromptExplainPath, map[string]string{"from": "user:alice"}, nil, InvalidParams},
+		{"invalid hops", PromptSummarizeNode, map[string]string{"id": "user:alice", "hops": "many"}, nil, InvalidParams},
+		{"invalid time", PromptChangesSince, map[string]string{"since": "yesterday"}, nil, InvalidParams},
+		{"unknown prompt", "write_poem", nil, nil, InvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(GetPromptRequest{Name: tt.prompt, Arguments: tt.arguments})
+			request := `{"jsonrpc": "2.0", "id": 3, "method": "prompts/get", "params": ` + string(params) + `}`
+			response, _ := handler.ProcessSingleRequest(ctx, request)
+
+			var resp struct {
+				Result GetPromptResponse `json:"result"`
+				Error  *JSONRPCError     `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(response), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if tt.code != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.code {
+					t.Fatalf("Expected error code %d, got %s", tt.code, response)
+				}
+				return
+			}
+			if resp.Error != nil || len(resp.Result.Messages) != 1 {
+				t.Fatalf("Expected one prompt message, got %s", response)
+			}
+			text := resp.Result.Messages[0].Content.Text
+			for _, want := range tt.contains {
+				if !strings.Contains(text, want) {
+					t.Fatalf("Expected %q in prompt, got %s", want, text)
+				}
+			}
+		})
+	}
+
+	// Large neighborhoods are cut off at maxPromptNodes
+	star := graph.NewMemoryGraph()
+	star.AddNode(ctx, graph.Node{ID: "hub"})
+	for i := 0; i < maxPromptNodes+10; i++ {
+		leaf := fmt.Sprintf("leaf:%d", i)
+		star.AddNode(ctx, graph.Node{ID: leaf})
+		star.AddEdge(ctx, graph.Edge{From: "hub", To: leaf, Label: "links"})
+	}
+	handler = NewHandler(star, nil, nil, false)
+	handler.ProcessSingleRequest(ctx, initReq)
+	params, _ := json.Marshal(GetPromptRequest{Name: PromptSummarizeNode, Arguments: map[string]string{"id": "hub"}})
+	response, _ = handler.ProcessSingleRequest(ctx, `{"jsonrpc": "2.0", "id": 4, "method": "prompts/get", "params": `+string(params)+`}`)
+	if !strings.Contains(response, fmt.Sprintf("only the %d nearest are listed", maxPromptNodes)) {
+		t.Fatalf("Expected the prompt to note the truncation, got %.300s", response)
+	}
+	nodes, edges := strings.Count(response, "- leaf:"), strings.Count(response, "-[links]-")
+	if nodes != maxPromptNodes-1 || edges != maxPromptNodes-1 {
+		t.Errorf("Expected %d leaves and edges, got %d and %d", maxPromptNodes-1, nodes, edges)
+	}
+
+	// Graphs without history don't offer changes_since
+	handler = NewHandler(graph.NewMemoryGraph(), nil, nil, false)
+	handler.ProcessSingleRequest(ctx, initReq)
+	response, _ = handler.ProcessSingleRequest(ctx, `{"jsonrpc": "2.0", "id": 2, "method": "prompts/list"}`)
+	if strings.Contains(response, PromptChangesSince) {
+		t.Fatalf("Expected no changes_since prompt without history, got %s", response)
+	}
+}
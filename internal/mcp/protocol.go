@@ -1,6 +1,7 @@
 package mcp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -11,11 +12,13 @@ type JSONRPCRequest struct {
 	ID      interface{} `json:"id,omitempty"`
 	Method  string      `json:"method"`
 	Params  interface{} `json:"params,omitempty"`
+
+	hasID bool // set by ParseJSONRPCRequest when the message has an id, even null
 }
 
 type JSONRPCResponse struct {
 	JSONRpc string        `json:"jsonrpc"`
-	ID      interface{}   `json:"id,omitempty"`
+	ID      interface{}   `json:"id"`
 	Result  interface{}   `json:"result,omitempty"`
 	Error   *JSONRPCError `json:"error,omitempty"`
 }
@@ -112,6 +115,90 @@ type ContentItem struct {
 	Text string `json:"text,omitempty"`
 }
 
+// Resources protocol types
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+type ListResourcesResponse struct {
+	Resources  []Resource `json:"resources"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+type ListResourceTemplatesResponse struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+	NextCursor        string             `json:"nextCursor,omitempty"`
+}
+
+// ResourceRequest is the params of resources/read, resources/subscribe and
+// resources/unsubscribe
+type ResourceRequest struct {
+	URI string `json:"uri"`
+}
+
+type ReadResourceResponse struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
+type ResourceUpdatedNotification struct {
+	URI string `json:"uri"`
+}
+
+// Prompts protocol types
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+type ListPromptsResponse struct {
+	Prompts    []Prompt `json:"prompts"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+type GetPromptRequest struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type GetPromptResponse struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content ContentItem `json:"content"`
+}
+
+// CancelledNotification is the params of notifications/cancelled
+type CancelledNotification struct {
+	RequestID interface{} `json:"requestId"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
 // Helper functions
 func NewJSONRPCRequest(method string, params interface{}) *JSONRPCRequest {
 	return &JSONRPCRequest{
@@ -148,6 +235,9 @@ const (
 	MethodNotFound = -32601
 	InvalidParams  = -32602
 	InternalError  = -32603
+
+	// MCP error codes
+	ResourceNotFound = -32002
 )
 
 // MCP Protocol methods
@@ -155,26 +245,105 @@ const (
 	MethodInitialize = "initialize"
 	MethodToolsList  = "tools/list"
 	MethodToolsCall  = "tools/call"
+
+	MethodResourcesList          = "resources/list"
+	MethodResourceTemplatesList  = "resources/templates/list"
+	MethodResourcesRead          = "resources/read"
+	MethodResourcesSubscribe     = "resources/subscribe"
+	MethodResourcesUnsubscribe   = "resources/unsubscribe"
+	MethodPromptsList            = "prompts/list"
+	MethodPromptsGet             = "prompts/get"
+	NotificationResourcesUpdated = "notifications/resources/updated"
+	NotificationCancelled        = "notifications/cancelled"
+	NotificationInitialized      = "notifications/initialized"
 )
 
-// ParseJSONRPCRequest parses a JSON-RPC request
+// RequestError reports a message that is not a valid JSON-RPC request, with
+// the error code to answer with and the request ID when it could be read
+type RequestError struct {
+	Code int
+	ID   interface{}
+	Err  error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Response returns the error response for the invalid message
+func (e *RequestError) Response() *JSONRPCResponse {
+	message := "Invalid Request"
+	if e.Code == ParseError {
+		message = "Parse error"
+	}
+	return NewJSONRPCErrorResponse(e.ID, e.Code, message, e.Err.Error())
+}
+
+// ParseJSONRPCRequest parses a JSON-RPC request. Invalid JSON is reported as
+// a ParseError and well-formed JSON that isn't a valid request as an
+// InvalidRequest, both as a *RequestError.
 func ParseJSONRPCRequest(data []byte) (*JSONRPCRequest, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		if json.Valid(data) {
+			return nil, &RequestError{Code: InvalidRequest, Err: fmt.Errorf("request must be a JSON object")}
+		}
+		return nil, &RequestError{Code: ParseError, Err: fmt.Errorf("failed to parse JSON-RPC request: %w", err)}
+	}
+
+	// The ID is answered with, so it is checked before anything else
+	rawID, hasID := fields["id"]
+	var id interface{}
+	if hasID {
+		if err := json.Unmarshal(rawID, &id); err != nil {
+			return nil, &RequestError{Code: InvalidRequest, Err: err}
+		}
+		switch id.(type) {
+		case nil, string, float64:
+		default:
+			return nil, &RequestError{Code: InvalidRequest, Err: fmt.Errorf("id must be a string, number or null")}
+		}
+	}
+
 	var req JSONRPCRequest
 	if err := json.Unmarshal(data, &req); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON-RPC request: %w", err)
+		return nil, &RequestError{Code: InvalidRequest, ID: id, Err: fmt.Errorf("invalid JSON-RPC request: %w", err)}
 	}
+	req.hasID = hasID
 
 	if req.JSONRpc != "2.0" {
-		return nil, fmt.Errorf("invalid JSON-RPC version: %s", req.JSONRpc)
+		return nil, &RequestError{Code: InvalidRequest, ID: id, Err: fmt.Errorf("invalid JSON-RPC version: %s", req.JSONRpc)}
 	}
 
 	if req.Method == "" {
-		return nil, fmt.Errorf("method field is required")
+		return nil, &RequestError{Code: InvalidRequest, ID: id, Err: fmt.Errorf("method field is required")}
+	}
+
+	if params, ok := fields["params"]; ok {
+		if trimmed := bytes.TrimSpace(params); len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[' && string(trimmed) != "null") {
+			return nil, &RequestError{Code: InvalidRequest, ID: id, Err: fmt.Errorf("params must be an object or array")}
+		}
 	}
 
 	return &req, nil
 }
 
+// IsNotification reports whether the request has no ID, so no response may
+// be sent for it
+func (r *JSONRPCRequest) IsNotification() bool {
+	return !r.hasID
+}
+
+// isBatch reports whether a message is a JSON array of requests
+func isBatch(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
 // ToJSON converts response to JSON bytes
 func (r *JSONRPCResponse) ToJSON() ([]byte, error) {
 	return json.Marshal(r)
@@ -0,0 +1,366 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+	"github.com/dshills/RelatixDB/internal/storage"
+)
+
+// Resource URIs. A node resource holds the node and its edges; a type
+// resource holds every node of the type. Path components are URL-escaped.
+const (
+	resourceScheme     = "relatix://"
+	nodeResourcePrefix = resourceScheme + "node/"
+	typeResourcePrefix = resourceScheme + "type/"
+	resourceMimeType   = "application/json"
+)
+
+// nodeResourceURI returns the URI of a node resource
+func nodeResourceURI(id string) string {
+	return nodeResourcePrefix + url.PathEscape(id)
+}
+
+// typeResourceURI returns the URI of a node type resource
+func typeResourceURI(nodeType string) string {
+	return typeResourcePrefix + url.PathEscape(nodeType)
+}
+
+// parseResourceURI splits a resource URI into its kind ("node" or "type")
+// and the unescaped node ID or type
+func parseResourceURI(uri string) (string, string, error) {
+	var kind, escaped string
+	switch {
+	case strings.HasPrefix(uri, nodeResourcePrefix):
+		kind, escaped = "node", strings.TrimPrefix(uri, nodeResourcePrefix)
+	case strings.HasPrefix(uri, typeResourcePrefix):
+		kind, escaped = "type", strings.TrimPrefix(uri, typeResourcePrefix)
+	default:
+		return "", "", fmt.Errorf("unknown resource URI '%s', expected %s{id} or %s{type}", uri, nodeResourcePrefix, typeResourcePrefix)
+	}
+
+	name, err := url.PathUnescape(escaped)
+	if err != nil || name == "" {
+		return "", "", fmt.Errorf("invalid resource URI '%s'", uri)
+	}
+	return kind, name, nil
+}
+
+// changedResources returns the URIs of the resources affected by changes:
+// the nodes that changed or whose edges changed, and the types of changed
+// nodes, including the type a node had before an update
+func changedResources(changes []graph.Change) []string {
+	seen := make(map[string]bool)
+	add := func(uri string) {
+		seen[uri] = true
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Node != nil:
+			add(nodeResourceURI(change.Node.ID))
+			if change.Node.Type != "" {
+				add(typeResourceURI(change.Node.Type))
+			}
+			if change.PrevNode != nil && change.PrevNode.Type != "" {
+				add(typeResourceURI(change.PrevNode.Type))
+			}
+		case change.Edge != nil:
+			add(nodeResourceURI(change.Edge.From))
+			add(nodeResourceURI(change.Edge.To))
+		}
+	}
+
+	uris := make([]string, 0, len(seen))
+	for uri := range seen {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// handleResourcesList lists a resource for each node type in the graph.
+// Individual nodes are reachable through the node resource template.
+func (h *Handler) handleResourcesList(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	nodes, err := h.graph.GetAllNodes(ctx)
+	if err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InternalError, "Failed to list resources", err.Error())
+	}
+
+	counts := make(map[string]int)
+	for _, node := range nodes {
+		if node.Type != "" {
+			counts[node.Type]++
+		}
+	}
+
+	types := make([]string, 0, len(counts))
+	for nodeType := range counts {
+		types = append(types, nodeType)
+	}
+	sort.Strings(types)
+
+	resources := make([]Resource, len(types))
+	for i, nodeType := range types {
+		resources[i] = Resource{
+			URI:         typeResourceURI(nodeType),
+			Name:        fmt.Sprintf("%s nodes", nodeType),
+			Description: fmt.Sprintf("All %d nodes of type '%s'", counts[nodeType], nodeType),
+			MimeType:    resourceMimeType,
+		}
+	}
+
+	h.debugLog("Returning %d resources", len(resources))
+	return NewJSONRPCResponse(req.ID, ListResourcesResponse{Resources: resources})
+}
+
+// handleResourceTemplatesList lists the node and type resource templates
+func (h *Handler) handleResourceTemplatesList(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	return NewJSONRPCResponse(req.ID, ListResourceTemplatesResponse{
+		ResourceTemplates: []ResourceTemplate{
+			{
+				URITemplate: nodeResourcePrefix + "{id}",
+				Name:        "Node",
+				Description: "A node with its incoming and outgoing edges",
+				MimeType:    resourceMimeType,
+			},
+			{
+				URITemplate: typeResourcePrefix + "{type}",
+				Name:        "Nodes by type",
+				Description: "All nodes of a type",
+				MimeType:    resourceMimeType,
+			},
+		},
+	})
+}
+
+// handleResourcesRead returns the current contents of a resource as JSON
+func (h *Handler) handleResourcesRead(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	var readReq ResourceRequest
+	if err := decodeParams(req, &readReq); err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid read resource params", err.Error())
+	}
+
+	kind, name, err := parseResourceURI(readReq.URI)
+	if err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid resource URI", err.Error())
+	}
+
+	var contents interface{}
+	switch kind {
+	case "node":
+		contents, err = h.readNodeResource(ctx, name)
+	case "type":
+		contents, err = h.readTypeResource(ctx, name)
+	}
+	if errors.Is(err, graph.ErrNodeNotFound) {
+		return NewJSONRPCErrorResponse(req.ID, ResourceNotFound, "Resource not found", readReq.URI)
+	}
+	if err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InternalError, "Failed to read resource", err.Error())
+	}
+
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InternalError, "Failed to encode resource", err.Error())
+	}
+
+	return NewJSONRPCResponse(req.ID, ReadResourceResponse{
+		Contents: []ResourceContents{{URI: readReq.URI, MimeType: resourceMimeType, Text: string(data)}},
+	})
+}
+
+// nodeResource is the contents of a node resource
+type nodeResource struct {
+	Node     graph.Node   `json:"node"`
+	Outgoing []graph.Edge `json:"outgoing"`
+	Incoming []graph.Edge `json:"incoming"`
+}
+
+// typeResource is the contents of a type resource
+type typeResource struct {
+	Type  string       `json:"type"`
+	Nodes []graph.Node `json:"nodes"`
+}
+
+func (h *Handler) readNodeResource(ctx context.Context, id string) (*nodeResource, error) {
+	node, err := h.graph.GetNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	outgoing, err := h.graph.GetEdges(ctx, id, "out")
+	if err != nil {
+		return nil, err
+	}
+	incoming, err := h.graph.GetEdges(ctx, id, "in")
+	if err != nil {
+		return nil, err
+	}
+
+	storage.SortEdges(outgoing)
+	storage.SortEdges(incoming)
+	return &nodeResource{Node: *node, Outgoing: outgoing, Incoming: incoming}, nil
+}
+
+func (h *Handler) readTypeResource(ctx context.Context, nodeType string) (*typeResource, error) {
+	nodes, err := h.graph.GetNodesByType(ctx, nodeType)
+	if err != nil {
+		return nil, err
+	}
+
+	// A type with no nodes is simply empty, so it can be subscribed to before it exists
+	storage.SortNodes(nodes)
+	if nodes == nil {
+		nodes = []graph.Node{}
+	}
+	return &typeResource{Type: nodeType, Nodes: nodes}, nil
+}
+
+// handleResourcesSubscribe starts sending notifications/resources/updated
+// for a resource. The resource does not need to exist yet.
+func (h *Handler) handleResourcesSubscribe(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	feed, ok := h.graph.(graph.ChangeFeed)
+	if !ok {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Graph does not publish changes", nil)
+	}
+
+	var subReq ResourceRequest
+	if err := decodeParams(req, &subReq); err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid subscribe params", err.Error())
+	}
+	if _, _, err := parseResourceURI(subReq.URI); err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid resource URI", err.Error())
+	}
+
+	h.watchMu.Lock()
+	if h.unwatch == nil {
+		h.unwatch = feed.Watch(h.recordChanges)
+	}
+	h.watchMu.Unlock()
+
+	h.subsMu.Lock()
+	if h.subscriptions == nil {
+		h.subscriptions = make(map[string]bool)
+	}
+	h.subscriptions[subReq.URI] = true
+	h.subsMu.Unlock()
+
+	h.debugLog("Subscribed to %s", subReq.URI)
+	return NewJSONRPCResponse(req.ID, struct{}{})
+}
+
+// handleResourcesUnsubscribe stops notifications for a resource
+func (h *Handler) handleResourcesUnsubscribe(_ context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	if !h.isInitialized() {
+		return NewJSONRPCErrorResponse(req.ID, InvalidRequest, "Server not initialized", nil)
+	}
+
+	var subReq ResourceRequest
+	if err := decodeParams(req, &subReq); err != nil {
+		return NewJSONRPCErrorResponse(req.ID, InvalidParams, "Invalid unsubscribe params", err.Error())
+	}
+
+	h.subsMu.Lock()
+	defer h.subsMu.Unlock()
+
+	delete(h.subscriptions, subReq.URI)
+	delete(h.updated, subReq.URI)
+
+	h.debugLog("Unsubscribed from %s", subReq.URI)
+	return NewJSONRPCResponse(req.ID, struct{}{})
+}
+
+// recordChanges is the graph watcher: it marks subscribed resources touched
+// by changes as updated and wakes the notification loop. It runs under the
+// graph's lock, so it only records; notifications are written later.
+func (h *Handler) recordChanges(changes []graph.Change) {
+	h.subsMu.Lock()
+	defer h.subsMu.Unlock()
+
+	marked := false
+	for _, uri := range changedResources(changes) {
+		if !h.subscriptions[uri] {
+			continue
+		}
+		if h.updated == nil {
+			h.updated = make(map[string]bool)
+		}
+		h.updated[uri] = true
+		marked = true
+	}
+
+	if marked {
+		select {
+		case h.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flushNotifications writes a notifications/resources/updated message for
+// each resource updated since the last flush. Repeated updates to a resource
+// are coalesced into one notification, since clients re-read it anyway.
+func (h *Handler) flushNotifications() error {
+	h.subsMu.Lock()
+	uris := make([]string, 0, len(h.updated))
+	for uri := range h.updated {
+		uris = append(uris, uri)
+	}
+	h.updated = nil
+	h.subsMu.Unlock()
+
+	sort.Strings(uris)
+	for _, uri := range uris {
+		notification := NewJSONRPCRequest(NotificationResourcesUpdated, ResourceUpdatedNotification{URI: uri})
+		if err := h.writeMessage(notification); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopWatching cancels the handler's graph watcher, if any
+func (h *Handler) stopWatching() {
+	h.watchMu.Lock()
+	defer h.watchMu.Unlock()
+
+	if h.unwatch != nil {
+		h.unwatch()
+		h.unwatch = nil
+	}
+}
+
+// decodeParams unmarshals a request's params into target
+func decodeParams(req *JSONRPCRequest, target interface{}) error {
+	if req.Params == nil {
+		return fmt.Errorf("params are required")
+	}
+
+	data, err := json.Marshal(req.Params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
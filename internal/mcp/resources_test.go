@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+func TestHandler_Resources(t *testing.T) {
+	ctx := context.Background()
+	g := graph.NewMemoryGraph()
+	g.AddNode(ctx, graph.Node{ID: "user:alice", Type: "user", Props: graph.StringProps(map[string]string{"name": "Alice"})})
+	g.AddNode(ctx, graph.Node{ID: "team/core", Type: "team"})
+	g.AddEdge(ctx, graph.Edge{From: "user:alice", To: "team/core", Label: "member_of"})
+
+	handler := NewHandler(g, nil, nil, false)
+
+	initReq := `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`
+	response, _ := handler.ProcessSingleRequest(ctx, initReq)
+	if !strings.Contains(response, `"resources":{"subscribe":true}`) {
+		t.Fatalf("Expected the resources capability to be advertised, got %s", response)
+	}
+
+	response, _ = handler.ProcessSingleRequest(ctx, `{"jsonrpc": "2.0", "id": 2, "method": "resources/list"}`)
+	if !strings.Contains(response, `"uri":"relatix://type/team"`) || !strings.Contains(response, `"uri":"relatix://type/user"`) {
+		t.Fatalf("Expected a resource per node type, got %s", response)
+	}
+
+	response, _ = handler.ProcessSingleRequest(ctx, `{"jsonrpc": "2.0", "id": 3, "method": "resources/templates/list"}`)
+	if !strings.Contains(response, `"uriTemplate":"relatix://node/{id}"`) {
+		t.Fatalf("Expected the node resource template, got %s", response)
+	}
+
+	tests := []struct {
+		name     string
+		uri      string
+		contains string
+		code     int
+	}{
+		{"node with edges", "relatix://node/user:alice", `"label": "member_of"`, 0},
+		{"escaped node ID", "relatix://node/team%2Fcore", `"id": "team/core"`, 0},
+		{"type", "relatix://type/user", `"id": "user:alice"`, 0},
+		{"empty type", "relatix://type/robot", `"nodes": []`, 0},
+		{"missing node", "relatix://node/user:bob", "", ResourceNotFound},
+		{"unknown scheme", "file:///etc/passwd", "", InvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := `{"jsonrpc": "2.0", "id": 4, "method": "resources/read", "params": {"uri": "` + tt.uri + `"}}`
+			response, _ := handler.ProcessSingleRequest(ctx, request)
+
+			var resp struct {
+				Result ReadResourceResponse `json:"result"`
+				Error  *JSONRPCError        `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(response), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			if tt.code != 0 {
+				if resp.Error == nil || resp.Error.Code != tt.code {
+					t.Fatalf("Expected error code %d, got %s", tt.code, response)
+				}
+				return
+			}
+			if resp.Error != nil || len(resp.Result.Contents) != 1 {
+				t.Fatalf("Expected one resource, got %s", response)
+			}
+			if text := resp.Result.Contents[0].Text; !strings.Contains(text, tt.contains) {
+				t.Fatalf("Expected %s in resource, got %s", tt.contains, text)
+			}
+		})
+	}
+}
+
+func TestHandler_ResourceSubscriptions(t *testing.T) {
+	requests := []string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2024-11-05", "capabilities": {}, "clientInfo": {"name": "test-client", "version": "1.0.0"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "resources/subscribe", "params": {"uri": "relatix://node/a"}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "resources/subscribe", "params": {"uri": "relatix://type/file"}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": {"name": "add_node", "arguments": {"id": "a", "type": "file"}}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": {"name": "add_node", "arguments": {"id": "b"}}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "tools/call", "params": {"name": "add_edge", "arguments": {"from": "b", "to": "a", "label": "imports"}}}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "resources/unsubscribe", "params": {"uri": "relatix://type/file"}}`,
+		`{"jsonrpc": "2.0", "id": 8, "method": "tools/call", "params": {"name": "update_node", "arguments": {"id": "a", "type": "doc"}}}`,
+		`{"jsonrpc": "2.0", "id": 9, "method": "resources/subscribe", "params": {"uri": "relatix://edge/a"}}`,
+	}
+
+	var output bytes.Buffer
+	handler := NewHandler(graph.NewMemoryGraph(), strings.NewReader(strings.Join(requests, "\n")), &output, false)
+	handler.Workers = 1 // the requests depend on each other's order
+	if err := handler.Run(context.Background()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	updates := make(map[string]int)
+	responses := 0
+	for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+		var message struct {
+			ID     interface{}                 `json:"id"`
+			Method string                      `json:"method"`
+			Params ResourceUpdatedNotification `json:"params"`
+			Error  *JSONRPCError               `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			t.Fatalf("Failed to parse output line %s: %v", line, err)
+		}
+
+		if message.Method == NotificationResourcesUpdated {
+			if message.ID != nil {
+				t.Fatalf("Expected notifications to have no id, got %s", line)
+			}
+			updates[message.Params.URI]++
+			continue
+		}
+
+		responses++
+		if message.Error != nil && message.ID != float64(9) {
+			t.Fatalf("Unexpected error response: %s", line)
+		}
+	}
+
+	if responses != len(requests) {
+		t.Fatalf("Expected %d responses, got %d", len(requests), responses)
+	}
+
+	// a changed when added, when b linked to it and when updated; its old
+	// type was unsubscribed before the update and b was never subscribed
+	expected := map[string]int{"relatix://node/a": 3, "relatix://type/file": 1}
+	if len(updates) != len(expected) {
+		t.Fatalf("Expected updates %v, got %v", expected, updates)
+	}
+	for uri, count := range expected {
+		if updates[uri] != count {
+			t.Fatalf("Expected %d updates of %s, got %v", count, uri, updates)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package storage
+
+import "fmt"
+
+// Storage backend kinds
+const (
+	BackendBolt = "bolt"
+	BackendWAL  = "wal"
+)
+
+// BackendKinds lists the storage backends NewBackend accepts
+var BackendKinds = []string{BackendBolt, BackendWAL}
+
+// NewBackend creates a storage backend of the given kind
+func NewBackend(kind string) (Backend, error) {
+	switch kind {
+	case BackendBolt, "":
+		return NewBoltBackend(), nil
+	case BackendWAL:
+		return NewWALBackend(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected one of: bolt, wal)", kind)
+	}
+}
@@ -0,0 +1,677 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// testBackend is the surface every storage backend offers
+type testBackend interface {
+	Backend
+	MetaStore
+	StatsProvider
+}
+
+// forEachBackend runs a test against every storage backend
+func forEachBackend(t *testing.T, fn func(t *testing.T, newBackend func() testBackend)) {
+	t.Run(BackendBolt, func(t *testing.T) {
+		fn(t, func() testBackend { return NewBoltBackend() })
+	})
+	t.Run(BackendWAL, func(t *testing.T) {
+		fn(t, func() testBackend { return NewWALBackend() })
+	})
+}
+
+func TestBackend_OpenClose(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+
+		backend := newBackend()
+
+		// Test open
+		err := backend.Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		// Test close
+		err = backend.Close()
+		if err != nil {
+			t.Fatalf("Failed to close database: %v", err)
+		}
+
+		// Verify file was created
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			t.Fatalf("Database file was not created")
+		}
+	})
+}
+
+func TestBackend_Transaction(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+
+		backend := newBackend()
+		err := backend.Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer backend.Close()
+
+		// Test transaction operations
+		tx, err := backend.BeginTransaction()
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+
+		// Test node operations
+		node := graph.Node{
+			ID:   "test:1",
+			Type: "test",
+			Props: graph.StringProps(map[string]string{
+				"name": "Test Node",
+			}),
+		}
+
+		err = tx.SaveNode(node)
+		if err != nil {
+			t.Fatalf("Failed to save node: %v", err)
+		}
+
+		// Test edge operations
+		edge := graph.Edge{
+			From:  "test:1",
+			To:    "test:2",
+			Label: "connects",
+			Props: graph.StringProps(map[string]string{
+				"weight": "1.0",
+			}),
+		}
+
+		err = tx.SaveEdge(edge)
+		if err != nil {
+			t.Fatalf("Failed to save edge: %v", err)
+		}
+
+		// Test commit
+		err = tx.Commit()
+		if err != nil {
+			t.Fatalf("Failed to commit transaction: %v", err)
+		}
+	})
+}
+
+func TestBackend_LoadGraph(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+
+		backend := newBackend()
+		err := backend.Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer backend.Close()
+
+		// Save some data first
+		tx, err := backend.BeginTransaction()
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+
+		node := graph.Node{
+			ID:   "test:1",
+			Type: "test",
+			Props: graph.StringProps(map[string]string{
+				"name": "Test Node",
+			}),
+		}
+
+		err = tx.SaveNode(node)
+		if err != nil {
+			t.Fatalf("Failed to save node: %v", err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+
+		// Now test loading
+		ctx := context.Background()
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		// Verify the node was loaded
+		retrievedNode, err := loadedGraph.GetNode(ctx, "test:1")
+		if err != nil {
+			t.Fatalf("Failed to get loaded node: %v", err)
+		}
+
+		if retrievedNode.ID != node.ID {
+			t.Fatalf("Expected node ID %s, got %s", node.ID, retrievedNode.ID)
+		}
+
+		if retrievedNode.Type != node.Type {
+			t.Fatalf("Expected node type %s, got %s", node.Type, retrievedNode.Type)
+		}
+
+		if retrievedNode.Props["name"] != node.Props["name"] {
+			t.Fatalf("Expected node name %s, got %s", node.Props["name"], retrievedNode.Props["name"])
+		}
+	})
+}
+
+func TestBackend_PropertyIndexes(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		if err := pg.AddNode(ctx, graph.Node{ID: "file1", Type: "file", Props: graph.StringProps(map[string]string{"path": "auth/login.go"})}); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+
+		if err := pg.CreatePropertyIndex("path"); err != nil {
+			t.Fatalf("Failed to create property index: %v", err)
+		}
+
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Failed to close graph: %v", err)
+		}
+
+		// Reopen and verify the declaration survived and the index was rebuilt
+		backend = newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to reopen database: %v", err)
+		}
+		defer backend.Close()
+
+		indexes, err := LoadPropertyIndexes(backend)
+		if err != nil {
+			t.Fatalf("Failed to load property indexes: %v", err)
+		}
+
+		if len(indexes) != 1 || indexes[0] != "path" {
+			t.Fatalf("Expected [path], got %v", indexes)
+		}
+
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		indexer, ok := loadedGraph.(graph.PropertyIndexer)
+		if !ok || len(indexer.PropertyIndexes()) != 1 {
+			t.Fatalf("Expected loaded graph to carry the path index")
+		}
+
+		nodes, err := loadedGraph.GetNodesByProperty(ctx, "path", "auth/login.go")
+		if err != nil {
+			t.Fatalf("Failed to query by property: %v", err)
+		}
+
+		if len(nodes) != 1 || nodes[0].ID != "file1" {
+			t.Fatalf("Expected file1, got %v", nodes)
+		}
+	})
+}
+
+func TestBackend_Schema(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		schema, err := graph.ParseSchema([]byte(`{"mode": "warn", "node_types": {"file": {"properties": {"path": {"required": true, "pattern": "[a-z/]+\\.go"}}}}}`))
+		if err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if err := pg.SetSchema(schema); err != nil {
+			t.Fatalf("Failed to set schema: %v", err)
+		}
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Failed to close graph: %v", err)
+		}
+
+		// Reopen and verify the schema survived with its patterns compiled
+		backend = newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to reopen database: %v", err)
+		}
+		defer backend.Close()
+
+		pg = NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+		loaded := pg.Schema()
+		if loaded == nil || loaded.Strict() || len(loaded.NodeTypes) != 1 {
+			t.Fatalf("Expected the warn schema to be loaded, got %+v", loaded)
+		}
+		if violations := loaded.CheckNode(graph.Node{ID: "a", Type: "file", Props: graph.StringProps(map[string]string{"path": "a.py"})}); len(violations) != 1 {
+			t.Fatalf("Expected the path pattern to be enforced, got %q", violations)
+		}
+
+		// Batches are checked while the persistent graph holds its lock
+		ops := graph.NewOperations(pg)
+		var warnings []string
+		ops.OnSchemaWarning = func(violation string) { warnings = append(warnings, violation) }
+		if _, err := ops.ApplyBatch(ctx, []graph.BatchOp{{Op: graph.OpUpsertNode, ID: "b", Type: "file"}}); err != nil || len(warnings) != 1 {
+			t.Fatalf("Expected the upsert to be made with a warning, got %q (%v)", warnings, err)
+		}
+
+		// Removing the schema is persisted too
+		if err := pg.SetSchema(nil); err != nil {
+			t.Fatalf("Failed to remove schema: %v", err)
+		}
+		schema, err = LoadSchema(backend)
+		if err != nil || schema != nil {
+			t.Fatalf("Expected no stored schema, got %+v (%v)", schema, err)
+		}
+	})
+}
+
+func TestBackend_SaveGraph(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		defer backend.Close()
+
+		// A stale record that the snapshot must replace
+		tx, err := backend.BeginTransaction()
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		if err := tx.SaveNode(graph.Node{ID: "stale"}); err != nil {
+			t.Fatalf("Failed to save node: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+
+		if err := SavePropertyIndexes(backend, []string{"name"}); err != nil {
+			t.Fatalf("Failed to save property indexes: %v", err)
+		}
+
+		g := graph.NewMemoryGraph()
+		g.AddNode(ctx, graph.Node{ID: "a", Type: "test", Props: graph.StringProps(map[string]string{"name": "A"})})
+		g.AddNode(ctx, graph.Node{ID: "b", Type: "test"})
+		g.AddEdge(ctx, graph.Edge{From: "a", To: "b", Label: "links", Props: graph.StringProps(map[string]string{"weight": "2"})})
+
+		if err := backend.SaveGraph(ctx, g); err != nil {
+			t.Fatalf("Failed to save graph: %v", err)
+		}
+
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		if loadedGraph.NodeExists(ctx, "stale") {
+			t.Fatalf("Expected stale node to be replaced by the snapshot")
+		}
+
+		nodes, _ := loadedGraph.GetAllNodes(ctx)
+		edges, _ := loadedGraph.GetAllEdges(ctx)
+		if len(nodes) != 2 || len(edges) != 1 {
+			t.Fatalf("Expected 2 nodes and 1 edge, got %d and %d", len(nodes), len(edges))
+		}
+
+		if edges[0].Props["weight"].String() != "2" {
+			t.Fatalf("Expected edge props to round-trip, got %v", edges[0].Props)
+		}
+
+		// Metadata survives a snapshot
+		indexes, err := LoadPropertyIndexes(backend)
+		if err != nil || len(indexes) != 1 {
+			t.Fatalf("Expected property indexes to survive, got %v (%v)", indexes, err)
+		}
+	})
+}
+
+func TestPersistentGraph_AutoSave(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, true, 10*time.Millisecond)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		pg.AddNode(ctx, graph.Node{ID: "a"})
+		pg.AddNode(ctx, graph.Node{ID: "b"})
+		pg.AddEdge(ctx, graph.Edge{From: "a", To: "b", Label: "links"})
+
+		// Wait for a checkpoint to land
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			stats, _ := backend.GetStats()
+			if stats.NodeCount == 2 && stats.EdgeCount == 1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected auto-save to persist the graph, got %+v", stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// Changes after the last checkpoint are written on close
+		pg.DeleteNode(ctx, "b")
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Failed to close graph: %v", err)
+		}
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Expected a second close to do nothing, got %v", err)
+		}
+
+		backend = newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to reopen database: %v", err)
+		}
+		defer backend.Close()
+
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		nodes, _ := loadedGraph.GetAllNodes(ctx)
+		edges, _ := loadedGraph.GetAllEdges(ctx)
+		if len(nodes) != 1 || len(edges) != 0 {
+			t.Fatalf("Expected 1 node and 0 edges after final save, got %d and %d", len(nodes), len(edges))
+		}
+	})
+}
+
+func TestPersistentGraph_ApplyBatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		ops := []graph.BatchOp{
+			{Op: graph.OpAddNode, ID: "a"},
+			{Op: graph.OpAddNode, ID: "b"},
+			{Op: graph.OpAddNode, ID: "c"},
+			{Op: graph.OpAddEdge, From: "a", To: "b", Label: "links"},
+			{Op: graph.OpAddEdge, From: "b", To: "c", Label: "links"},
+		}
+		if _, err := pg.ApplyBatch(ctx, ops, nil); err != nil {
+			t.Fatalf("Failed to apply batch: %v", err)
+		}
+
+		// A failing batch writes nothing
+		failing := []graph.BatchOp{
+			{Op: graph.OpAddNode, ID: "d"},
+			{Op: graph.OpAddEdge, From: "d", To: "missing", Label: "links"},
+		}
+		if _, err := pg.ApplyBatch(ctx, failing, nil); err == nil {
+			t.Fatalf("Expected batch to fail")
+		}
+
+		// Deleting a node removes its connected edges from storage too
+		if err := pg.DeleteNode(ctx, "b"); err != nil {
+			t.Fatalf("Failed to delete node: %v", err)
+		}
+
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Failed to close graph: %v", err)
+		}
+
+		backend = newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to reopen database: %v", err)
+		}
+		defer backend.Close()
+
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		nodes, _ := loadedGraph.GetAllNodes(ctx)
+		edges, _ := loadedGraph.GetAllEdges(ctx)
+		if len(nodes) != 2 || len(edges) != 0 {
+			t.Fatalf("Expected 2 nodes and 0 edges, got %v and %v", nodes, edges)
+		}
+	})
+}
+
+func TestPersistentGraph_Update(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		tempDir := t.TempDir()
+		dbPath := filepath.Join(tempDir, "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		pg.AddNode(ctx, graph.Node{ID: "a", Type: "file", Props: graph.StringProps(map[string]string{"lang": "go"})})
+		pg.AddNode(ctx, graph.Node{ID: "b"})
+		pg.AddEdge(ctx, graph.Edge{From: "a", To: "b", Label: "imports"})
+
+		if err := pg.UpdateNode(ctx, "a", graph.Update{Props: graph.StringProps(map[string]string{"lines": "10"})}); err != nil {
+			t.Fatalf("Failed to update node: %v", err)
+		}
+		if err := pg.UpdateEdge(ctx, "a", "b", "imports", graph.Update{Props: graph.StringProps(map[string]string{"alias": "x"})}); err != nil {
+			t.Fatalf("Failed to update edge: %v", err)
+		}
+
+		if err := pg.Close(); err != nil {
+			t.Fatalf("Failed to close graph: %v", err)
+		}
+
+		backend = newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to reopen database: %v", err)
+		}
+		defer backend.Close()
+
+		loadedGraph, err := backend.LoadGraph(ctx)
+		if err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+
+		node, err := loadedGraph.GetNode(ctx, "a")
+		if err != nil || node.Props["lang"].String() != "go" || node.Props["lines"].String() != "10" {
+			t.Fatalf("Expected persisted merged node, got %+v (%v)", node, err)
+		}
+
+		edge, err := loadedGraph.GetEdge(ctx, "a", "b", "imports")
+		if err != nil || edge.Props["alias"].String() != "x" {
+			t.Fatalf("Expected persisted updated edge, got %+v (%v)", edge, err)
+		}
+	})
+}
+
+func TestPersistentGraph_History(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		for _, autoSave := range []bool{false, true} {
+			name := "write-through"
+			if autoSave {
+				name = "autosave"
+			}
+
+			t.Run(name, func(t *testing.T) {
+				dbPath := filepath.Join(t.TempDir(), "test.db")
+				ctx := graph.WithActor(context.Background(), "agent-1")
+				base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+				backend := newBackend()
+				if err := backend.Open(dbPath); err != nil {
+					t.Fatalf("Failed to open database: %v", err)
+				}
+
+				pg := NewPersistentGraph(backend, autoSave, time.Hour)
+				if err := pg.Load(ctx); err != nil {
+					t.Fatalf("Failed to load graph: %v", err)
+				}
+
+				minute := 0
+				pg.now = func() time.Time { return base.Add(time.Duration(minute) * time.Minute) }
+
+				minute = 1
+				pg.AddNode(ctx, graph.Node{ID: "a", Type: "file", Props: graph.StringProps(map[string]string{"status": "draft"})})
+				pg.AddNode(ctx, graph.Node{ID: "b"})
+				pg.AddEdge(ctx, graph.Edge{From: "a", To: "b", Label: "imports"})
+				minute = 2
+				pg.UpdateNode(ctx, "a", graph.Update{Props: graph.StringProps(map[string]string{"status": "final"})})
+				minute = 3
+				pg.DeleteEdge(ctx, "a", "b", "imports")
+
+				if err := pg.Close(); err != nil {
+					t.Fatalf("Failed to close graph: %v", err)
+				}
+
+				// History survives a reload
+				backend = newBackend()
+				if err := backend.Open(dbPath); err != nil {
+					t.Fatalf("Failed to reopen database: %v", err)
+				}
+				pg = NewPersistentGraph(backend, false, 0)
+				if err := pg.Load(ctx); err != nil {
+					t.Fatalf("Failed to reload graph: %v", err)
+				}
+				defer pg.Close()
+
+				versions, _ := pg.NodeHistory(ctx, "a")
+				if len(versions) != 2 || versions[1].Action != graph.ActionUpdated || versions[1].Actor != "agent-1" {
+					t.Fatalf("Expected a to be created and updated by agent-1, got %+v", versions)
+				}
+				if versions[1].PrevNode == nil || versions[1].PrevNode.Props["status"].String() != "draft" {
+					t.Fatalf("Expected the update to record the draft state, got %+v", versions[1].PrevNode)
+				}
+
+				asOf := base.Add(90 * time.Second)
+				changes, _ := pg.Changes(ctx, asOf)
+				if len(changes) != 2 || changes[0].Action != graph.ActionUpdated || changes[1].Action != graph.ActionDeleted {
+					t.Fatalf("Expected the update and edge deletion since %v, got %+v", asOf, changes)
+				}
+
+				result, err := pg.Query(ctx, graph.Query{Type: "neighbors", Node: "a", Direction: "out", AsOf: &asOf})
+				if err != nil {
+					t.Fatalf("Failed to query as of %v: %v", asOf, err)
+				}
+				if len(result.Nodes) != 1 || result.Nodes[0].ID != "b" {
+					t.Fatalf("Expected b as a past neighbor, got %v", result.Nodes)
+				}
+
+				result, err = pg.Query(ctx, graph.Query{Type: "find", Filters: map[string]string{"status": "draft"}, AsOf: &asOf})
+				if err != nil || len(result.Nodes) != 1 {
+					t.Fatalf("Expected a to be draft as of %v, got %v (%v)", asOf, result, err)
+				}
+
+				result, err = pg.Query(ctx, graph.Query{Type: "neighbors", Node: "a", Direction: "out"})
+				if err != nil || len(result.Nodes) != 0 {
+					t.Fatalf("Expected no current neighbors, got %v (%v)", result, err)
+				}
+			})
+		}
+	})
+}
+
+func TestPersistentGraph_Watch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, newBackend func() testBackend) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+		ctx := context.Background()
+
+		backend := newBackend()
+		if err := backend.Open(dbPath); err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+
+		pg := NewPersistentGraph(backend, false, 0)
+		if err := pg.Load(ctx); err != nil {
+			t.Fatalf("Failed to load graph: %v", err)
+		}
+		defer pg.Close()
+
+		var published [][]graph.Change
+		cancel := pg.Watch(func(changes []graph.Change) {
+			published = append(published, changes)
+		})
+
+		pg.AddNode(ctx, graph.Node{ID: "a"})
+		pg.AddNode(ctx, graph.Node{ID: "b"})
+		pg.AddEdge(ctx, graph.Edge{From: "a", To: "b", Label: "links"})
+
+		// Failed mutations publish nothing
+		pg.AddNode(ctx, graph.Node{ID: "a"})
+		pg.ApplyBatch(ctx, []graph.BatchOp{{Op: graph.OpAddNode, ID: "c"}, {Op: graph.OpDeleteNode, ID: "missing"}}, nil)
+
+		// A node delete is published together with its cascaded edge delete
+		pg.DeleteNode(ctx, "b")
+
+		if len(published) != 4 {
+			t.Fatalf("Expected 4 published mutations, got %d: %+v", len(published), published)
+		}
+		if last := published[3]; len(last) != 2 || !last[0].Deleted || last[0].Edge == nil || last[1].Node.ID != "b" {
+			t.Fatalf("Expected the edge and node deletes, got %+v", last)
+		}
+
+		cancel()
+		pg.AddNode(ctx, graph.Node{ID: "d"})
+		if len(published) != 4 {
+			t.Fatalf("Expected no changes after cancel, got %d mutations", len(published))
+		}
+	})
+}
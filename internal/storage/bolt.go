@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.etcd.io/bbolt"
@@ -15,7 +17,9 @@ import (
 type BoltBackend struct {
 	db         *bbolt.DB
 	serializer Serializer
-	stats      Stats
+
+	statsMu sync.Mutex // guards stats, which background saves update
+	stats   Stats
 }
 
 // BoltTransaction implements the Transaction interface for BoltDB
@@ -26,9 +30,14 @@ type BoltTransaction struct {
 }
 
 const (
-	nodesBucket = "nodes"
-	edgesBucket = "edges"
-	metaBucket  = "meta"
+	nodesBucket   = "nodes"
+	edgesBucket   = "edges"
+	metaBucket    = "meta"
+	historyBucket = "history"
+
+	// Meta keys
+	propertyIndexesKey = "property_indexes"
+	schemaKey          = "schema"
 )
 
 // NewBoltBackend creates a new BoltDB backend
@@ -59,6 +68,9 @@ func (b *BoltBackend) Open(path string) error {
 		if _, err := tx.CreateBucketIfNotExists([]byte(metaBucket)); err != nil {
 			return err
 		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(historyBucket)); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -89,7 +101,26 @@ func (b *BoltBackend) LoadGraph(ctx context.Context) (graph.Graph, error) {
 
 	memGraph := graph.NewMemoryGraph()
 
-	err := b.db.View(func(tx *bbolt.Tx) error {
+	// Declare property indexes before loading so they are populated as nodes arrive
+	indexes, err := LoadPropertyIndexes(b)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range indexes {
+		if err := memGraph.CreatePropertyIndex(key); err != nil {
+			return nil, fmt.Errorf("failed to create property index %s: %w", key, err)
+		}
+	}
+
+	schema, err := LoadSchema(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := memGraph.SetSchema(schema); err != nil {
+		return nil, fmt.Errorf("failed to set schema: %w", err)
+	}
+
+	err = b.db.View(func(tx *bbolt.Tx) error {
 		// Load nodes
 		nodesBucket := tx.Bucket([]byte(nodesBucket))
 		if nodesBucket != nil {
@@ -137,31 +168,90 @@ func (b *BoltBackend) LoadGraph(ctx context.Context) (graph.Graph, error) {
 		return nil, fmt.Errorf("failed to load graph: %w", err)
 	}
 
+	b.statsMu.Lock()
 	b.stats.LastLoaded = time.Now().Unix()
+	b.statsMu.Unlock()
+
 	return memGraph, nil
 }
 
-// SaveGraph saves the entire graph to BoltDB
+// SaveGraph writes a full snapshot of the graph to BoltDB, replacing the
+// nodes and edges buckets in a single transaction so a failed save leaves the
+// previous snapshot intact. Metadata such as index declarations and version
+// history are preserved.
 func (b *BoltBackend) SaveGraph(ctx context.Context, g graph.Graph) error {
 	if b.db == nil {
 		return fmt.Errorf("database not opened")
 	}
 
-	// This is a simplified implementation - in production you'd want
-	// to iterate over the graph more efficiently
-	tx, err := b.BeginTransaction()
+	nodes, err := g.GetAllNodes(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to get nodes: %w", err)
 	}
-	defer tx.Rollback()
 
-	// TODO: Implement full graph iteration and saving
-	// This requires extending the Graph interface to provide:
-	// - GetAllNodes() method
-	// - GetAllEdges() method
-	// For now, individual operations are persisted immediately via transactions
+	edges, err := g.GetAllEdges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get edges: %w", err)
+	}
 
-	return fmt.Errorf("SaveGraph not fully implemented - requires Graph interface extension for iteration")
+	err = b.db.Update(func(tx *bbolt.Tx) error {
+		nodeBucket, err := recreateBucket(tx, nodesBucket)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			data, err := b.serializer.SerializeNode(node)
+			if err != nil {
+				return fmt.Errorf("failed to serialize node %s: %w", node.ID, err)
+			}
+			if err := nodeBucket.Put([]byte(node.ID), data); err != nil {
+				return err
+			}
+		}
+
+		edgeBucket, err := recreateBucket(tx, edgesBucket)
+		if err != nil {
+			return err
+		}
+		for _, edge := range edges {
+			key := edgeKey(edge.From, edge.To, edge.Label)
+			data, err := b.serializer.SerializeEdge(edge)
+			if err != nil {
+				return fmt.Errorf("failed to serialize edge %s: %w", key, err)
+			}
+			if err := edgeBucket.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save graph: %w", err)
+	}
+
+	b.markSaved()
+	return nil
+}
+
+// recreateBucket drops a bucket and creates it again empty
+func recreateBucket(tx *bbolt.Tx, name string) (*bbolt.Bucket, error) {
+	if tx.Bucket([]byte(name)) != nil {
+		if err := tx.DeleteBucket([]byte(name)); err != nil {
+			return nil, fmt.Errorf("failed to clear %s bucket: %w", name, err)
+		}
+	}
+
+	bucket, err := tx.CreateBucket([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s bucket: %w", name, err)
+	}
+	return bucket, nil
+}
+
+// edgeKey builds the storage key for an edge
+func edgeKey(from, to, label string) string {
+	return fmt.Sprintf("%s:%s:%s", from, to, label)
 }
 
 // BeginTransaction starts a new transaction
@@ -219,8 +309,7 @@ func (bt *BoltTransaction) SaveEdge(edge graph.Edge) error {
 		return fmt.Errorf("failed to serialize edge: %w", err)
 	}
 
-	key := fmt.Sprintf("%s:%s:%s", edge.From, edge.To, edge.Label)
-	return bucket.Put([]byte(key), data)
+	return bucket.Put([]byte(edgeKey(edge.From, edge.To, edge.Label)), data)
 }
 
 // DeleteEdge deletes an edge in the transaction
@@ -230,16 +319,36 @@ func (bt *BoltTransaction) DeleteEdge(from, to, label string) error {
 		return fmt.Errorf("edges bucket not found")
 	}
 
-	key := fmt.Sprintf("%s:%s:%s", from, to, label)
-	return bucket.Delete([]byte(key))
+	return bucket.Delete([]byte(edgeKey(from, to, label)))
+}
+
+// SaveVersion records a version in the history bucket, keyed by sequence number
+func (bt *BoltTransaction) SaveVersion(version graph.Version) error {
+	bucket := bt.tx.Bucket([]byte(historyBucket))
+	if bucket == nil {
+		return fmt.Errorf("history bucket not found")
+	}
+
+	data, err := json.Marshal(version)
+	if err != nil {
+		return fmt.Errorf("failed to serialize version: %w", err)
+	}
+
+	return bucket.Put(historyKey(version.Seq), data)
+}
+
+// historyKey encodes a sequence number so versions sort in order
+func historyKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
 }
 
 // Commit commits the transaction
 func (bt *BoltTransaction) Commit() error {
 	err := bt.tx.Commit()
 	if err == nil {
-		bt.backend.updateStats()
-		bt.backend.stats.LastSaved = time.Now().Unix()
+		bt.backend.markSaved()
 	}
 	return err
 }
@@ -249,12 +358,130 @@ func (bt *BoltTransaction) Rollback() error {
 	return bt.tx.Rollback()
 }
 
+// GetMeta returns the value stored under key in the meta bucket, or nil if it is not set
+func (b *BoltBackend) GetMeta(key string) ([]byte, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not opened")
+	}
+
+	var value []byte
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucket))
+		if bucket == nil {
+			return fmt.Errorf("meta bucket not found")
+		}
+		if data := bucket.Get([]byte(key)); data != nil {
+			// Bolt values are only valid for the life of the transaction
+			value = append([]byte{}, data...)
+		}
+		return nil
+	})
+
+	return value, err
+}
+
+// PutMeta stores a value under key in the meta bucket
+func (b *BoltBackend) PutMeta(key string, value []byte) error {
+	if b.db == nil {
+		return fmt.Errorf("database not opened")
+	}
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucket))
+		if bucket == nil {
+			return fmt.Errorf("meta bucket not found")
+		}
+		return bucket.Put([]byte(key), value)
+	})
+}
+
+// LoadHistory returns every recorded version in sequence order
+func (b *BoltBackend) LoadHistory() ([]graph.Version, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not opened")
+	}
+
+	var versions []graph.Version
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(historyBucket))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var version graph.Version
+			if err := json.Unmarshal(v, &version); err != nil {
+				return fmt.Errorf("failed to deserialize version %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			versions = append(versions, version)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	return versions, nil
+}
+
+// bucketKeys returns the set of keys stored in a bucket
+func (b *BoltBackend) bucketKeys(name string) (map[string]bool, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not opened")
+	}
+
+	keys := make(map[string]bool)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return fmt.Errorf("%s bucket not found", name)
+		}
+		return bucket.ForEach(func(k, _ []byte) error {
+			keys[string(k)] = true
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing keys: %w", err)
+	}
+	return keys, nil
+}
+
+// nodeTypes returns the type of every stored node, by ID
+func (b *BoltBackend) nodeTypes() (map[string]string, error) {
+	if b.db == nil {
+		return nil, fmt.Errorf("database not opened")
+	}
+
+	types := make(map[string]string)
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(nodesBucket))
+		if bucket == nil {
+			return fmt.Errorf("%s bucket not found", nodesBucket)
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			node, err := b.serializer.DeserializeNode(v)
+			if err != nil {
+				return fmt.Errorf("failed to deserialize node %s: %w", k, err)
+			}
+			types[node.ID] = node.Type
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node types: %w", err)
+	}
+	return types, nil
+}
+
 // updateStats updates internal statistics
 func (b *BoltBackend) updateStats() {
 	if b.db == nil {
 		return
 	}
 
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
 	b.db.View(func(tx *bbolt.Tx) error {
 		// Get database file size (simplified approach)
 		if stat := b.db.Stats(); stat.TxStats.PageCount > 0 {
@@ -276,13 +503,29 @@ func (b *BoltBackend) updateStats() {
 	})
 }
 
-// GetStats returns storage statistics
+// markSaved refreshes statistics and records the time of a successful write
+func (b *BoltBackend) markSaved() {
+	b.updateStats()
+
+	b.statsMu.Lock()
+	b.stats.LastSaved = time.Now().Unix()
+	b.statsMu.Unlock()
+}
+
+// GetStats returns a snapshot of storage statistics
 func (b *BoltBackend) GetStats() (*Stats, error) {
 	b.updateStats()
-	return &b.stats, nil
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	stats := b.stats
+	return &stats, nil
 }
 
-// JSONSerializer implements JSON serialization for graph objects
+// JSONSerializer implements JSON serialization for graph objects. Property
+// values use the encoding of graph.Value; values stored before properties
+// were typed are JSON strings and read back as string values.
 type JSONSerializer struct{}
 
 // SerializeNode converts a node to JSON bytes
@@ -0,0 +1,503 @@
+package storage
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// DefaultCSVBatchSize is the number of rows written per transaction by LoadCSV
+const DefaultCSVBatchSize = 10000
+
+// maxCSVErrors bounds the validation errors kept in a CSVLoadResult
+const maxCSVErrors = 100
+
+// CSV load phases reported to progress callbacks
+const (
+	CSVPhaseValidate = "validate"
+	CSVPhaseWrite    = "write"
+)
+
+// CSVMapping names the columns that hold node and edge fields. Every other
+// column becomes a property; empty cells are skipped.
+type CSVMapping struct {
+	ID   string // node ID column (default "id")
+	Type string // node type column (default "type"; optional in the file)
+
+	From  string // edge source column (default "from")
+	To    string // edge target column (default "to")
+	Label string // edge label column (default "label")
+}
+
+// CSVLoadOptions configures LoadCSV
+type CSVLoadOptions struct {
+	Mapping   CSVMapping
+	BatchSize int  // rows per write transaction (default DefaultCSVBatchSize)
+	DryRun    bool // validate only, never write
+
+	// Progress, if set, is called after each validated or written batch
+	Progress func(CSVProgress)
+}
+
+// CSVProgress reports how far a load has got
+type CSVProgress struct {
+	Phase string // CSVPhaseValidate or CSVPhaseWrite
+	Nodes int    // nodes validated or written so far
+	Edges int    // edges validated or written so far
+}
+
+// CSVError is a validation error tied to a row of an input file
+type CSVError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e CSVError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// CSVLoadResult summarizes a CSV load
+type CSVLoadResult struct {
+	Nodes      int        // valid node rows
+	Edges      int        // valid edge rows
+	Written    bool       // whether the rows were written
+	Errors     []CSVError // the first validation errors, in input order
+	ErrorCount int        // total validation errors, including those not kept
+
+	// Schema violations of rows loaded under a warn mode schema, kept like
+	// Errors
+	Warnings     []CSVError
+	WarningCount int
+}
+
+// CSVInput is a named CSV stream; the name is used in error messages
+type CSVInput struct {
+	Name   string
+	Reader io.Reader
+}
+
+// LoadCSV validates node and edge CSVs against each other and the existing
+// database, including its schema, then writes them directly to the backend in
+// batched transactions. Schema violations are validation errors in strict
+// mode and warnings in warn mode.
+// Either input may be nil. Nothing is written if any row fails validation or
+// opts.DryRun is set; the result lists the problems found. A dry run may pass
+// a nil backend to validate against an empty database.
+func LoadCSV(ctx context.Context, backend *BoltBackend, nodesCSV, edgesCSV *CSVInput, opts CSVLoadOptions) (*CSVLoadResult, error) {
+	if (backend == nil && !opts.DryRun) || (backend != nil && backend.db == nil) {
+		return nil, fmt.Errorf("database not opened")
+	}
+	if nodesCSV == nil && edgesCSV == nil {
+		return nil, fmt.Errorf("at least one of the nodes and edges CSVs is required")
+	}
+
+	mapping := opts.Mapping.withDefaults()
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultCSVBatchSize
+	}
+
+	l := &csvLoader{
+		mapping:   mapping,
+		batchSize: batchSize,
+		progress:  opts.Progress,
+		nodeIDs:   map[string]bool{},
+		edgeKeys:  map[string]bool{},
+		result:    &CSVLoadResult{},
+	}
+	if backend != nil {
+		var err error
+		if l.nodeIDs, err = backend.bucketKeys(nodesBucket); err != nil {
+			return nil, err
+		}
+		if l.edgeKeys, err = backend.bucketKeys(edgesBucket); err != nil {
+			return nil, err
+		}
+		if l.schema, err = LoadSchema(backend); err != nil {
+			return nil, err
+		}
+		if l.schema != nil {
+			if l.nodeTypes, err = backend.nodeTypes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if nodesCSV != nil {
+		if err := l.readNodes(ctx, nodesCSV); err != nil {
+			return nil, err
+		}
+	}
+	if edgesCSV != nil {
+		if err := l.readEdges(ctx, edgesCSV); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.DryRun || l.result.ErrorCount > 0 {
+		return l.result, nil
+	}
+
+	if err := l.write(ctx, backend); err != nil {
+		return l.result, err
+	}
+	l.result.Written = true
+	return l.result, nil
+}
+
+// withDefaults fills in the default column names
+func (m CSVMapping) withDefaults() CSVMapping {
+	if m.ID == "" {
+		m.ID = "id"
+	}
+	if m.Type == "" {
+		m.Type = "type"
+	}
+	if m.From == "" {
+		m.From = "from"
+	}
+	if m.To == "" {
+		m.To = "to"
+	}
+	if m.Label == "" {
+		m.Label = "label"
+	}
+	return m
+}
+
+// csvLoader holds the rows staged for writing and the keys seen so far
+type csvLoader struct {
+	mapping   CSVMapping
+	batchSize int
+	progress  func(CSVProgress)
+
+	nodeIDs  map[string]bool // existing plus staged node IDs
+	edgeKeys map[string]bool // existing plus staged edge keys
+
+	schema    *graph.Schema     // the database's schema, if any
+	nodeTypes map[string]string // existing plus staged node types, kept under a schema
+
+	nodes    []graph.Node
+	edges    []graph.Edge
+	result   *CSVLoadResult
+	reported CSVProgress // last progress sent, to avoid repeats
+}
+
+// csvTable reads CSV rows and maps their cells to fields and properties
+type csvTable struct {
+	name    string
+	reader  *csv.Reader
+	columns []string
+	index   map[string]int
+}
+
+// openCSV reads the header row and checks that the required columns exist
+func openCSV(input *CSVInput, required ...string) (*csvTable, error) {
+	reader := csv.NewReader(input.Reader)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: missing header row", input.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read header: %w", input.Name, err)
+	}
+
+	t := &csvTable{
+		name:    input.Name,
+		reader:  reader,
+		columns: append([]string(nil), header...),
+		index:   make(map[string]int, len(header)),
+	}
+	for i, column := range t.columns {
+		if _, dup := t.index[column]; dup {
+			return nil, fmt.Errorf("%s: duplicate column %q", input.Name, column)
+		}
+		t.index[column] = i
+	}
+	for _, column := range required {
+		if _, ok := t.index[column]; !ok {
+			return nil, fmt.Errorf("%s: missing required column %q", input.Name, column)
+		}
+	}
+	return t, nil
+}
+
+// next returns the next record and its line number, or io.EOF. Rows with the
+// wrong number of fields are reported through rowErr so loading can continue.
+func (t *csvTable) next() (record []string, line int, rowErr error, err error) {
+	record, err = t.reader.Read()
+	if err == io.EOF {
+		return nil, 0, nil, io.EOF
+	}
+
+	line, _ = t.reader.FieldPos(0)
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+		return nil, parseErr.StartLine, fmt.Errorf("expected %d fields, got %d", len(t.columns), len(record)), nil
+	}
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%s: %w", t.name, err)
+	}
+	return record, line, nil, nil
+}
+
+// props collects every non-empty cell outside the mapped columns
+func (t *csvTable) props(record []string, mapped ...string) map[string]graph.Value {
+	var props map[string]graph.Value
+	for i, column := range t.columns {
+		if record[i] == "" || containsString(mapped, column) {
+			continue
+		}
+		if props == nil {
+			props = make(map[string]graph.Value)
+		}
+		props[column] = graph.StringValue(record[i])
+	}
+	return props
+}
+
+// cell returns the value of a column, or "" if the file doesn't have it
+func (t *csvTable) cell(record []string, column string) string {
+	if i, ok := t.index[column]; ok {
+		return record[i]
+	}
+	return ""
+}
+
+// readNodes validates and stages node rows
+func (l *csvLoader) readNodes(ctx context.Context, input *CSVInput) error {
+	t, err := openCSV(input, l.mapping.ID)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, line, rowErr, err := t.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if rowErr != nil {
+			l.addError(t.name, line, rowErr.Error())
+			continue
+		}
+
+		node := graph.Node{
+			ID:    t.cell(record, l.mapping.ID),
+			Type:  t.cell(record, l.mapping.Type),
+			Props: t.props(record, l.mapping.ID, l.mapping.Type),
+		}
+
+		if err := node.Validate(); err != nil {
+			l.addError(t.name, line, err.Error())
+			continue
+		}
+		if l.nodeIDs[node.ID] {
+			l.addError(t.name, line, fmt.Sprintf("duplicate node ID %q", node.ID))
+			continue
+		}
+
+		l.nodeIDs[node.ID] = true
+		if l.schema != nil {
+			// Edges are checked against the node's type even if it is rejected
+			l.nodeTypes[node.ID] = node.Type
+			if !l.checkSchema(t.name, line, l.schema.CheckNode(node)) {
+				continue
+			}
+		}
+		l.nodes = append(l.nodes, node)
+		l.result.Nodes++
+		if l.result.Nodes%l.batchSize == 0 {
+			l.report(CSVPhaseValidate, l.result.Nodes, 0)
+		}
+	}
+
+	l.report(CSVPhaseValidate, l.result.Nodes, 0)
+	return nil
+}
+
+// readEdges validates and stages edge rows. Endpoints must be existing nodes
+// or nodes from the nodes CSV.
+func (l *csvLoader) readEdges(ctx context.Context, input *CSVInput) error {
+	t, err := openCSV(input, l.mapping.From, l.mapping.To, l.mapping.Label)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		record, line, rowErr, err := t.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if rowErr != nil {
+			l.addError(t.name, line, rowErr.Error())
+			continue
+		}
+
+		edge := graph.Edge{
+			From:  t.cell(record, l.mapping.From),
+			To:    t.cell(record, l.mapping.To),
+			Label: t.cell(record, l.mapping.Label),
+			Props: t.props(record, l.mapping.From, l.mapping.To, l.mapping.Label),
+		}
+
+		if err := edge.Validate(); err != nil {
+			l.addError(t.name, line, err.Error())
+			continue
+		}
+
+		valid := true
+		for _, endpoint := range []string{edge.From, edge.To} {
+			if !l.nodeIDs[endpoint] {
+				l.addError(t.name, line, fmt.Sprintf("missing endpoint node %q", endpoint))
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+
+		key := edgeKey(edge.From, edge.To, edge.Label)
+		if l.edgeKeys[key] {
+			l.addError(t.name, line, fmt.Sprintf("duplicate edge %s -[%s]-> %s", edge.From, edge.Label, edge.To))
+			continue
+		}
+
+		l.edgeKeys[key] = true
+		if l.schema != nil && !l.checkSchema(t.name, line, l.schema.CheckEdge(edge, l.nodeTypes[edge.From], l.nodeTypes[edge.To])) {
+			continue
+		}
+		l.edges = append(l.edges, edge)
+		l.result.Edges++
+		if l.result.Edges%l.batchSize == 0 {
+			l.report(CSVPhaseValidate, l.result.Nodes, l.result.Edges)
+		}
+	}
+
+	l.report(CSVPhaseValidate, l.result.Nodes, l.result.Edges)
+	return nil
+}
+
+// write saves the staged rows, committing a transaction every batchSize rows.
+// Nodes are written before edges so an interrupted load never leaves edges
+// pointing at unsaved nodes.
+func (l *csvLoader) write(ctx context.Context, backend *BoltBackend) error {
+	var (
+		tx      Transaction
+		pending int
+		nodes   int
+		edges   int
+	)
+
+	commit := func() error {
+		if tx == nil {
+			return nil
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit batch: %w", err)
+		}
+		tx, pending = nil, 0
+		l.report(CSVPhaseWrite, nodes, edges)
+		return nil
+	}
+
+	save := func(fn func(Transaction) error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if tx == nil {
+			var err error
+			if tx, err = backend.BeginTransaction(); err != nil {
+				return err
+			}
+		}
+		if err := fn(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if pending++; pending >= l.batchSize {
+			return commit()
+		}
+		return nil
+	}
+
+	for _, node := range l.nodes {
+		nodes++
+		if err := save(func(tx Transaction) error { return tx.SaveNode(node) }); err != nil {
+			return fmt.Errorf("failed to write node %s: %w", node.ID, err)
+		}
+	}
+	for _, edge := range l.edges {
+		edges++
+		if err := save(func(tx Transaction) error { return tx.SaveEdge(edge) }); err != nil {
+			return fmt.Errorf("failed to write edge %s: %w", edgeKey(edge.From, edge.To, edge.Label), err)
+		}
+	}
+
+	return commit()
+}
+
+// addError records a validation error, keeping only the first maxCSVErrors
+func (l *csvLoader) addError(file string, line int, message string) {
+	l.result.ErrorCount++
+	if len(l.result.Errors) < maxCSVErrors {
+		l.result.Errors = append(l.result.Errors, CSVError{File: file, Line: line, Message: message})
+	}
+}
+
+// checkSchema records a row's schema violations, as errors in strict mode
+// and warnings in warn mode, and reports whether the row may be written
+func (l *csvLoader) checkSchema(file string, line int, violations []string) bool {
+	strict := l.schema.Strict()
+	for _, violation := range violations {
+		if strict {
+			l.addError(file, line, violation)
+			continue
+		}
+		l.result.WarningCount++
+		if len(l.result.Warnings) < maxCSVErrors {
+			l.result.Warnings = append(l.result.Warnings, CSVError{File: file, Line: line, Message: violation})
+		}
+	}
+	return len(violations) == 0 || !strict
+}
+
+// report forwards progress to the caller's callback, if any
+func (l *csvLoader) report(phase string, nodes, edges int) {
+	p := CSVProgress{Phase: phase, Nodes: nodes, Edges: edges}
+	if l.progress == nil || p == l.reported {
+		return
+	}
+	l.reported = p
+	l.progress(p)
+}
+
+// containsString reports whether slice holds s
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
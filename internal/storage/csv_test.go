@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+func openTestBackend(t *testing.T) *BoltBackend {
+	t.Helper()
+
+	backend := NewBoltBackend()
+	if err := backend.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { backend.Close() })
+	return backend
+}
+
+func csvInput(name, data string) *CSVInput {
+	return &CSVInput{Name: name, Reader: strings.NewReader(data)}
+}
+
+func TestLoadCSV(t *testing.T) {
+	ctx := context.Background()
+	backend := openTestBackend(t)
+
+	nodes := "name,kind,owner,tier\n" +
+		"auth,service,team-a,1\n" +
+		"billing,service,team-b,\n" +
+		"db,,team-a,0\n"
+	edges := "src,dst,rel,weight\n" +
+		"billing,auth,calls,3\n" +
+		"auth,db,reads,\n"
+
+	var progress []CSVProgress
+	result, err := LoadCSV(ctx, backend, csvInput("nodes.csv", nodes), csvInput("edges.csv", edges), CSVLoadOptions{
+		Mapping:   CSVMapping{ID: "name", Type: "kind", From: "src", To: "dst", Label: "rel"},
+		BatchSize: 2,
+		Progress:  func(p CSVProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatalf("LoadCSV failed: %v", err)
+	}
+	if result.ErrorCount != 0 || !result.Written || result.Nodes != 3 || result.Edges != 2 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	last := progress[len(progress)-1]
+	if last.Phase != CSVPhaseWrite || last.Nodes != 3 || last.Edges != 2 {
+		t.Errorf("Expected final write progress of 3 nodes and 2 edges, got %+v", last)
+	}
+
+	g, err := backend.LoadGraph(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load graph: %v", err)
+	}
+
+	auth, err := g.GetNode(ctx, "auth")
+	if err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	if auth.Type != "service" || auth.Props["owner"].String() != "team-a" || auth.Props["tier"].String() != "1" {
+		t.Errorf("Unexpected node: %+v", auth)
+	}
+
+	billing, _ := g.GetNode(ctx, "billing")
+	if _, ok := billing.Props["tier"]; ok {
+		t.Errorf("Expected empty cell to be skipped, got %v", billing.Props)
+	}
+
+	calls, err := g.GetEdge(ctx, "billing", "auth", "calls")
+	if err != nil {
+		t.Fatalf("Failed to get edge: %v", err)
+	}
+	if calls.Props["weight"].String() != "3" {
+		t.Errorf("Unexpected edge props: %v", calls.Props)
+	}
+
+	// Edges may reference nodes that are already in the database
+	more := "src,dst,rel\nbilling,db,reads\n"
+	result, err = LoadCSV(ctx, backend, nil, csvInput("more.csv", more), CSVLoadOptions{
+		Mapping: CSVMapping{From: "src", To: "dst", Label: "rel"},
+	})
+	if err != nil || result.ErrorCount != 0 || !result.Written {
+		t.Fatalf("Expected edges-only load to succeed, got %+v, %v", result, err)
+	}
+}
+
+func TestLoadCSV_Validation(t *testing.T) {
+	ctx := context.Background()
+	backend := openTestBackend(t)
+
+	if _, err := LoadCSV(ctx, backend, csvInput("seed.csv", "id\nexisting\n"), nil, CSVLoadOptions{}); err != nil {
+		t.Fatalf("Failed to seed database: %v", err)
+	}
+
+	nodes := "id,type\n" +
+		"a,x\n" +
+		"a,x\n" +
+		"existing,x\n" +
+		",x\n" +
+		"b\n"
+	edges := "from,to,label\n" +
+		"a,ghost,links\n" +
+		"a,existing,links\n" +
+		"a,existing,links\n" +
+		"a,existing,\n"
+
+	for _, dryRun := range []bool{true, false} {
+		result, err := LoadCSV(ctx, backend, csvInput("nodes.csv", nodes), csvInput("edges.csv", edges), CSVLoadOptions{DryRun: dryRun})
+		if err != nil {
+			t.Fatalf("LoadCSV failed: %v", err)
+		}
+		if result.Written {
+			t.Errorf("Expected nothing written with validation errors (dry run %v)", dryRun)
+		}
+
+		want := []string{
+			`nodes.csv:3: duplicate node ID "a"`,
+			`nodes.csv:4: duplicate node ID "existing"`,
+			`nodes.csv:5: node ID cannot be empty`,
+			`nodes.csv:6: expected 2 fields, got 1`,
+			`edges.csv:2: missing endpoint node "ghost"`,
+			`edges.csv:4: duplicate edge a -[links]-> existing`,
+			`edges.csv:5: edge label cannot be empty`,
+		}
+		if result.ErrorCount != len(want) {
+			t.Fatalf("Expected %d errors, got %d: %v", len(want), result.ErrorCount, result.Errors)
+		}
+		for i, e := range result.Errors {
+			if e.Error() != want[i] {
+				t.Errorf("Error %d: expected %s, got %s", i, want[i], e.Error())
+			}
+		}
+	}
+
+	g, err := backend.LoadGraph(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load graph: %v", err)
+	}
+	if all, _ := g.GetAllNodes(ctx); len(all) != 1 {
+		t.Errorf("Expected only the seeded node, got %d nodes", len(all))
+	}
+
+	// A valid dry run reports counts without writing
+	result, err := LoadCSV(ctx, backend, csvInput("nodes.csv", "id\nnew\n"), nil, CSVLoadOptions{DryRun: true})
+	if err != nil || result.Nodes != 1 || result.Written {
+		t.Errorf("Unexpected dry run result: %+v, %v", result, err)
+	}
+
+	// Without a database, a dry run validates against an empty one
+	result, err = LoadCSV(ctx, nil, csvInput("nodes.csv", "id\nexisting\n"), csvInput("edges.csv", "from,to,label\nexisting,ghost,links\n"), CSVLoadOptions{DryRun: true})
+	if err != nil || result.Nodes != 1 || result.ErrorCount != 1 || result.Written {
+		t.Errorf("Unexpected dry run result without a database: %+v, %v", result, err)
+	}
+	if _, err := LoadCSV(ctx, nil, csvInput("nodes.csv", "id\nnew\n"), nil, CSVLoadOptions{}); err == nil {
+		t.Errorf("Expected a load without a database to fail")
+	}
+
+	if _, err := LoadCSV(ctx, backend, nil, csvInput("edges.csv", "source,target\n"), CSVLoadOptions{}); err == nil || !strings.Contains(err.Error(), `missing required column "from"`) {
+		t.Errorf("Expected missing column error, got %v", err)
+	}
+}
+
+func TestLoadCSV_Schema(t *testing.T) {
+	ctx := context.Background()
+	backend := openTestBackend(t)
+
+	if _, err := LoadCSV(ctx, backend, csvInput("seed.csv", "id,type\nteam:core,team\n"), nil, CSVLoadOptions{}); err != nil {
+		t.Fatalf("Failed to seed database: %v", err)
+	}
+
+	nodes := "id,type,name\n" +
+		"user:alice,user,Alice\n" +
+		"user:bob,user,\n" +
+		"robot,robot,\n"
+	edges := "from,to,label\n" +
+		"user:alice,team:core,member_of\n" +
+		"team:core,user:alice,member_of\n"
+
+	for _, mode := range []string{graph.SchemaStrict, graph.SchemaWarn} {
+		schema, err := graph.ParseSchema([]byte(`{"mode": "` + mode + `",
+			"node_types": {"user": {"properties": {"name": {"required": true}}}, "team": {}},
+			"edge_labels": {"member_of": {"connections": [{"from": "user", "to": "team"}]}}}`))
+		if err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		if err := SaveSchema(backend, schema); err != nil {
+			t.Fatalf("Failed to save schema: %v", err)
+		}
+
+		result, err := LoadCSV(ctx, backend, csvInput("nodes.csv", nodes), csvInput("edges.csv", edges), CSVLoadOptions{DryRun: true})
+		if err != nil {
+			t.Fatalf("LoadCSV failed: %v", err)
+		}
+
+		want := []string{
+			`nodes.csv:3: node "user:bob": required property name is missing`,
+			`nodes.csv:4: node "robot": type "robot" is not declared`,
+			`edges.csv:3: edge "team:core" -[member_of]-> "user:alice": label "member_of" may not connect "team" to "user"`,
+		}
+		got, count := result.Errors, result.ErrorCount
+		if mode == graph.SchemaWarn {
+			got, count = result.Warnings, result.WarningCount
+			if result.ErrorCount != 0 || result.Nodes != 3 || result.Edges != 2 {
+				t.Errorf("Expected warn mode to keep every row, got %+v", result)
+			}
+		} else if result.Nodes != 1 || result.Edges != 1 {
+			t.Errorf("Expected strict mode to reject the violating rows, got %+v", result)
+		}
+		if count != len(want) {
+			t.Fatalf("Expected %d violations in %s mode, got %d: %v", len(want), mode, count, got)
+		}
+		for i, e := range got {
+			if e.Error() != want[i] {
+				t.Errorf("Violation %d: expected %s, got %s", i, want[i], e.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// dotPalette colors node types; each type hashes to a fixed entry so it keeps
+// its color across renderings of different subgraphs
+var dotPalette = []string{
+	"#8dd3c7", "#ffffb3", "#bebada", "#fb8072", "#80b1d3", "#fdb462",
+	"#b3de69", "#fccde5", "#d9d9d9", "#bc80bd", "#ccebc5", "#ffed6f",
+}
+
+// dotUntypedColor fills nodes without a type
+const dotUntypedColor = "#ffffff"
+
+// DOTOptions selects the part of the graph a DOTExporter renders. With Node
+// set it renders the Hops neighborhood of that node; with From and To set it
+// renders every path between them; otherwise it renders the whole graph.
+type DOTOptions struct {
+	Node      string
+	Hops      int    // neighborhood radius; 0 renders the node alone
+	Direction string // edge direction for neighborhoods and paths
+
+	From     string
+	To       string
+	MaxDepth int      // path length limit (default 4)
+	Labels   []string // edge labels paths may follow (default all)
+}
+
+// DOTExporter writes graphs in Graphviz DOT format, grouping nodes into
+// clusters colored by type and labeling edges with their label
+type DOTExporter struct {
+	Options DOTOptions
+}
+
+// NewDOTExporter creates a DOT exporter for the selected part of the graph
+func NewDOTExporter(opts DOTOptions) *DOTExporter {
+	return &DOTExporter{Options: opts}
+}
+
+// Export writes the selected nodes and edges to writer as DOT
+func (e *DOTExporter) Export(ctx context.Context, g graph.Graph, writer io.Writer) error {
+	nodes, edges, err := SelectSubgraph(ctx, g, e.Options)
+	if err != nil {
+		return err
+	}
+	return WriteDOT(writer, nodes, edges)
+}
+
+// SelectSubgraph returns the nodes and edges chosen by opts
+func SelectSubgraph(ctx context.Context, g graph.Graph, opts DOTOptions) ([]graph.Node, []graph.Edge, error) {
+	switch {
+	case opts.Node != "" && (opts.From != "" || opts.To != ""):
+		return nil, nil, fmt.Errorf("%w: use either a node neighborhood or a from/to path, not both", graph.ErrInvalidQuery)
+
+	case opts.Node != "":
+		nodes, edges, err := graph.Neighborhood(ctx, g, opts.Node, opts.Hops, opts.Direction)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to collect neighborhood of %s: %w", opts.Node, err)
+		}
+		return nodes, edges, nil
+
+	case opts.From != "" || opts.To != "":
+		result, err := g.Query(ctx, graph.Query{
+			Type:      "paths",
+			From:      opts.From,
+			To:        opts.To,
+			MaxDepth:  opts.MaxDepth,
+			Direction: opts.Direction,
+			Labels:    opts.Labels,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes, edges := mergePaths(result.Paths)
+		return nodes, edges, nil
+
+	default:
+		nodes, err := g.GetAllNodes(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get nodes: %w", err)
+		}
+		edges, err := g.GetAllEdges(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get edges: %w", err)
+		}
+		return nodes, edges, nil
+	}
+}
+
+// mergePaths collects the distinct nodes and edges of a set of paths
+func mergePaths(paths []graph.Path) ([]graph.Node, []graph.Edge) {
+	var (
+		nodes     []graph.Node
+		edges     []graph.Edge
+		seenNodes = make(map[string]bool)
+		seenEdges = make(map[string]bool)
+	)
+
+	for _, path := range paths {
+		for _, node := range path.Nodes {
+			if !seenNodes[node.ID] {
+				seenNodes[node.ID] = true
+				nodes = append(nodes, node)
+			}
+		}
+		for _, edge := range path.Edges {
+			key := edgeKey(edge.From, edge.To, edge.Label)
+			if !seenEdges[key] {
+				seenEdges[key] = true
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	return nodes, edges
+}
+
+// WriteDOT renders nodes and edges as a DOT digraph. Output is sorted so the
+// same subgraph always renders identically.
+func WriteDOT(writer io.Writer, nodes []graph.Node, edges []graph.Edge) error {
+	nodes = append([]graph.Node(nil), nodes...)
+	edges = append([]graph.Edge(nil), edges...)
+	SortNodes(nodes)
+	SortEdges(edges)
+
+	byType := make(map[string][]graph.Node)
+	for _, node := range nodes {
+		byType[node.Type] = append(byType[node.Type], node)
+	}
+
+	var types []string
+	for t := range byType {
+		if t != "" {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, "digraph relatixdb {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [shape=box, style="rounded,filled", fontname="Helvetica"];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=10];`)
+
+	for _, t := range types {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "  subgraph %s {\n", dotQuote("cluster_"+t))
+		fmt.Fprintf(w, "    label=%s;\n", dotQuote(t))
+		fmt.Fprintln(w, `    style=dashed; color="#999999";`)
+		for _, node := range byType[t] {
+			writeDOTNode(w, "    ", node, dotTypeColor(t))
+		}
+		fmt.Fprintln(w, "  }")
+	}
+
+	if untyped := byType[""]; len(untyped) > 0 {
+		fmt.Fprintln(w)
+		for _, node := range untyped {
+			writeDOTNode(w, "  ", node, dotUntypedColor)
+		}
+	}
+
+	if len(edges) > 0 {
+		fmt.Fprintln(w)
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(w, "  %s -> %s [label=%s", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Label))
+		if len(edge.Props) > 0 {
+			fmt.Fprintf(w, ", tooltip=%s", dotQuote(dotTooltip(edge.Props)))
+		}
+		fmt.Fprintln(w, "];")
+	}
+
+	fmt.Fprintln(w, "}")
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write DOT: %w", err)
+	}
+	return nil
+}
+
+// writeDOTNode writes a node statement; properties go into the tooltip so
+// SVG renderings show them on hover without cluttering the layout
+func writeDOTNode(w io.Writer, indent string, node graph.Node, color string) {
+	fmt.Fprintf(w, "%s%s [fillcolor=%s", indent, dotQuote(node.ID), dotQuote(color))
+	if len(node.Props) > 0 {
+		fmt.Fprintf(w, ", tooltip=%s", dotQuote(dotTooltip(node.Props)))
+	}
+	fmt.Fprintln(w, "];")
+}
+
+// dotTypeColor picks the palette color for a node type
+func dotTypeColor(nodeType string) string {
+	h := fnv.New32a()
+	h.Write([]byte(nodeType))
+	return dotPalette[h.Sum32()%uint32(len(dotPalette))]
+}
+
+// dotTooltip formats properties one "key: value" per line in key order
+func dotTooltip(props map[string]graph.Value) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, k := range keys {
+		lines[i] = k + ": " + props[k].String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dotQuote renders s as a DOT double-quoted string
+func dotQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			// Dropped; line breaks are carried by \n
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDOTExporter_WholeGraph(t *testing.T) {
+	ctx := context.Background()
+	g := exportTestGraph(t)
+
+	var buf bytes.Buffer
+	if err := NewDOTExporter(DOTOptions{}).Export(ctx, g, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"digraph relatixdb {",
+		`subgraph "cluster_user" {`,
+		`label="user";`,
+		`"user:1" [fillcolor="` + dotTypeColor("user") + `", tooltip="active: true\nage: 31\nname: Alice & co\ntype: admin"];`,
+		`"doc:1" [fillcolor="#ffffff"];`,
+		`"user:1" -> "user:2" [label="follows", tooltip="weight: 0.5"];`,
+		`"user:1" -> "doc:1" [label="wrote"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, out)
+		}
+	}
+
+	if !strings.HasSuffix(out, "}\n") {
+		t.Errorf("Expected closing brace, got:\n%s", out)
+	}
+}
+
+func TestDOTExporter_Subgraphs(t *testing.T) {
+	ctx := context.Background()
+	g := exportTestGraph(t)
+
+	render := func(opts DOTOptions) string {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := NewDOTExporter(opts).Export(ctx, g, &buf); err != nil {
+			t.Fatalf("Failed to export %+v: %v", opts, err)
+		}
+		return buf.String()
+	}
+
+	// The neighborhood of doc:1 within one hop excludes user:2
+	out := render(DOTOptions{Node: "doc:1", Hops: 1})
+	if !strings.Contains(out, `"user:1" -> "doc:1"`) || strings.Contains(out, "user:2") {
+		t.Errorf("Unexpected neighborhood rendering:\n%s", out)
+	}
+
+	// The path from user:1 to user:2 excludes doc:1
+	out = render(DOTOptions{From: "user:1", To: "user:2"})
+	if !strings.Contains(out, `"user:1" -> "user:2"`) || strings.Contains(out, "doc:1") {
+		t.Errorf("Unexpected path rendering:\n%s", out)
+	}
+
+	if err := NewDOTExporter(DOTOptions{Node: "doc:1", From: "user:1", To: "user:2"}).Export(ctx, g, &bytes.Buffer{}); err == nil {
+		t.Error("Expected error when combining node and path selection")
+	}
+}
+
+func TestDOTQuote(t *testing.T) {
+	tests := map[string]string{
+		`plain`:          `"plain"`,
+		`say "hi"`:       `"say \"hi\""`,
+		`C:\path`:        `"C:\\path"`,
+		"line1\r\nline2": `"line1\nline2"`,
+	}
+	for input, want := range tests {
+		if got := dotQuote(input); got != want {
+			t.Errorf("dotQuote(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// Export formats
+const (
+	FormatJSONL   = "jsonl"
+	FormatGraphML = "graphml"
+	FormatGEXF    = "gexf"
+	FormatDOT     = "dot"
+)
+
+// ExportFormats lists the formats accepted by NewExporter
+var ExportFormats = []string{FormatJSONL, FormatGraphML, FormatGEXF, FormatDOT}
+
+// NewExporter returns the exporter for the named format. DOT exports render
+// the whole graph; use NewDOTExporter to select a subgraph.
+func NewExporter(format string) (Exporter, error) {
+	switch strings.ToLower(format) {
+	case FormatJSONL:
+		return NewJSONLBackup(), nil
+	case FormatGraphML:
+		return NewGraphMLExporter(), nil
+	case FormatGEXF:
+		return NewGEXFExporter(), nil
+	case FormatDOT:
+		return NewDOTExporter(DOTOptions{}), nil
+	default:
+		return nil, fmt.Errorf("unknown export format '%s' (supported: %s)", format, strings.Join(ExportFormats, ", "))
+	}
+}
+
+// Attribute types shared by GraphML and GEXF
+const (
+	attrBoolean = "boolean"
+	attrLong    = "long"
+	attrDouble  = "double"
+	attrString  = "string"
+)
+
+// attribute is a typed column declared for a node or edge property
+type attribute struct {
+	ID   string
+	Name string // exported attribute name
+	Key  string // property key the values come from
+	Type string
+}
+
+// sortedSnapshot returns all nodes and edges of g in deterministic order
+func sortedSnapshot(ctx context.Context, g graph.Graph) ([]graph.Node, []graph.Edge, error) {
+	nodes, err := g.GetAllNodes(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+
+	edges, err := g.GetAllEdges(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	SortNodes(nodes)
+	SortEdges(edges)
+	return nodes, edges, nil
+}
+
+// propertyAttributes declares one typed attribute per property key found in
+// props, sorted by key. Keys that collide with a reserved attribute name are
+// exported as "prop:<key>". Each attribute's ID is prefix followed by its position.
+func propertyAttributes(prefix string, reserved []string, props []map[string]graph.Value) []attribute {
+	values := make(map[string][]graph.Value)
+	for _, p := range props {
+		for key, value := range p {
+			values[key] = append(values[key], value)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute, len(keys))
+	for i, key := range keys {
+		name := key
+		for _, r := range reserved {
+			if key == r {
+				name = "prop:" + key
+			}
+		}
+
+		attrs[i] = attribute{
+			ID:   fmt.Sprintf("%s%d", prefix, i),
+			Name: name,
+			Key:  key,
+			Type: inferAttributeType(values[key]),
+		}
+	}
+	return attrs
+}
+
+// inferAttributeType picks the narrowest type that every value parses as,
+// so numeric and boolean properties can be filtered and sized in Gephi or yEd.
+// Values are parsed from their text form, since properties written before
+// values were typed are all strings; floats are never narrowed to longs.
+func inferAttributeType(values []graph.Value) string {
+	isBool, isLong, isDouble := true, true, true
+	for _, value := range values {
+		v := value.String()
+		if value.Kind() == graph.KindFloat {
+			isLong = false
+		}
+		if v != "true" && v != "false" {
+			isBool = false
+		}
+		if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+			isLong = false
+		}
+		// Reject the special values and hex forms ParseFloat accepts but XML readers don't
+		if _, err := strconv.ParseFloat(v, 64); err != nil || strings.ContainsAny(strings.ToLower(v), "inx_p") {
+			isDouble = false
+		}
+	}
+
+	switch {
+	case len(values) == 0:
+		return attrString
+	case isBool:
+		return attrBoolean
+	case isLong:
+		return attrLong
+	case isDouble:
+		return attrDouble
+	default:
+		return attrString
+	}
+}
+
+// nodeProps and edgeProps collect property maps for attribute inference
+func nodeProps(nodes []graph.Node) []map[string]graph.Value {
+	props := make([]map[string]graph.Value, len(nodes))
+	for i, node := range nodes {
+		props[i] = node.Props
+	}
+	return props
+}
+
+func edgeProps(edges []graph.Edge) []map[string]graph.Value {
+	props := make([]map[string]graph.Value, len(edges))
+	for i, edge := range edges {
+		props[i] = edge.Props
+	}
+	return props
+}
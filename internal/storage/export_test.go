@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// exportTestGraph builds a small graph with typed-looking properties
+func exportTestGraph(t *testing.T) graph.Graph {
+	t.Helper()
+	ctx := context.Background()
+
+	g := graph.NewMemoryGraph()
+	nodes := []graph.Node{
+		{ID: "user:1", Type: "user", Props: graph.StringProps(map[string]string{"name": "Alice & co", "age": "31", "active": "true", "type": "admin"})},
+		{ID: "user:2", Type: "user", Props: graph.StringProps(map[string]string{"name": "Bob", "age": "27", "score": "4.5"})},
+		{ID: "doc:1"},
+	}
+	for _, node := range nodes {
+		if err := g.AddNode(ctx, node); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	edges := []graph.Edge{
+		{From: "user:1", To: "user:2", Label: "follows", Props: graph.StringProps(map[string]string{"weight": "0.5"})},
+		{From: "user:1", To: "doc:1", Label: "wrote"},
+	}
+	for _, edge := range edges {
+		if err := g.AddEdge(ctx, edge); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+	return g
+}
+
+func TestInferAttributeType(t *testing.T) {
+	text := func(values ...string) []graph.Value {
+		typed := make([]graph.Value, len(values))
+		for i, value := range values {
+			typed[i] = graph.StringValue(value)
+		}
+		return typed
+	}
+
+	tests := []struct {
+		values []graph.Value
+		want   string
+	}{
+		{text("true", "false"), attrBoolean},
+		{text("1", "-42"), attrLong},
+		{text("1", "2.5", "1e3"), attrDouble},
+		{text("1", "abc"), attrString},
+		{text("NaN"), attrString},
+		{text("Inf"), attrString},
+		{text("0x1p-2"), attrString},
+		{text("TRUE"), attrString},
+		{text(""), attrString},
+		{nil, attrString},
+		{[]graph.Value{graph.IntValue(1), graph.BoolValue(true)}, attrString},
+		{[]graph.Value{graph.IntValue(1), graph.StringValue("2")}, attrLong},
+		{[]graph.Value{graph.IntValue(1), graph.FloatValue(2)}, attrDouble},
+		{[]graph.Value{graph.TimeValue(time.Unix(0, 0))}, attrString},
+	}
+
+	for _, tt := range tests {
+		if got := inferAttributeType(tt.values); got != tt.want {
+			t.Errorf("inferAttributeType(%q) = %s, want %s", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestNewExporter(t *testing.T) {
+	for _, format := range ExportFormats {
+		if _, err := NewExporter(format); err != nil {
+			t.Errorf("NewExporter(%s) failed: %v", format, err)
+		}
+	}
+
+	if _, err := NewExporter("csv"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestGraphMLExporter_Export(t *testing.T) {
+	ctx := context.Background()
+	g := exportTestGraph(t)
+
+	var buf bytes.Buffer
+	if err := NewGraphMLExporter().Export(ctx, g, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	var doc graphMLDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Export is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	keys := make(map[string]graphMLKey)
+	for _, key := range doc.Keys {
+		keys[key.For+"/"+key.Name] = key
+	}
+	for name, wantType := range map[string]string{
+		"node/type":      attrString,
+		"node/prop:type": attrString,
+		"node/name":      attrString,
+		"node/age":       attrLong,
+		"node/active":    attrBoolean,
+		"node/score":     attrDouble,
+		"edge/label":     attrString,
+		"edge/weight":    attrDouble,
+	} {
+		key, ok := keys[name]
+		if !ok {
+			t.Errorf("Missing key %s", name)
+			continue
+		}
+		if key.Type != wantType {
+			t.Errorf("Key %s has type %s, want %s", name, key.Type, wantType)
+		}
+	}
+
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("Expected 3 nodes and 2 edges, got %d and %d", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("Expected directed graph, got %s", doc.Graph.EdgeDefault)
+	}
+
+	alice := doc.Graph.Nodes[1]
+	if alice.ID != "user:1" {
+		t.Fatalf("Expected nodes sorted by ID, got %s at index 1", alice.ID)
+	}
+	values := make(map[string]string)
+	for _, data := range alice.Data {
+		values[data.Key] = data.Value
+	}
+	if values[graphMLTypeKey] != "user" || values[keys["node/name"].ID] != "Alice & co" || values[keys["node/prop:type"].ID] != "admin" {
+		t.Errorf("Unexpected node data: %v", values)
+	}
+
+	edge := doc.Graph.Edges[0]
+	if edge.Source != "user:1" || edge.Target != "doc:1" || edge.Data[0].Value != "wrote" {
+		t.Errorf("Unexpected first edge: %+v", edge)
+	}
+}
+
+func TestGEXFExporter_Export(t *testing.T) {
+	ctx := context.Background()
+	g := exportTestGraph(t)
+
+	var buf bytes.Buffer
+	if err := NewGEXFExporter().Export(ctx, g, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	var doc gexfDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Export is not valid XML: %v\n%s", err, buf.String())
+	}
+
+	if doc.Version != "1.3" || doc.Graph.DefaultEdgeType != "directed" {
+		t.Errorf("Unexpected header: version %s, edge type %s", doc.Version, doc.Graph.DefaultEdgeType)
+	}
+	if len(doc.Graph.Attributes) != 2 {
+		t.Fatalf("Expected node and edge attribute classes, got %d", len(doc.Graph.Attributes))
+	}
+
+	titles := make(map[string]gexfAttribute)
+	for _, class := range doc.Graph.Attributes {
+		for _, attr := range class.Attributes {
+			titles[class.Class+"/"+attr.Title] = attr
+		}
+	}
+	if titles["node/age"].Type != attrLong || titles["node/active"].Type != attrBoolean || titles["edge/weight"].Type != attrDouble {
+		t.Errorf("Unexpected attribute types: %+v", titles)
+	}
+
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("Expected 3 nodes and 2 edges, got %d and %d", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+	if doc.Graph.Nodes[0].ID != "doc:1" || len(doc.Graph.Nodes[0].AttValues) != 0 {
+		t.Errorf("Unexpected first node: %+v", doc.Graph.Nodes[0])
+	}
+
+	follows := doc.Graph.Edges[1]
+	if follows.Label != "follows" || len(follows.AttValues) != 1 || follows.AttValues[0].Value != "0.5" {
+		t.Errorf("Unexpected follows edge: %+v", follows)
+	}
+}
+
+func TestExport_FromBoltFile(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	backend := NewBoltBackend()
+	if err := backend.Open(dbPath); err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer backend.Close()
+
+	if err := backend.SaveGraph(ctx, exportTestGraph(t)); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+
+	loaded, err := backend.LoadGraph(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load graph: %v", err)
+	}
+
+	// Exports of the live graph and the reloaded Bolt file must be identical
+	for _, format := range ExportFormats {
+		exporter, err := NewExporter(format)
+		if err != nil {
+			t.Fatalf("NewExporter(%s) failed: %v", format, err)
+		}
+
+		var live, stored bytes.Buffer
+		if err := exporter.Export(ctx, exportTestGraph(t), &live); err != nil {
+			t.Fatalf("Failed to export live graph as %s: %v", format, err)
+		}
+		if err := exporter.Export(ctx, loaded, &stored); err != nil {
+			t.Fatalf("Failed to export stored graph as %s: %v", format, err)
+		}
+		if live.String() != stored.String() {
+			t.Errorf("%s export differs between live and stored graph", format)
+		}
+		if !strings.Contains(stored.String(), "user:2") {
+			t.Errorf("%s export is missing nodes", format)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+const (
+	gexfNamespace = "http://gexf.net/1.3"
+	gexfVersion   = "1.3"
+
+	// gexfTypeAttr is the attribute ID holding node types
+	gexfTypeAttr = "type"
+)
+
+// GEXFExporter writes graphs as GEXF 1.3 for Gephi. Node types and
+// properties become typed attributes; edge labels use the native label field.
+type GEXFExporter struct{}
+
+type gexfDocument struct {
+	XMLName xml.Name  `xml:"gexf"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Meta    gexfMeta  `xml:"meta"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfMeta struct {
+	Creator string `xml:"creator"`
+}
+
+type gexfGraph struct {
+	DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+	Mode            string           `xml:"mode,attr"`
+	Attributes      []gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode       `xml:"nodes>node"`
+	Edges           []gexfEdge       `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue,omitempty"`
+}
+
+type gexfEdge struct {
+	ID        string         `xml:"id,attr"`
+	Source    string         `xml:"source,attr"`
+	Target    string         `xml:"target,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue,omitempty"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// NewGEXFExporter creates a GEXF exporter
+func NewGEXFExporter() *GEXFExporter {
+	return &GEXFExporter{}
+}
+
+// Export writes the graph to writer as GEXF
+func (e *GEXFExporter) Export(ctx context.Context, g graph.Graph, writer io.Writer) error {
+	nodes, edges, err := sortedSnapshot(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	nodeAttrs := propertyAttributes("n", []string{gexfTypeAttr}, nodeProps(nodes))
+	edgeAttrs := propertyAttributes("e", nil, edgeProps(edges))
+
+	nodeClass := gexfAttributes{Class: "node"}
+	nodeClass.Attributes = append(nodeClass.Attributes, gexfAttribute{ID: gexfTypeAttr, Title: gexfTypeAttr, Type: attrString})
+	for _, attr := range nodeAttrs {
+		nodeClass.Attributes = append(nodeClass.Attributes, gexfAttribute{ID: attr.ID, Title: attr.Name, Type: attr.Type})
+	}
+
+	edgeClass := gexfAttributes{Class: "edge"}
+	for _, attr := range edgeAttrs {
+		edgeClass.Attributes = append(edgeClass.Attributes, gexfAttribute{ID: attr.ID, Title: attr.Name, Type: attr.Type})
+	}
+
+	doc := gexfDocument{
+		XMLNS:   gexfNamespace,
+		Version: gexfVersion,
+		Meta:    gexfMeta{Creator: "RelatixDB"},
+		Graph: gexfGraph{
+			DefaultEdgeType: "directed",
+			Mode:            "static",
+			Attributes:      []gexfAttributes{nodeClass},
+			Nodes:           make([]gexfNode, len(nodes)),
+			Edges:           make([]gexfEdge, len(edges)),
+		},
+	}
+	if len(edgeClass.Attributes) > 0 {
+		doc.Graph.Attributes = append(doc.Graph.Attributes, edgeClass)
+	}
+
+	for i, node := range nodes {
+		n := gexfNode{ID: node.ID, Label: node.ID}
+		if node.Type != "" {
+			n.AttValues = append(n.AttValues, gexfAttValue{For: gexfTypeAttr, Value: node.Type})
+		}
+		n.AttValues = append(n.AttValues, gexfPropValues(nodeAttrs, node.Props)...)
+		doc.Graph.Nodes[i] = n
+	}
+
+	for i, edge := range edges {
+		doc.Graph.Edges[i] = gexfEdge{
+			ID:        strconv.Itoa(i),
+			Source:    edge.From,
+			Target:    edge.To,
+			Label:     edge.Label,
+			AttValues: gexfPropValues(edgeAttrs, edge.Props),
+		}
+	}
+
+	return writeXML(writer, doc)
+}
+
+// gexfPropValues returns the attribute values for the properties that are set
+func gexfPropValues(attrs []attribute, props map[string]graph.Value) []gexfAttValue {
+	var values []gexfAttValue
+	for _, attr := range attrs {
+		if value, ok := props[attr.Key]; ok {
+			values = append(values, gexfAttValue{For: attr.ID, Value: value.String()})
+		}
+	}
+	return values
+}
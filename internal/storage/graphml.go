@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+// GraphML key IDs for the built-in node type and edge label attributes
+const (
+	graphMLTypeKey  = "type"
+	graphMLLabelKey = "label"
+)
+
+// GraphMLExporter writes graphs as GraphML for tools such as yEd and Gephi.
+// Node types, edge labels and properties become typed data attributes.
+type GraphMLExporter struct{}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// NewGraphMLExporter creates a GraphML exporter
+func NewGraphMLExporter() *GraphMLExporter {
+	return &GraphMLExporter{}
+}
+
+// Export writes the graph to writer as GraphML
+func (e *GraphMLExporter) Export(ctx context.Context, g graph.Graph, writer io.Writer) error {
+	nodes, edges, err := sortedSnapshot(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	nodeAttrs := propertyAttributes("n", []string{graphMLTypeKey}, nodeProps(nodes))
+	edgeAttrs := propertyAttributes("e", []string{graphMLLabelKey}, edgeProps(edges))
+
+	doc := graphMLDocument{
+		XMLNS: graphMLNamespace,
+		Graph: graphMLGraph{
+			ID:          "G",
+			EdgeDefault: "directed",
+			Nodes:       make([]graphMLNode, len(nodes)),
+			Edges:       make([]graphMLEdge, len(edges)),
+		},
+	}
+
+	doc.Keys = append(doc.Keys, graphMLKey{ID: graphMLTypeKey, For: "node", Name: graphMLTypeKey, Type: attrString})
+	for _, attr := range nodeAttrs {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attr.ID, For: "node", Name: attr.Name, Type: attr.Type})
+	}
+	doc.Keys = append(doc.Keys, graphMLKey{ID: graphMLLabelKey, For: "edge", Name: graphMLLabelKey, Type: attrString})
+	for _, attr := range edgeAttrs {
+		doc.Keys = append(doc.Keys, graphMLKey{ID: attr.ID, For: "edge", Name: attr.Name, Type: attr.Type})
+	}
+
+	for i, node := range nodes {
+		n := graphMLNode{ID: node.ID}
+		if node.Type != "" {
+			n.Data = append(n.Data, graphMLData{Key: graphMLTypeKey, Value: node.Type})
+		}
+		n.Data = append(n.Data, graphMLPropData(nodeAttrs, node.Props)...)
+		doc.Graph.Nodes[i] = n
+	}
+
+	for i, edge := range edges {
+		e := graphMLEdge{
+			Source: edge.From,
+			Target: edge.To,
+			Data:   []graphMLData{{Key: graphMLLabelKey, Value: edge.Label}},
+		}
+		e.Data = append(e.Data, graphMLPropData(edgeAttrs, edge.Props)...)
+		doc.Graph.Edges[i] = e
+	}
+
+	return writeXML(writer, doc)
+}
+
+// graphMLPropData returns the data elements for the properties that are set
+func graphMLPropData(attrs []attribute, props map[string]graph.Value) []graphMLData {
+	var data []graphMLData
+	for _, attr := range attrs {
+		if value, ok := props[attr.Key]; ok {
+			data = append(data, graphMLData{Key: attr.ID, Value: value.String()})
+		}
+	}
+	return data
+}
+
+// writeXML writes doc as an indented XML document
+func writeXML(writer io.Writer, doc any) error {
+	w := bufio.NewWriter(writer)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush export: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// LoadPropertyIndexes returns the property index declarations persisted in a meta store
+func LoadPropertyIndexes(store MetaStore) ([]string, error) {
+	data, err := store.GetMeta(propertyIndexesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read property indexes: %w", err)
+	}
+	return decodePropertyIndexes(data)
+}
+
+// decodePropertyIndexes parses a stored property index declaration
+func decodePropertyIndexes(data []byte) ([]string, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode property indexes: %w", err)
+	}
+
+	return keys, nil
+}
+
+// SavePropertyIndexes persists property index declarations to a meta store
+func SavePropertyIndexes(store MetaStore, keys []string) error {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return fmt.Errorf("failed to encode property indexes: %w", err)
+	}
+
+	if err := store.PutMeta(propertyIndexesKey, data); err != nil {
+		return fmt.Errorf("failed to write property indexes: %w", err)
+	}
+
+	return nil
+}
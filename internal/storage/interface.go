@@ -42,6 +42,28 @@ type Transaction interface {
 	Rollback() error
 }
 
+// MetaStore provides access to backend metadata such as index declarations
+type MetaStore interface {
+	// GetMeta returns the value stored under key, or nil if it is not set
+	GetMeta(key string) ([]byte, error)
+
+	// PutMeta stores a value under key
+	PutMeta(key string, value []byte) error
+}
+
+// HistoryStore is implemented by backends that persist version history
+type HistoryStore interface {
+	// LoadHistory returns every recorded version in sequence order
+	LoadHistory() ([]graph.Version, error)
+}
+
+// HistoryTransaction is implemented by transactions that can record versions
+// alongside the changes they describe
+type HistoryTransaction interface {
+	// SaveVersion records a version of a node or edge
+	SaveVersion(version graph.Version) error
+}
+
 // Serializer handles conversion between graph objects and storage format
 type Serializer interface {
 	// Serialize converts a graph object to bytes
@@ -53,10 +75,15 @@ type Serializer interface {
 	DeserializeEdge(data []byte) (graph.Edge, error)
 }
 
-// Backup provides backup and restore functionality
-type Backup interface {
+// Exporter writes a graph in an interchange format
+type Exporter interface {
 	// Export exports the graph to a writer
 	Export(ctx context.Context, g graph.Graph, writer io.Writer) error
+}
+
+// Backup provides backup and restore functionality
+type Backup interface {
+	Exporter
 
 	// Import imports a graph from a reader
 	Import(ctx context.Context, reader io.Reader) (graph.Graph, error)
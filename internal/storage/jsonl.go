@@ -0,0 +1,251 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+const (
+	// JSONLFormat identifies RelatixDB JSON Lines exports in the header record
+	JSONLFormat = "relatixdb-jsonl"
+
+	// JSONLVersion is the format version written by JSONLBackup. Version 2
+	// added typed property values; version 1 files, whose values are all
+	// strings, still import.
+	JSONLVersion = 2
+)
+
+// JSON Lines record kinds
+const (
+	recordHeader = "header"
+	recordNode   = "node"
+	recordEdge   = "edge"
+)
+
+// JSONLBackup implements Backup using JSON Lines: a header record followed by
+// one record per node and one per edge, sorted so exports of the same graph
+// are byte-for-byte identical and diff cleanly
+type JSONLBackup struct {
+	// OnSchemaWarning, if set, receives each schema violation of a record
+	// imported under a schema in warn mode
+	OnSchemaWarning func(violation string)
+}
+
+// jsonlRecord is the union of every record kind, discriminated by Kind
+type jsonlRecord struct {
+	Kind string `json:"kind"`
+
+	// Header fields
+	Format  string `json:"format,omitempty"`
+	Version int    `json:"version,omitempty"`
+
+	// Node fields
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type,omitempty"`
+
+	// Edge fields
+	From  string `json:"from,omitempty"`
+	To    string `json:"to,omitempty"`
+	Label string `json:"label,omitempty"`
+
+	Props map[string]graph.Value `json:"props,omitempty"`
+}
+
+// NewJSONLBackup creates a JSON Lines backup handler
+func NewJSONLBackup() *JSONLBackup {
+	return &JSONLBackup{}
+}
+
+// Export writes the graph to writer in JSON Lines format
+func (b *JSONLBackup) Export(ctx context.Context, g graph.Graph, writer io.Writer) error {
+	nodes, edges, err := sortedSnapshot(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(writer)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(jsonlRecord{Kind: recordHeader, Format: JSONLFormat, Version: JSONLVersion}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, node := range nodes {
+		record := jsonlRecord{Kind: recordNode, ID: node.ID, Type: node.Type, Props: node.Props}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write node %s: %w", node.ID, err)
+		}
+	}
+
+	for _, edge := range edges {
+		record := jsonlRecord{Kind: recordEdge, From: edge.From, To: edge.To, Label: edge.Label, Props: edge.Props}
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write edge %s: %w", edgeKey(edge.From, edge.To, edge.Label), err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush export: %w", err)
+	}
+
+	return nil
+}
+
+// Import reads a JSON Lines export into a new in-memory graph. Edges are
+// added after all nodes, so records may appear in any order after the header.
+func (b *JSONLBackup) Import(ctx context.Context, reader io.Reader) (graph.Graph, error) {
+	memGraph := graph.NewMemoryGraph()
+	if err := b.ImportInto(ctx, reader, memGraph); err != nil {
+		return nil, err
+	}
+	return memGraph, nil
+}
+
+// ImportInto reads a JSON Lines export and adds its nodes and edges to g. If
+// g has a schema, records that violate it are rejected in strict mode and
+// reported to OnSchemaWarning in warn mode.
+func (b *JSONLBackup) ImportInto(ctx context.Context, reader io.Reader, g graph.Graph) error {
+	r := bufio.NewReader(reader)
+
+	var schema *graph.Schema
+	if provider, ok := g.(graph.SchemaProvider); ok {
+		schema = provider.Schema()
+	}
+
+	var (
+		edges     []graph.Edge
+		edgeLines []int
+		sawHeader bool
+	)
+
+	for lineNum := 1; ; lineNum++ {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			record, recordErr := parseRecord(line)
+			if recordErr != nil {
+				return fmt.Errorf("line %d: %w", lineNum, recordErr)
+			}
+
+			switch {
+			case record == nil:
+				// Blank line
+			case !sawHeader:
+				if record.Kind != recordHeader {
+					return fmt.Errorf("line %d: expected %s header record, got %q", lineNum, JSONLFormat, record.Kind)
+				}
+				if record.Format != JSONLFormat {
+					return fmt.Errorf("line %d: unsupported format %q", lineNum, record.Format)
+				}
+				if record.Version < 1 || record.Version > JSONLVersion {
+					return fmt.Errorf("line %d: unsupported format version %d (supported: %d)", lineNum, record.Version, JSONLVersion)
+				}
+				sawHeader = true
+			case record.Kind == recordNode:
+				node := graph.Node{ID: record.ID, Type: record.Type, Props: record.Props}
+				if schema != nil {
+					if err := b.enforce(schema, schema.CheckNode(node)); err != nil {
+						return fmt.Errorf("line %d: %w", lineNum, err)
+					}
+				}
+				if err := g.AddNode(ctx, node); err != nil {
+					return fmt.Errorf("line %d: failed to add node %s: %w", lineNum, node.ID, err)
+				}
+			case record.Kind == recordEdge:
+				edges = append(edges, graph.Edge{From: record.From, To: record.To, Label: record.Label, Props: record.Props})
+				edgeLines = append(edgeLines, lineNum)
+			default:
+				return fmt.Errorf("line %d: unknown record kind %q", lineNum, record.Kind)
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read import: %w", err)
+		}
+	}
+
+	if !sawHeader {
+		return fmt.Errorf("missing %s header record", JSONLFormat)
+	}
+
+	for i, edge := range edges {
+		if schema != nil {
+			// Missing endpoints are left for AddEdge to report
+			from, fromErr := g.GetNode(ctx, edge.From)
+			to, toErr := g.GetNode(ctx, edge.To)
+			if fromErr == nil && toErr == nil {
+				if err := b.enforce(schema, schema.CheckEdge(edge, from.Type, to.Type)); err != nil {
+					return fmt.Errorf("line %d: %w", edgeLines[i], err)
+				}
+			}
+		}
+		if err := g.AddEdge(ctx, edge); err != nil {
+			return fmt.Errorf("line %d: failed to add edge %s: %w", edgeLines[i], edgeKey(edge.From, edge.To, edge.Label), err)
+		}
+	}
+
+	return nil
+}
+
+// enforce rejects schema violations in strict mode and reports them to
+// OnSchemaWarning in warn mode
+func (b *JSONLBackup) enforce(schema *graph.Schema, violations []string) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	if schema.Strict() {
+		return fmt.Errorf("%w: %s", graph.ErrSchemaViolation, strings.Join(violations, "; "))
+	}
+	if b.OnSchemaWarning != nil {
+		for _, violation := range violations {
+			b.OnSchemaWarning(violation)
+		}
+	}
+	return nil
+}
+
+// parseRecord decodes one JSON Lines record, returning nil for blank lines
+func parseRecord(line []byte) (*jsonlRecord, error) {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return nil, nil
+	}
+
+	var record jsonlRecord
+	if err := json.Unmarshal(trimmed, &record); err != nil {
+		return nil, fmt.Errorf("invalid record: %w", err)
+	}
+	return &record, nil
+}
+
+// SortNodes orders nodes by ID for deterministic output
+func SortNodes(nodes []graph.Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// SortEdges orders edges by from, to and label for deterministic output
+func SortEdges(edges []graph.Edge) {
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Label < edges[j].Label
+	})
+}
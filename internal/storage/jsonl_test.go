@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+func TestJSONLBackup_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	g := graph.NewMemoryGraph()
+	nodes := []graph.Node{
+		{ID: "user:2", Type: "user", Props: map[string]graph.Value{
+			"name":   graph.StringValue("Bob"),
+			"age":    graph.IntValue(30),
+			"score":  graph.FloatValue(2),
+			"admin":  graph.BoolValue(false),
+			"tags":   graph.ListValue("ops", "db"),
+			"joined": graph.TimeValue(time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)),
+		}},
+		{ID: "user:1", Type: "user", Props: graph.StringProps(map[string]string{"name": "Alice <admin>", "note": "line1\nline2"})},
+		{ID: "doc:1"},
+	}
+	for _, node := range nodes {
+		if err := g.AddNode(ctx, node); err != nil {
+			t.Fatalf("Failed to add node: %v", err)
+		}
+	}
+	edges := []graph.Edge{
+		{From: "user:1", To: "user:2", Label: "follows", Props: graph.StringProps(map[string]string{"since": "2023"})},
+		{From: "user:1", To: "doc:1", Label: "wrote"},
+	}
+	for _, edge := range edges {
+		if err := g.AddEdge(ctx, edge); err != nil {
+			t.Fatalf("Failed to add edge: %v", err)
+		}
+	}
+
+	backup := NewJSONLBackup()
+
+	var buf bytes.Buffer
+	if err := backup.Export(ctx, g, &buf); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+	exported := buf.String()
+
+	lines := strings.Split(strings.TrimSuffix(exported, "\n"), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("Expected 6 lines (header, 3 nodes, 2 edges), got %d:\n%s", len(lines), exported)
+	}
+	if want := `{"kind":"header","format":"relatixdb-jsonl","version":2}`; lines[0] != want {
+		t.Errorf("Expected header %s, got %s", want, lines[0])
+	}
+	if want := `{"kind":"node","id":"user:2","type":"user","props":{"admin":false,"age":30,"joined":{"timestamp":"2024-03-01T09:30:00Z"},"name":"Bob","score":2.0,"tags":["ops","db"]}}`; lines[3] != want {
+		t.Errorf("Expected typed props %s, got %s", want, lines[3])
+	}
+	if want := `{"kind":"node","id":"doc:1"}`; lines[1] != want {
+		t.Errorf("Expected nodes sorted by ID, got first node %s", lines[1])
+	}
+
+	imported, err := backup.Import(ctx, strings.NewReader(exported))
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	node, err := imported.GetNode(ctx, "user:1")
+	if err != nil {
+		t.Fatalf("Failed to get imported node: %v", err)
+	}
+	if node.Props["name"].String() != "Alice <admin>" || node.Props["note"].String() != "line1\nline2" {
+		t.Errorf("Imported node props don't match: %v", node.Props)
+	}
+
+	node, err = imported.GetNode(ctx, "user:2")
+	if err != nil {
+		t.Fatalf("Failed to get imported node: %v", err)
+	}
+	for key, value := range nodes[0].Props {
+		if node.Props[key] != value {
+			t.Errorf("Expected imported %s to be the %s %s, got the %s %s", key, value.Kind(), value, node.Props[key].Kind(), node.Props[key])
+		}
+	}
+
+	edge, err := imported.GetEdge(ctx, "user:1", "user:2", "follows")
+	if err != nil {
+		t.Fatalf("Failed to get imported edge: %v", err)
+	}
+	if edge.Props["since"].String() != "2023" {
+		t.Errorf("Imported edge props don't match: %v", edge.Props)
+	}
+
+	// Exporting the imported graph must reproduce the same bytes
+	buf.Reset()
+	if err := backup.Export(ctx, imported, &buf); err != nil {
+		t.Fatalf("Failed to re-export: %v", err)
+	}
+	if buf.String() != exported {
+		t.Errorf("Re-export differs from original:\n%s\nvs\n%s", buf.String(), exported)
+	}
+}
+
+func TestJSONLBackup_ImportErrors(t *testing.T) {
+	ctx := context.Background()
+	backup := NewJSONLBackup()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty input",
+			input: "",
+			want:  "missing relatixdb-jsonl header",
+		},
+		{
+			name:  "missing header",
+			input: `{"kind":"node","id":"a"}` + "\n",
+			want:  "line 1: expected relatixdb-jsonl header",
+		},
+		{
+			name:  "newer version",
+			input: `{"kind":"header","format":"relatixdb-jsonl","version":99}` + "\n",
+			want:  "unsupported format version 99",
+		},
+		{
+			name:  "malformed record",
+			input: `{"kind":"header","format":"relatixdb-jsonl","version":1}` + "\n{not json}\n",
+			want:  "line 2: invalid record",
+		},
+		{
+			name:  "unknown kind",
+			input: `{"kind":"header","format":"relatixdb-jsonl","version":1}` + "\n" + `{"kind":"hyperedge"}` + "\n",
+			want:  `line 2: unknown record kind "hyperedge"`,
+		},
+		{
+			name: "dangling edge",
+			input: `{"kind":"header","format":"relatixdb-jsonl","version":1}` + "\n\n" +
+				`{"kind":"edge","from":"a","to":"b","label":"x"}` + "\n" +
+				`{"kind":"node","id":"a"}` + "\n",
+			want: "line 3: failed to add edge a:b:x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := backup.Import(ctx, strings.NewReader(tt.input))
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got nil", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestJSONLBackup_ImportInto(t *testing.T) {
+	ctx := context.Background()
+
+	g := graph.NewMemoryGraph()
+	if err := g.AddNode(ctx, graph.Node{ID: "existing"}); err != nil {
+		t.Fatalf("Failed to add node: %v", err)
+	}
+
+	// Edges may precede the nodes they reference, and the last line needs no newline
+	input := `{"kind":"header","format":"relatixdb-jsonl","version":1}` + "\n" +
+		`{"kind":"edge","from":"existing","to":"new","label":"links"}` + "\n" +
+		`{"kind":"node","id":"new","type":"page"}`
+
+	if err := NewJSONLBackup().ImportInto(ctx, strings.NewReader(input), g); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	if _, err := g.GetEdge(ctx, "existing", "new", "links"); err != nil {
+		t.Errorf("Expected imported edge: %v", err)
+	}
+}
+
+func TestJSONLBackup_ImportSchema(t *testing.T) {
+	ctx := context.Background()
+
+	input := `{"kind":"header","format":"relatixdb-jsonl","version":2}` + "\n" +
+		`{"kind":"node","id":"a","type":"page"}` + "\n" +
+		`{"kind":"node","id":"b","type":"page","props":{"color":"red"}}` + "\n" +
+		`{"kind":"edge","from":"a","to":"b","label":"cites"}` + "\n"
+
+	for _, mode := range []string{graph.SchemaStrict, graph.SchemaWarn} {
+		schema, err := graph.ParseSchema([]byte(`{"mode": "` + mode + `", "node_types": {"page": {}}, "edge_labels": {"links": {}}}`))
+		if err != nil {
+			t.Fatalf("Failed to parse schema: %v", err)
+		}
+		g := graph.NewMemoryGraph()
+		if err := g.SetSchema(schema); err != nil {
+			t.Fatalf("Failed to set schema: %v", err)
+		}
+
+		var warnings []string
+		backup := NewJSONLBackup()
+		backup.OnSchemaWarning = func(violation string) { warnings = append(warnings, violation) }
+		err = backup.ImportInto(ctx, strings.NewReader(input), g)
+
+		if mode == graph.SchemaStrict {
+			if !errors.Is(err, graph.ErrSchemaViolation) || !strings.HasPrefix(err.Error(), "line 3: ") {
+				t.Errorf("Expected a schema violation on line 3, got %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected a warn mode import to succeed, got %v", err)
+		}
+		want := []string{
+			`node "b": property color is not declared for type "page"`,
+			`edge "a" -[cites]-> "b": label "cites" is not declared`,
+		}
+		if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+			t.Errorf("Expected warnings %q, got %q", want, warnings)
+		}
+	}
+}
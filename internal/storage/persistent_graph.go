@@ -3,21 +3,35 @@ package storage
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
 	"time"
 
 	"github.com/dshills/RelatixDB/internal/graph"
 )
 
-// PersistentGraph wraps a memory graph with persistent storage
+// PersistentGraph wraps a memory graph with persistent storage. Without
+// auto-save every mutation is written through in its own transaction; with
+// auto-save mutations only touch memory and the whole graph is checkpointed
+// every saveInterval and on Close. Every mutation is also recorded as a
+// version in the graph's history, which backends implementing HistoryStore
+// persist alongside the data, and published to the graph's watchers.
 type PersistentGraph struct {
 	memory  graph.Graph
 	backend Backend
+	history *graph.History
+	pending []graph.Version // versions not yet persisted, only with auto-save
+	now     func() time.Time
+	feed    graph.Feed
 
 	// Auto-save configuration
 	autoSave     bool
 	saveInterval time.Duration
 	stopChan     chan struct{}
+	loopDone     sync.WaitGroup
+	closeOnce    sync.Once
+	closeErr     error
+	dirty        bool // unsaved in-memory changes, only tracked with auto-save
 	mu           sync.RWMutex
 }
 
@@ -26,7 +40,9 @@ func NewPersistentGraph(backend Backend, autoSave bool, saveInterval time.Durati
 	return &PersistentGraph{
 		memory:       graph.NewMemoryGraph(),
 		backend:      backend,
-		autoSave:     autoSave,
+		history:      graph.NewHistory(nil),
+		now:          time.Now,
+		autoSave:     autoSave && saveInterval > 0,
 		saveInterval: saveInterval,
 		stopChan:     make(chan struct{}),
 	}
@@ -42,53 +58,107 @@ func (pg *PersistentGraph) Load(ctx context.Context) error {
 		return fmt.Errorf("failed to load from storage: %w", err)
 	}
 
+	history := graph.NewHistory(nil)
+	if store, ok := pg.backend.(HistoryStore); ok {
+		versions, err := store.LoadHistory()
+		if err != nil {
+			return err
+		}
+		history = graph.NewHistory(versions)
+	}
+
 	pg.memory = memGraph
+	pg.history = history
+	pg.dirty = false
+	pg.pending = nil
 
 	// Start auto-save if enabled
 	if pg.autoSave {
+		pg.loopDone.Add(1)
 		go pg.autoSaveLoop()
 	}
 
 	return nil
 }
 
-// Save saves the graph to persistent storage
+// Save writes a full snapshot of the graph to persistent storage
 func (pg *PersistentGraph) Save(ctx context.Context) error {
-	pg.mu.RLock()
-	defer pg.mu.RUnlock()
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
 
-	return pg.backend.SaveGraph(ctx, pg.memory)
+	return pg.save(ctx)
 }
 
-// Close closes the persistent graph and saves if needed
+// save writes a snapshot and any pending versions and clears the dirty flag.
+// Callers must hold the write lock.
+func (pg *PersistentGraph) save(ctx context.Context) error {
+	if err := pg.backend.SaveGraph(ctx, pg.memory); err != nil {
+		return err
+	}
+	pg.dirty = false
+
+	if len(pg.pending) > 0 {
+		if err := pg.persistChanges(nil, pg.pending); err != nil {
+			return fmt.Errorf("failed to save history: %w", err)
+		}
+		pg.pending = nil
+	}
+	return nil
+}
+
+// unsaved reports whether there are changes a checkpoint would write.
+// Callers must hold the lock.
+func (pg *PersistentGraph) unsaved() bool {
+	return pg.dirty || len(pg.pending) > 0
+}
+
+// Close stops auto-save, writes a final checkpoint if there are unsaved
+// changes, and closes the backend. The graph owns the backend, so callers
+// must not close it themselves. Closing more than once returns the result of
+// the first call.
 func (pg *PersistentGraph) Close() error {
+	pg.closeOnce.Do(func() {
+		pg.closeErr = pg.close()
+	})
+	return pg.closeErr
+}
+
+// close does the work of Close
+func (pg *PersistentGraph) close() error {
+	// Stop auto-save before taking the lock so an in-flight save can finish
+	close(pg.stopChan)
+	pg.loopDone.Wait()
+
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
 
-	// Stop auto-save
-	if pg.autoSave {
-		close(pg.stopChan)
+	if pg.unsaved() {
+		if err := pg.save(context.Background()); err != nil {
+			pg.backend.Close()
+			return fmt.Errorf("final save failed: %w", err)
+		}
 	}
 
-	// Skip final save for now since SaveGraph is not implemented
-	// Individual operations are already persisted via transactions
-	// TODO: Implement final save when SaveGraph is complete
-
 	return pg.backend.Close()
 }
 
-// Auto-save loop runs in background
+// Auto-save loop runs in background, checkpointing only when there are changes
 func (pg *PersistentGraph) autoSaveLoop() {
+	defer pg.loopDone.Done()
+
 	ticker := time.NewTicker(pg.saveInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			ctx := context.Background()
-			if err := pg.Save(ctx); err != nil {
-				fmt.Printf("Auto-save failed: %v\n", err)
+			pg.mu.Lock()
+			if pg.unsaved() {
+				if err := pg.save(context.Background()); err != nil {
+					log.Printf("Auto-save failed: %v", err)
+				}
 			}
+			pg.mu.Unlock()
 		case <-pg.stopChan:
 			return
 		}
@@ -99,36 +169,18 @@ func (pg *PersistentGraph) autoSaveLoop() {
 
 // AddNode adds a node to the graph
 func (pg *PersistentGraph) AddNode(ctx context.Context, node graph.Node) error {
-	pg.mu.Lock()
-	defer pg.mu.Unlock()
-
-	// Add to memory first
-	if err := pg.memory.AddNode(ctx, node); err != nil {
+	if err := node.Validate(); err != nil {
 		return err
 	}
 
-	// Persist immediately for critical operations
-	tx, err := pg.backend.BeginTransaction()
-	if err != nil {
-		// Rollback memory change
-		pg.memory.DeleteNode(ctx, node.ID)
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	if err := tx.SaveNode(node); err != nil {
-		// Rollback memory change
-		pg.memory.DeleteNode(ctx, node.ID)
-		return fmt.Errorf("failed to persist node: %w", err)
-	}
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
 
-	if err := tx.Commit(); err != nil {
-		// Rollback memory change
-		pg.memory.DeleteNode(ctx, node.ID)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if pg.memory.NodeExists(ctx, node.ID) {
+		return graph.ErrNodeExists
 	}
 
-	return nil
+	return pg.apply(ctx, graph.BatchOp{Op: graph.OpAddNode, ID: node.ID, Type: node.Type, Props: node.Props})
 }
 
 // GetNode retrieves a node by ID
@@ -144,75 +196,93 @@ func (pg *PersistentGraph) DeleteNode(ctx context.Context, id string) error {
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
 
-	// Get node first to check if it exists
-	node, err := pg.memory.GetNode(ctx, id)
-	if err != nil {
-		return err
+	if !pg.memory.NodeExists(ctx, id) {
+		return graph.ErrNodeNotFound
 	}
 
-	// Begin transaction
-	tx, err := pg.backend.BeginTransaction()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
+	// Cascaded edge deletes are persisted in the same transaction
+	return pg.apply(ctx, graph.BatchOp{Op: graph.OpDeleteNode, ID: id})
+}
 
-	// Delete from memory first
-	if err := pg.memory.DeleteNode(ctx, id); err != nil {
-		return err
+// apply makes a single change through the batch path so it is recorded and,
+// without auto-save, persisted in one transaction, undoing the memory change
+// if the write fails. Callers must hold the write lock and check the
+// preconditions whose errors should reach the caller unwrapped.
+func (pg *PersistentGraph) apply(ctx context.Context, op graph.BatchOp) error {
+	batcher, ok := pg.memory.(graph.Batcher)
+	if !ok {
+		return fmt.Errorf("graph does not support batches")
 	}
 
-	// Persist deletion
-	if err := tx.DeleteNode(id); err != nil {
-		// Rollback memory change
-		pg.memory.AddNode(ctx, *node)
-		return fmt.Errorf("failed to persist node deletion: %w", err)
-	}
+	_, err := batcher.ApplyBatch(ctx, []graph.BatchOp{op}, pg.committer(ctx, nil))
+	return err
+}
+
+// committer returns the commit function for a batch: it runs commit, turns
+// the changes into history versions attributed to the context's actor,
+// either persists both or leaves them for the next checkpoint, and publishes
+// the changes. Callers must hold the write lock.
+func (pg *PersistentGraph) committer(ctx context.Context, commit func([]graph.Change) error) func([]graph.Change) error {
+	return func(changes []graph.Change) error {
+		if commit != nil {
+			if err := commit(changes); err != nil {
+				return err
+			}
+		}
 
-	// TODO: Also delete connected edges from storage
-	// This requires extending the interface to get all edges for a node
+		versions := pg.history.Prepare(changes, graph.ActorFromContext(ctx), pg.now())
 
-	if err := tx.Commit(); err != nil {
-		// Rollback memory change
-		pg.memory.AddNode(ctx, *node)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		if pg.autoSave {
+			pg.dirty = true
+			pg.pending = append(pg.pending, versions...)
+		} else if err := pg.persistChanges(changes, versions); err != nil {
+			return err
+		}
+
+		pg.history.Append(versions)
+		pg.feed.Publish(changes)
+		return nil
 	}
+}
 
-	return nil
+// Watch calls fn with the changes of every committed mutation until cancel
+// is called. Changes are published once written, or with auto-save once
+// applied in memory.
+func (pg *PersistentGraph) Watch(fn func([]graph.Change)) (cancel func()) {
+	return pg.feed.Watch(fn)
 }
 
-// AddEdge adds an edge to the graph
-func (pg *PersistentGraph) AddEdge(ctx context.Context, edge graph.Edge) error {
+// UpdateNode changes a node in place, keeping its edges
+func (pg *PersistentGraph) UpdateNode(ctx context.Context, id string, update graph.Update) error {
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
 
-	// Add to memory first
-	if err := pg.memory.AddEdge(ctx, edge); err != nil {
-		return err
+	if !pg.memory.NodeExists(ctx, id) {
+		return graph.ErrNodeNotFound
 	}
 
-	// Persist immediately
-	tx, err := pg.backend.BeginTransaction()
-	if err != nil {
-		// Rollback memory change
-		pg.memory.DeleteEdge(ctx, edge.From, edge.To, edge.Label)
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
+	return pg.apply(ctx, graph.BatchOp{
+		Op: graph.OpUpdateNode, ID: id, Type: update.Type, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+}
 
-	if err := tx.SaveEdge(edge); err != nil {
-		// Rollback memory change
-		pg.memory.DeleteEdge(ctx, edge.From, edge.To, edge.Label)
-		return fmt.Errorf("failed to persist edge: %w", err)
+// AddEdge adds an edge to the graph
+func (pg *PersistentGraph) AddEdge(ctx context.Context, edge graph.Edge) error {
+	if err := edge.Validate(); err != nil {
+		return err
 	}
 
-	if err := tx.Commit(); err != nil {
-		// Rollback memory change
-		pg.memory.DeleteEdge(ctx, edge.From, edge.To, edge.Label)
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	if !pg.memory.NodeExists(ctx, edge.From) || !pg.memory.NodeExists(ctx, edge.To) {
+		return graph.ErrNodeNotFound
+	}
+	if _, err := pg.memory.GetEdge(ctx, edge.From, edge.To, edge.Label); err == nil {
+		return graph.ErrEdgeExists
 	}
 
-	return nil
+	return pg.apply(ctx, graph.BatchOp{Op: graph.OpAddEdge, From: edge.From, To: edge.To, Label: edge.Label, Props: edge.Props})
 }
 
 // GetEdge retrieves an edge by from, to, and label
@@ -223,53 +293,137 @@ func (pg *PersistentGraph) GetEdge(ctx context.Context, from, to, label string)
 	return pg.memory.GetEdge(ctx, from, to, label)
 }
 
+// UpdateEdge changes an edge's properties in place
+func (pg *PersistentGraph) UpdateEdge(ctx context.Context, from, to, label string, update graph.Update) error {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	if _, err := pg.memory.GetEdge(ctx, from, to, label); err != nil {
+		return err
+	}
+
+	return pg.apply(ctx, graph.BatchOp{
+		Op: graph.OpUpdateEdge, From: from, To: to, Label: label, Props: update.Props, Remove: update.Remove, Mode: update.Mode,
+	})
+}
+
 // DeleteEdge removes an edge from the graph
 func (pg *PersistentGraph) DeleteEdge(ctx context.Context, from, to, label string) error {
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
 
-	// Get edge first to check if it exists
-	edge, err := pg.memory.GetEdge(ctx, from, to, label)
-	if err != nil {
+	if _, err := pg.memory.GetEdge(ctx, from, to, label); err != nil {
 		return err
 	}
 
-	// Begin transaction
+	return pg.apply(ctx, graph.BatchOp{Op: graph.OpDeleteEdge, From: from, To: to, Label: label})
+}
+
+// ApplyBatch applies a batch of mutations atomically in memory and, without
+// auto-save, persists the resulting changes in a single storage transaction.
+// A failed write rolls the in-memory batch back.
+func (pg *PersistentGraph) ApplyBatch(ctx context.Context, ops []graph.BatchOp, commit func([]graph.Change) error) ([]graph.BatchResult, error) {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	batcher, ok := pg.memory.(graph.Batcher)
+	if !ok {
+		return nil, fmt.Errorf("graph does not support batches")
+	}
+
+	return batcher.ApplyBatch(ctx, ops, pg.committer(ctx, commit))
+}
+
+// persistChanges writes a set of changes and the versions recording them in
+// a single transaction. Versions are dropped if the backend can't store them.
+func (pg *PersistentGraph) persistChanges(changes []graph.Change, versions []graph.Version) error {
 	tx, err := pg.backend.BeginTransaction()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Delete from memory first
-	if err := pg.memory.DeleteEdge(ctx, from, to, label); err != nil {
-		return err
+	for _, change := range changes {
+		switch {
+		case change.Node != nil && change.Deleted:
+			err = tx.DeleteNode(change.Node.ID)
+		case change.Node != nil:
+			err = tx.SaveNode(*change.Node)
+		case change.Edge != nil && change.Deleted:
+			err = tx.DeleteEdge(change.Edge.From, change.Edge.To, change.Edge.Label)
+		case change.Edge != nil:
+			err = tx.SaveEdge(*change.Edge)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to persist change: %w", err)
+		}
 	}
 
-	// Persist deletion
-	if err := tx.DeleteEdge(from, to, label); err != nil {
-		// Rollback memory change
-		pg.memory.AddEdge(ctx, *edge)
-		return fmt.Errorf("failed to persist edge deletion: %w", err)
+	if htx, ok := tx.(HistoryTransaction); ok {
+		for _, version := range versions {
+			if err := htx.SaveVersion(version); err != nil {
+				return fmt.Errorf("failed to persist version: %w", err)
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		// Rollback memory change
-		pg.memory.AddEdge(ctx, *edge)
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-// Query executes a graph query
+// Query executes a graph query, against a past state of the graph when
+// query.AsOf is set
 func (pg *PersistentGraph) Query(ctx context.Context, query graph.Query) (*graph.QueryResult, error) {
 	pg.mu.RLock()
 	defer pg.mu.RUnlock()
 
+	if query.AsOf != nil {
+		past, err := pg.history.StateAt(ctx, pg.memory, *query.AsOf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebuild graph as of %s: %w", query.AsOf.Format(time.RFC3339), err)
+		}
+		query.AsOf = nil
+		return past.Query(ctx, query)
+	}
+
 	return pg.memory.Query(ctx, query)
 }
 
+// NodeHistory returns the recorded versions of a node, oldest first
+func (pg *PersistentGraph) NodeHistory(ctx context.Context, id string) ([]graph.Version, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.history.NodeVersions(id), nil
+}
+
+// EdgeHistory returns the recorded versions of an edge, oldest first
+func (pg *PersistentGraph) EdgeHistory(ctx context.Context, from, to, label string) ([]graph.Version, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.history.EdgeVersions(from, to, label), nil
+}
+
+// AsOf returns a copy of the graph as it was at the given time
+func (pg *PersistentGraph) AsOf(ctx context.Context, at time.Time) (graph.Graph, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.history.StateAt(ctx, pg.memory, at)
+}
+
+// Changes returns the versions recorded after the given time, oldest first
+func (pg *PersistentGraph) Changes(ctx context.Context, since time.Time) ([]graph.Version, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.history.VersionsSince(since), nil
+}
+
 // NodeExists checks if a node exists in the graph
 func (pg *PersistentGraph) NodeExists(ctx context.Context, id string) bool {
 	pg.mu.RLock()
@@ -286,6 +440,118 @@ func (pg *PersistentGraph) GetNodesByType(ctx context.Context, nodeType string)
 	return pg.memory.GetNodesByType(ctx, nodeType)
 }
 
+// GetNodesByProperty returns all nodes whose property key equals value
+func (pg *PersistentGraph) GetNodesByProperty(ctx context.Context, key, value string) ([]graph.Node, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.memory.GetNodesByProperty(ctx, key, value)
+}
+
+// CreatePropertyIndex indexes a node property key in memory and persists the
+// declaration so the index is rebuilt on the next load
+func (pg *PersistentGraph) CreatePropertyIndex(key string) error {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	indexer, ok := pg.memory.(graph.PropertyIndexer)
+	if !ok {
+		return fmt.Errorf("graph does not support property indexes")
+	}
+
+	if err := indexer.CreatePropertyIndex(key); err != nil {
+		return err
+	}
+
+	if store, ok := pg.backend.(MetaStore); ok {
+		if err := SavePropertyIndexes(store, indexer.PropertyIndexes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PropertyIndexes returns the indexed property keys in sorted order
+func (pg *PersistentGraph) PropertyIndexes() []string {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if indexer, ok := pg.memory.(graph.PropertyIndexer); ok {
+		return indexer.PropertyIndexes()
+	}
+	return nil
+}
+
+// Schema returns the graph's schema, or nil if it has none
+func (pg *PersistentGraph) Schema() *graph.Schema {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if provider, ok := pg.memory.(graph.SchemaProvider); ok {
+		return provider.Schema()
+	}
+	return nil
+}
+
+// SetSchema replaces the graph's schema and persists it so it is restored on
+// the next load; nil removes it
+func (pg *PersistentGraph) SetSchema(schema *graph.Schema) error {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	provider, ok := pg.memory.(graph.SchemaProvider)
+	if !ok {
+		return fmt.Errorf("graph does not support schemas")
+	}
+
+	if err := provider.SetSchema(schema); err != nil {
+		return err
+	}
+
+	if store, ok := pg.backend.(MetaStore); ok {
+		if err := SaveSchema(store, schema); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Statistics returns a snapshot of the graph's cardinality statistics
+func (pg *PersistentGraph) Statistics() graph.Statistics {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if provider, ok := pg.memory.(graph.StatisticsProvider); ok {
+		return provider.Statistics()
+	}
+	return graph.Statistics{}
+}
+
+// Degree returns the number of edges of a node in a direction, counting only
+// the given labels when any are given
+func (pg *PersistentGraph) Degree(nodeID, direction string, labels ...string) int {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if provider, ok := pg.memory.(graph.StatisticsProvider); ok {
+		return provider.Degree(nodeID, direction, labels...)
+	}
+	return 0
+}
+
+// PropertyCount returns the number of nodes with an indexed property value
+func (pg *PersistentGraph) PropertyCount(key, value string) (int, bool) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	if provider, ok := pg.memory.(graph.StatisticsProvider); ok {
+		return provider.PropertyCount(key, value)
+	}
+	return 0, false
+}
+
 // GetNeighbors returns neighboring nodes in the specified direction
 func (pg *PersistentGraph) GetNeighbors(ctx context.Context, nodeID, direction string) ([]graph.Node, error) {
 	pg.mu.RLock()
@@ -294,6 +560,14 @@ func (pg *PersistentGraph) GetNeighbors(ctx context.Context, nodeID, direction s
 	return pg.memory.GetNeighbors(ctx, nodeID, direction)
 }
 
+// GetEdges returns the edges incident to a node in the specified direction
+func (pg *PersistentGraph) GetEdges(ctx context.Context, nodeID, direction string) ([]graph.Edge, error) {
+	pg.mu.RLock()
+	defer pg.mu.RUnlock()
+
+	return pg.memory.GetEdges(ctx, nodeID, direction)
+}
+
 // GetAllNodes returns all nodes in the graph
 func (pg *PersistentGraph) GetAllNodes(ctx context.Context) ([]graph.Node, error) {
 	pg.mu.RLock()
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// LoadSchema returns the schema persisted in a meta store, or nil if none is set
+func LoadSchema(store MetaStore) (*graph.Schema, error) {
+	data, err := store.GetMeta(schemaKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return decodeSchema(data)
+}
+
+// decodeSchema parses a stored schema; an unset or removed schema decodes to nil
+func decodeSchema(data []byte) (*graph.Schema, error) {
+	if data == nil || string(data) == "null" {
+		return nil, nil
+	}
+
+	schema, err := graph.ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schema: %w", err)
+	}
+	return schema, nil
+}
+
+// SaveSchema persists a schema to a meta store; nil removes it
+func SaveSchema(store MetaStore, schema *graph.Schema) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	if err := store.PutMeta(schemaKey, data); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,954 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// WALBackend implements the Backend interface as an append-only log. Every
+// committed transaction is appended to the log as one checksummed record and
+// the graph is rebuilt by replaying a snapshot followed by the log. Once the
+// log outgrows CompactThreshold it is folded into a new snapshot in the
+// background while writes continue on a fresh log.
+//
+// Each log starts with its generation, and a snapshot records the generation
+// of the first log it does not cover. SaveGraph writes a snapshot of a new
+// generation before emptying the log, so a log left behind by a crash in
+// between is recognized as already covered and skipped.
+//
+// On disk a database at path consists of:
+//
+//	path             the live log
+//	path.snapshot    the last compacted state
+//	path.compacting  a sealed log being folded into the snapshot
+type WALBackend struct {
+	// CompactThreshold is the log size in bytes that triggers a background
+	// compaction, once the log is also larger than the snapshot. 0 disables it.
+	CompactThreshold int64
+
+	path string
+
+	mu           sync.Mutex // guards the fields below
+	log          *os.File
+	logSize      int64
+	logStart     int64  // size of the live log's header, which holds no changes
+	generation   uint64 // generation of the live log
+	snapshotSize int64
+	nodeIDs      map[string]bool
+	edgeKeys     map[string]bool
+	meta         map[string][]byte
+	stats        Stats
+	compacting   bool // a background compaction is scheduled or running
+	closed       bool
+
+	compactMu   sync.Mutex // serializes compactions, snapshots and loads
+	compactions sync.WaitGroup
+}
+
+// WALTransaction buffers operations until Commit appends them as one record
+type WALTransaction struct {
+	backend *WALBackend
+	ops     []walOp
+	done    bool
+}
+
+// DefaultWALCompactThreshold is the log size that triggers compaction by default
+const DefaultWALCompactThreshold = 4 << 20
+
+const (
+	walMagic            = "RLXWAL01"
+	walSnapshotSuffix   = ".snapshot"
+	walCompactingSuffix = ".compacting"
+	walRecordHeaderLen  = 8       // payload length and CRC-32C, both big endian
+	walMaxRecordLen     = 1 << 30 // larger lengths can only come from corruption
+	walSnapshotChunk    = 1000    // operations per snapshot record
+)
+
+// Log operation kinds
+const (
+	walSaveNode    = "save_node"
+	walDeleteNode  = "delete_node"
+	walSaveEdge    = "save_edge"
+	walDeleteEdge  = "delete_edge"
+	walPutMeta     = "put_meta"
+	walSaveVersion = "save_version"
+	walGeneration  = "generation"
+)
+
+var (
+	walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// errWALDamaged marks a truncated or corrupt record
+	errWALDamaged = errors.New("damaged log record")
+)
+
+// walOp is a single logged mutation
+type walOp struct {
+	Op    string      `json:"op"`
+	Node  *graph.Node `json:"node,omitempty"`
+	Edge  *graph.Edge `json:"edge,omitempty"`
+	ID    string      `json:"id,omitempty"`
+	From  string      `json:"from,omitempty"`
+	To    string      `json:"to,omitempty"`
+	Label string      `json:"label,omitempty"`
+	Key   string      `json:"key,omitempty"`
+	Value []byte      `json:"value,omitempty"`
+
+	Version    *graph.Version `json:"version,omitempty"`
+	Generation uint64         `json:"generation,omitempty"`
+}
+
+// walState is the result of replaying snapshot and log records
+type walState struct {
+	nodes      map[string]graph.Node
+	edges      map[string]graph.Edge
+	meta       map[string][]byte
+	versions   []graph.Version
+	generation uint64 // logs of earlier generations are covered by the snapshot
+}
+
+// walLog describes a log file replayed by walState.readLog
+type walLog struct {
+	generation uint64 // 0 for logs written before generations were recorded
+	stale      bool   // covered by the snapshot, so its records were skipped
+	records    bool   // holds records besides its generation
+	start      int64  // size of the header, which holds no changes
+	valid      int64
+	size       int64
+}
+
+// NewWALBackend creates a new append-only log backend
+func NewWALBackend() *WALBackend {
+	return &WALBackend{
+		CompactThreshold: DefaultWALCompactThreshold,
+	}
+}
+
+// Open opens or creates a log database, finishing any compaction that was
+// interrupted and discarding a torn record left at the end of the log
+func (b *WALBackend) Open(path string) error {
+	b.path = path
+
+	// A leftover sealed log means a compaction was interrupted; fold it in first
+	if _, err := os.Stat(b.compactingPath()); err == nil {
+		if _, err := b.fold(); err != nil {
+			return fmt.Errorf("failed to finish interrupted compaction: %w", err)
+		}
+	}
+
+	state := newWALState()
+	snapshotSize, _, err := readWALFile(b.snapshotPath(), true, state.apply)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	live, err := state.readLog(path, false)
+	if err != nil {
+		return fmt.Errorf("failed to read log: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+
+	valid, logStart, generation := live.valid, live.start, live.generation
+	if live.stale {
+		log.Printf("Discarding %s, which the snapshot already holds", path)
+	} else if valid < live.size {
+		log.Printf("Discarding %d bytes of incomplete log records at the end of %s", live.size-valid, path)
+	}
+	if live.stale || !live.records {
+		// Start a log of the snapshot's generation
+		generation = state.generation
+		valid, err = resetWALFile(file, generation)
+		logStart = valid
+	} else if valid < live.size {
+		err = file.Truncate(valid)
+	}
+	if err == nil {
+		_, err = file.Seek(valid, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to prepare log: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.log = file
+	b.logSize = valid
+	b.logStart = logStart
+	b.generation = generation
+	b.snapshotSize = snapshotSize
+	b.closed = false
+	b.setKeys(state)
+	b.meta = state.meta
+
+	return nil
+}
+
+// Close waits for a running compaction and closes the log
+func (b *WALBackend) Close() error {
+	b.mu.Lock()
+	if b.log == nil || b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.compactions.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.log.Close()
+}
+
+// LoadGraph replays the snapshot and log into a new memory graph
+func (b *WALBackend) LoadGraph(ctx context.Context) (graph.Graph, error) {
+	b.compactMu.Lock()
+	defer b.compactMu.Unlock()
+
+	b.mu.Lock()
+	if err := b.checkOpen(); err != nil {
+		b.mu.Unlock()
+		return nil, err
+	}
+	state, err := b.replay()
+	b.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graph: %w", err)
+	}
+
+	memGraph := graph.NewMemoryGraph()
+
+	// Declare property indexes before loading so they are populated as nodes arrive
+	indexes, err := decodePropertyIndexes(state.meta[propertyIndexesKey])
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range indexes {
+		if err := memGraph.CreatePropertyIndex(key); err != nil {
+			return nil, fmt.Errorf("failed to create property index %s: %w", key, err)
+		}
+	}
+
+	schema, err := decodeSchema(state.meta[schemaKey])
+	if err != nil {
+		return nil, err
+	}
+	if err := memGraph.SetSchema(schema); err != nil {
+		return nil, fmt.Errorf("failed to set schema: %w", err)
+	}
+
+	for id, node := range state.nodes {
+		if err := memGraph.AddNode(ctx, node); err != nil {
+			return nil, fmt.Errorf("failed to add node %s: %w", id, err)
+		}
+	}
+	for key, edge := range state.edges {
+		if err := memGraph.AddEdge(ctx, edge); err != nil {
+			return nil, fmt.Errorf("failed to add edge %s: %w", key, err)
+		}
+	}
+
+	b.mu.Lock()
+	b.stats.LastLoaded = time.Now().Unix()
+	b.mu.Unlock()
+
+	return memGraph, nil
+}
+
+// SaveGraph replaces the database with a snapshot of g and empties the log.
+// Metadata such as index declarations and version history are preserved.
+func (b *WALBackend) SaveGraph(ctx context.Context, g graph.Graph) error {
+	nodes, err := g.GetAllNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get nodes: %w", err)
+	}
+
+	edges, err := g.GetAllEdges(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get edges: %w", err)
+	}
+
+	b.compactMu.Lock()
+	defer b.compactMu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	// Start from the stored state to carry its metadata and history over
+	state, err := b.replay()
+	if err != nil {
+		return fmt.Errorf("failed to save graph: %w", err)
+	}
+	state.nodes = make(map[string]graph.Node, len(nodes))
+	state.edges = make(map[string]graph.Edge, len(edges))
+	for _, node := range nodes {
+		state.nodes[node.ID] = node
+	}
+	for _, edge := range edges {
+		state.edges[edgeKey(edge.From, edge.To, edge.Label)] = edge
+	}
+
+	// The snapshot replaces rather than extends the logs, so it starts a new
+	// generation: if the log outlives it in a crash, the log is skipped
+	// instead of replayed on top
+	generation := b.generation + 1
+	state.generation = generation
+	size, err := b.writeSnapshot(state)
+	if err != nil {
+		return fmt.Errorf("failed to save graph: %w", err)
+	}
+	b.snapshotSize = size
+	b.generation = generation
+
+	// The snapshot now holds everything, so the logs start over
+	if err := os.Remove(b.compactingPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sealed log: %w", err)
+	}
+	logSize, err := resetWALFile(b.log, generation)
+	if err != nil {
+		return fmt.Errorf("failed to reset log: %w", err)
+	}
+
+	b.logSize = logSize
+	b.logStart = logSize
+	b.setKeys(state)
+	b.stats.LastSaved = time.Now().Unix()
+
+	return nil
+}
+
+// Compact folds the current log into the snapshot. Writes may continue while
+// it runs; they go to a fresh log.
+func (b *WALBackend) Compact() error {
+	b.compactMu.Lock()
+	defer b.compactMu.Unlock()
+
+	b.mu.Lock()
+	if err := b.checkOpen(); err != nil && !b.compacting {
+		b.mu.Unlock()
+		return err
+	}
+	if b.logSize <= b.logStart {
+		b.mu.Unlock()
+		return nil
+	}
+	err := b.sealLog()
+	b.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to seal log: %w", err)
+	}
+
+	size, err := b.fold()
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.snapshotSize = size
+	b.mu.Unlock()
+
+	return nil
+}
+
+// sealLog moves the live log aside for compaction and starts a new one.
+// Callers must hold compactMu and mu.
+func (b *WALBackend) sealLog() error {
+	if err := b.log.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(b.path, b.compactingPath()); err != nil {
+		// Keep appending to the old log
+		file, openErr := os.OpenFile(b.path, os.O_RDWR, 0600)
+		if openErr != nil {
+			return fmt.Errorf("%w (and failed to reopen log: %v)", err, openErr)
+		}
+		if _, seekErr := file.Seek(b.logSize, io.SeekStart); seekErr != nil {
+			file.Close()
+			return fmt.Errorf("%w (and failed to reopen log: %v)", err, seekErr)
+		}
+		b.log = file
+		return err
+	}
+
+	file, err := os.OpenFile(b.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	size, err := resetWALFile(file, b.generation)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := syncDir(b.path); err != nil {
+		file.Close()
+		return err
+	}
+
+	b.log = file
+	b.logSize = size
+	b.logStart = size
+	return nil
+}
+
+// fold replays the snapshot and the sealed log into a new snapshot of the
+// same generation and removes the sealed log. Callers must hold compactMu.
+func (b *WALBackend) fold() (int64, error) {
+	state := newWALState()
+	if _, _, err := readWALFile(b.snapshotPath(), true, state.apply); err != nil {
+		return 0, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if _, err := state.readLog(b.compactingPath(), true); err != nil {
+		return 0, fmt.Errorf("failed to read sealed log: %w", err)
+	}
+
+	size, err := b.writeSnapshot(state)
+	if err != nil {
+		return 0, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	// Replaying the sealed log again would be harmless, so a crash before
+	// this point only repeats work on the next open
+	if err := os.Remove(b.compactingPath()); err != nil {
+		return 0, fmt.Errorf("failed to remove sealed log: %w", err)
+	}
+	return size, syncDir(b.path)
+}
+
+// writeSnapshot atomically replaces the snapshot file with state
+func (b *WALBackend) writeSnapshot(state *walState) (int64, error) {
+	tmpPath := b.snapshotPath() + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmpPath)
+
+	w := bufio.NewWriter(file)
+	size, err := w.WriteString(walMagic)
+
+	var chunk []walOp
+	flush := func() {
+		if err != nil || len(chunk) == 0 {
+			return
+		}
+		var n int
+		n, err = writeWALRecord(w, chunk)
+		size += n
+		chunk = chunk[:0]
+	}
+	add := func(op walOp) {
+		if chunk = append(chunk, op); len(chunk) >= walSnapshotChunk {
+			flush()
+		}
+	}
+
+	add(walOp{Op: walGeneration, Generation: state.generation})
+	for key, value := range state.meta {
+		add(walOp{Op: walPutMeta, Key: key, Value: value})
+	}
+	for _, node := range state.nodes {
+		node := node
+		add(walOp{Op: walSaveNode, Node: &node})
+	}
+	for _, edge := range state.edges {
+		edge := edge
+		add(walOp{Op: walSaveEdge, Edge: &edge})
+	}
+	for i := range state.versions {
+		add(walOp{Op: walSaveVersion, Version: &state.versions[i]})
+	}
+	flush()
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.Rename(tmpPath, b.snapshotPath()); err != nil {
+		return 0, err
+	}
+	return int64(size), syncDir(b.path)
+}
+
+// replay reads the snapshot, any sealed log and the live log. Callers must
+// hold compactMu and mu so the files don't change underneath.
+func (b *WALBackend) replay() (*walState, error) {
+	state := newWALState()
+	if _, _, err := readWALFile(b.snapshotPath(), true, state.apply); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if _, err := state.readLog(b.compactingPath(), true); err != nil {
+		return nil, fmt.Errorf("failed to read sealed log: %w", err)
+	}
+	if _, err := state.readLog(b.path, true); err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+	return state, nil
+}
+
+// commit appends ops to the log as a single record and syncs it to disk
+func (b *WALBackend) commit(ops []walOp) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkOpen(); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(b.log)
+	n, err := writeWALRecord(w, ops)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = b.log.Sync()
+	}
+	if err != nil {
+		// Drop whatever part of the record made it out so the log stays clean
+		b.log.Truncate(b.logSize)
+		b.log.Seek(b.logSize, io.SeekStart)
+		return fmt.Errorf("failed to append to log: %w", err)
+	}
+
+	b.logSize += int64(n)
+	for _, op := range ops {
+		b.applyKeys(op)
+	}
+	b.stats.LastSaved = time.Now().Unix()
+
+	if b.CompactThreshold > 0 && !b.compacting && b.logSize >= b.CompactThreshold && b.logSize > b.snapshotSize {
+		b.compacting = true
+		b.compactions.Add(1)
+		go b.backgroundCompact()
+	}
+
+	return nil
+}
+
+// backgroundCompact runs a compaction triggered by log growth
+func (b *WALBackend) backgroundCompact() {
+	defer b.compactions.Done()
+
+	if err := b.Compact(); err != nil {
+		log.Printf("Log compaction failed: %v", err)
+	}
+
+	b.mu.Lock()
+	b.compacting = false
+	b.mu.Unlock()
+}
+
+// BeginTransaction starts a new transaction
+func (b *WALBackend) BeginTransaction() (Transaction, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	return &WALTransaction{backend: b}, nil
+}
+
+// SaveNode saves a node in the transaction
+func (wt *WALTransaction) SaveNode(node graph.Node) error {
+	return wt.add(walOp{Op: walSaveNode, Node: &node})
+}
+
+// DeleteNode deletes a node in the transaction
+func (wt *WALTransaction) DeleteNode(id string) error {
+	return wt.add(walOp{Op: walDeleteNode, ID: id})
+}
+
+// SaveEdge saves an edge in the transaction
+func (wt *WALTransaction) SaveEdge(edge graph.Edge) error {
+	return wt.add(walOp{Op: walSaveEdge, Edge: &edge})
+}
+
+// DeleteEdge deletes an edge in the transaction
+func (wt *WALTransaction) DeleteEdge(from, to, label string) error {
+	return wt.add(walOp{Op: walDeleteEdge, From: from, To: to, Label: label})
+}
+
+// SaveVersion records a version of a node or edge in the transaction
+func (wt *WALTransaction) SaveVersion(version graph.Version) error {
+	return wt.add(walOp{Op: walSaveVersion, Version: &version})
+}
+
+// Commit appends the transaction to the log
+func (wt *WALTransaction) Commit() error {
+	if wt.done {
+		return fmt.Errorf("transaction already closed")
+	}
+	wt.done = true
+	return wt.backend.commit(wt.ops)
+}
+
+// Rollback discards the transaction
+func (wt *WALTransaction) Rollback() error {
+	wt.done = true
+	wt.ops = nil
+	return nil
+}
+
+func (wt *WALTransaction) add(op walOp) error {
+	if wt.done {
+		return fmt.Errorf("transaction already closed")
+	}
+	wt.ops = append(wt.ops, op)
+	return nil
+}
+
+// LoadHistory returns every recorded version in sequence order
+func (b *WALBackend) LoadHistory() ([]graph.Version, error) {
+	b.compactMu.Lock()
+	defer b.compactMu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	state, err := b.replay()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	return state.versions, nil
+}
+
+// GetMeta returns the value stored under key, or nil if it is not set
+func (b *WALBackend) GetMeta(key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	if value, ok := b.meta[key]; ok {
+		return append([]byte{}, value...), nil
+	}
+	return nil, nil
+}
+
+// PutMeta stores a value under key
+func (b *WALBackend) PutMeta(key string, value []byte) error {
+	return b.commit([]walOp{{Op: walPutMeta, Key: key, Value: append([]byte{}, value...)}})
+}
+
+// GetStats returns a snapshot of storage statistics
+func (b *WALBackend) GetStats() (*Stats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.NodeCount = len(b.nodeIDs)
+	stats.EdgeCount = len(b.edgeKeys)
+	stats.DatabaseSize = b.snapshotSize + b.logSize
+	stats.TransactionLog = b.logSize
+	return &stats, nil
+}
+
+// checkOpen reports whether the backend can be used. Callers must hold mu.
+func (b *WALBackend) checkOpen() error {
+	if b.log == nil || b.closed {
+		return fmt.Errorf("database not opened")
+	}
+	return nil
+}
+
+// setKeys replaces the tracked node and edge keys with those of state. Callers must hold mu.
+func (b *WALBackend) setKeys(state *walState) {
+	b.nodeIDs = make(map[string]bool, len(state.nodes))
+	for id := range state.nodes {
+		b.nodeIDs[id] = true
+	}
+	b.edgeKeys = make(map[string]bool, len(state.edges))
+	for key := range state.edges {
+		b.edgeKeys[key] = true
+	}
+}
+
+// applyKeys tracks the keys and metadata changed by a committed op. Callers must hold mu.
+func (b *WALBackend) applyKeys(op walOp) {
+	switch op.Op {
+	case walSaveNode:
+		b.nodeIDs[op.Node.ID] = true
+	case walDeleteNode:
+		delete(b.nodeIDs, op.ID)
+	case walSaveEdge:
+		b.edgeKeys[edgeKey(op.Edge.From, op.Edge.To, op.Edge.Label)] = true
+	case walDeleteEdge:
+		delete(b.edgeKeys, edgeKey(op.From, op.To, op.Label))
+	case walPutMeta:
+		b.meta[op.Key] = op.Value
+	}
+}
+
+func (b *WALBackend) snapshotPath() string {
+	return b.path + walSnapshotSuffix
+}
+
+func (b *WALBackend) compactingPath() string {
+	return b.path + walCompactingSuffix
+}
+
+func newWALState() *walState {
+	return &walState{
+		nodes: make(map[string]graph.Node),
+		edges: make(map[string]graph.Edge),
+		meta:  make(map[string][]byte),
+	}
+}
+
+// readLog replays a log file on top of the snapshot read into s, skipping
+// it if the snapshot is of a later generation. A log must be read after the
+// snapshot.
+func (s *walState) readLog(path string, strict bool) (walLog, error) {
+	l := walLog{start: int64(len(walMagic))}
+	first := true
+	valid, size, err := readWALFile(path, strict, func(op walOp) error {
+		if first {
+			first = false
+			if op.Op == walGeneration {
+				l.generation = op.Generation
+				l.start = int64(len(walHeader(op.Generation)))
+			}
+			l.stale = l.generation < s.generation
+		}
+		if op.Op == walGeneration {
+			return nil
+		}
+		l.records = true
+		if l.stale {
+			return nil
+		}
+		return s.apply(op)
+	})
+	l.valid, l.size = valid, size
+	return l, err
+}
+
+// apply replays one logged operation. Saves and deletes are idempotent, so
+// replaying a record twice leaves the same state.
+func (s *walState) apply(op walOp) error {
+	switch op.Op {
+	case walSaveNode:
+		if op.Node == nil {
+			return fmt.Errorf("%w: %s without node", errWALDamaged, op.Op)
+		}
+		s.nodes[op.Node.ID] = *op.Node
+	case walDeleteNode:
+		delete(s.nodes, op.ID)
+	case walSaveEdge:
+		if op.Edge == nil {
+			return fmt.Errorf("%w: %s without edge", errWALDamaged, op.Op)
+		}
+		s.edges[edgeKey(op.Edge.From, op.Edge.To, op.Edge.Label)] = *op.Edge
+	case walDeleteEdge:
+		delete(s.edges, edgeKey(op.From, op.To, op.Label))
+	case walPutMeta:
+		s.meta[op.Key] = op.Value
+	case walGeneration:
+		s.generation = op.Generation
+	case walSaveVersion:
+		if op.Version == nil {
+			return fmt.Errorf("%w: %s without version", errWALDamaged, op.Op)
+		}
+		// A sealed log replayed on top of a snapshot that already holds it repeats versions
+		if n := len(s.versions); n == 0 || op.Version.Seq > s.versions[n-1].Seq {
+			s.versions = append(s.versions, *op.Version)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operation %q", errWALDamaged, op.Op)
+	}
+	return nil
+}
+
+// writeWALRecord writes ops as one length-prefixed, checksummed record
+func writeWALRecord(w io.Writer, ops []walOp) (int, error) {
+	payload, err := json.Marshal(ops)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode log record: %w", err)
+	}
+
+	var header [walRecordHeaderLen]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], crc32.Checksum(payload, walCRCTable))
+
+	n, err := w.Write(header[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.Write(payload)
+	return n + m, err
+}
+
+// readWALFile replays every record in a log file through fn and returns the
+// offset just past the last intact record along with the file size. A
+// missing file is empty. With strict set a damaged record is an error;
+// otherwise reading stops at the first damaged record, which is how a torn
+// final write shows up after a crash.
+func readWALFile(path string, strict bool, fn func(walOp) error) (valid, size int64, err error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	size = info.Size()
+
+	r := bufio.NewReader(file)
+	magic := make([]byte, len(walMagic))
+	if n, err := io.ReadFull(r, magic); err != nil {
+		// A file cut short inside the magic was never committed to
+		if n > 0 && string(magic[:n]) != walMagic[:n] {
+			return 0, size, fmt.Errorf("%s is not a RelatixDB log", path)
+		}
+		if strict && size > 0 {
+			return 0, size, fmt.Errorf("%s: %w: incomplete header", path, errWALDamaged)
+		}
+		return 0, size, nil
+	}
+	if string(magic) != walMagic {
+		return 0, size, fmt.Errorf("%s is not a RelatixDB log", path)
+	}
+	valid = int64(len(walMagic))
+
+	for {
+		ops, n, err := readWALRecord(r)
+		if err == io.EOF {
+			return valid, size, nil
+		}
+		if errors.Is(err, errWALDamaged) && !strict {
+			return valid, size, nil
+		}
+		if err != nil {
+			return valid, size, fmt.Errorf("%s at offset %d: %w", path, valid, err)
+		}
+
+		for _, op := range ops {
+			if err := fn(op); err != nil {
+				return valid, size, fmt.Errorf("%s at offset %d: %w", path, valid, err)
+			}
+		}
+		valid += int64(n)
+	}
+}
+
+// readWALRecord reads one record, returning io.EOF at a clean end of file
+// and errWALDamaged for a truncated or corrupt record
+func readWALRecord(r io.Reader) ([]walOp, int, error) {
+	var header [walRecordHeaderLen]byte
+	if n, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF && n == 0 {
+			return nil, 0, io.EOF
+		}
+		return nil, 0, fmt.Errorf("%w: truncated header", errWALDamaged)
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	checksum := binary.BigEndian.Uint32(header[4:8])
+	if length > walMaxRecordLen {
+		return nil, 0, fmt.Errorf("%w: implausible length %d", errWALDamaged, length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: truncated payload", errWALDamaged)
+	}
+	if crc32.Checksum(payload, walCRCTable) != checksum {
+		return nil, 0, fmt.Errorf("%w: checksum mismatch", errWALDamaged)
+	}
+
+	var ops []walOp
+	if err := json.Unmarshal(payload, &ops); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", errWALDamaged, err)
+	}
+	return ops, walRecordHeaderLen + int(length), nil
+}
+
+// resetWALFile empties a log file down to its header: the magic and a
+// record of the log's generation
+func resetWALFile(file *os.File, generation uint64) (int64, error) {
+	if err := file.Truncate(0); err != nil {
+		return 0, err
+	}
+
+	header := walHeader(generation)
+	if _, err := file.WriteAt(header, 0); err != nil {
+		return 0, err
+	}
+	if _, err := file.Seek(int64(len(header)), io.SeekStart); err != nil {
+		return 0, err
+	}
+	return int64(len(header)), file.Sync()
+}
+
+// walHeader returns the start of a log of the given generation
+func walHeader(generation uint64) []byte {
+	var header bytes.Buffer
+	header.WriteString(walMagic)
+	// Encoding a generation op cannot fail
+	writeWALRecord(&header, []walOp{{Op: walGeneration, Generation: generation}})
+	return header.Bytes()
+}
+
+// syncDir flushes directory entries so renames and new files survive a crash
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	// Not every platform can sync a directory; the rename itself has still happened
+	dir.Sync()
+	return nil
+}
@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dshills/RelatixDB/internal/graph"
+)
+
+// openTestWAL opens a log backend at path, failing the test on error
+func openTestWAL(t *testing.T, path string) *WALBackend {
+	t.Helper()
+
+	backend := NewWALBackend()
+	if err := backend.Open(path); err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	return backend
+}
+
+// saveTestNodes commits one node per ID, each in its own transaction
+func saveTestNodes(t *testing.T, backend *WALBackend, ids ...string) {
+	t.Helper()
+
+	for _, id := range ids {
+		tx, err := backend.BeginTransaction()
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+		if err := tx.SaveNode(graph.Node{ID: id, Props: graph.StringProps(map[string]string{"name": id})}); err != nil {
+			t.Fatalf("Failed to save node: %v", err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	}
+}
+
+// loadTestNodeIDs returns the IDs of the nodes a backend loads
+func loadTestNodeIDs(t *testing.T, backend Backend) []string {
+	t.Helper()
+
+	ctx := context.Background()
+	g, err := backend.LoadGraph(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load graph: %v", err)
+	}
+
+	nodes, _ := g.GetAllNodes(ctx)
+	SortNodes(nodes)
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+func TestWALBackend_TornTail(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.wal")
+
+	backend := openTestWAL(t, dbPath)
+	saveTestNodes(t, backend, "a", "b")
+	backend.Close()
+
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to stat log: %v", err)
+	}
+
+	// Simulate a crash halfway through appending the last record
+	if err := os.Truncate(dbPath, info.Size()-3); err != nil {
+		t.Fatalf("Failed to truncate log: %v", err)
+	}
+
+	backend = openTestWAL(t, dbPath)
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "a" {
+		t.Fatalf("Expected only the intact record to survive, got %v", ids)
+	}
+
+	// New writes land after the intact records, not after the torn bytes
+	saveTestNodes(t, backend, "c")
+	backend.Close()
+
+	backend = openTestWAL(t, dbPath)
+	defer backend.Close()
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "a,c" {
+		t.Fatalf("Expected a,c after reopening, got %v", ids)
+	}
+}
+
+func TestWALBackend_Corruption(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("checksum", func(t *testing.T) {
+		dbPath := filepath.Join(dir, "checksum.wal")
+		backend := openTestWAL(t, dbPath)
+		saveTestNodes(t, backend, "a", "b")
+		backend.Close()
+
+		// Flip a byte inside the last record's payload
+		data, err := os.ReadFile(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to read log: %v", err)
+		}
+		data[len(data)-2] ^= 0xff
+		if err := os.WriteFile(dbPath, data, 0600); err != nil {
+			t.Fatalf("Failed to write log: %v", err)
+		}
+
+		backend = openTestWAL(t, dbPath)
+		defer backend.Close()
+		if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "a" {
+			t.Fatalf("Expected the corrupt record to be dropped, got %v", ids)
+		}
+	})
+
+	t.Run("not a log", func(t *testing.T) {
+		dbPath := filepath.Join(dir, "other.db")
+		if err := os.WriteFile(dbPath, []byte("definitely not a log file"), 0600); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		err := NewWALBackend().Open(dbPath)
+		if err == nil || !strings.Contains(err.Error(), "not a RelatixDB log") {
+			t.Fatalf("Expected a foreign file to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("corrupt snapshot", func(t *testing.T) {
+		dbPath := filepath.Join(dir, "snapshot.wal")
+		backend := openTestWAL(t, dbPath)
+		saveTestNodes(t, backend, "a")
+		if err := backend.Compact(); err != nil {
+			t.Fatalf("Failed to compact: %v", err)
+		}
+		backend.Close()
+
+		// Unlike the log, a snapshot is never torn, so damage is an error
+		if err := os.Truncate(dbPath+walSnapshotSuffix, int64(len(walMagic))+3); err != nil {
+			t.Fatalf("Failed to truncate snapshot: %v", err)
+		}
+
+		if err := NewWALBackend().Open(dbPath); err == nil {
+			t.Fatalf("Expected a damaged snapshot to be reported")
+		}
+	})
+}
+
+func TestWALBackend_Compact(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.wal")
+	ctx := context.Background()
+
+	backend := openTestWAL(t, dbPath)
+	saveTestNodes(t, backend, "a", "b", "c")
+
+	tx, _ := backend.BeginTransaction()
+	tx.DeleteNode("b")
+	tx.SaveEdge(graph.Edge{From: "a", To: "c", Label: "links"})
+	tx.(HistoryTransaction).SaveVersion(graph.Version{Seq: 1, Action: graph.ActionDeleted, Node: &graph.Node{ID: "b"}})
+	tx.Commit()
+
+	if err := SavePropertyIndexes(backend, []string{"name"}); err != nil {
+		t.Fatalf("Failed to save property indexes: %v", err)
+	}
+
+	if err := backend.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+
+	stats, _ := backend.GetStats()
+	if stats.TransactionLog != int64(len(walHeader(0))) {
+		t.Fatalf("Expected an empty log after compaction, got %d bytes", stats.TransactionLog)
+	}
+	if stats.NodeCount != 2 || stats.EdgeCount != 1 {
+		t.Fatalf("Expected 2 nodes and 1 edge, got %+v", stats)
+	}
+	if _, err := os.Stat(dbPath + walCompactingSuffix); !os.IsNotExist(err) {
+		t.Fatalf("Expected the sealed log to be removed, got %v", err)
+	}
+
+	// Writes after compaction go to the fresh log on top of the snapshot
+	saveTestNodes(t, backend, "d")
+	backend.Close()
+
+	backend = openTestWAL(t, dbPath)
+	defer backend.Close()
+
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "a,c,d" {
+		t.Fatalf("Expected a,c,d, got %v", ids)
+	}
+
+	g, _ := backend.LoadGraph(ctx)
+	if _, err := g.GetEdge(ctx, "a", "c", "links"); err != nil {
+		t.Fatalf("Expected edge a->c to survive compaction")
+	}
+
+	nodes, err := g.GetNodesByProperty(ctx, "name", "d")
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("Expected the name index to survive compaction, got %v (%v)", nodes, err)
+	}
+
+	versions, err := backend.LoadHistory()
+	if err != nil || len(versions) != 1 || versions[0].Node.ID != "b" {
+		t.Fatalf("Expected history to survive compaction, got %v (%v)", versions, err)
+	}
+}
+
+func TestWALBackend_BackgroundCompaction(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.wal")
+
+	backend := openTestWAL(t, dbPath)
+	backend.CompactThreshold = 512
+
+	var ids []string
+	for i := 0; i < 50; i++ {
+		ids = append(ids, string(rune('a'+i%26))+strings.Repeat("x", i/26+1))
+	}
+	saveTestNodes(t, backend, ids...)
+
+	// Wait for a triggered compaction to write its snapshot; Close waits for any still running
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(dbPath + walSnapshotSuffix); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected log growth to trigger a compaction")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	backend.Close()
+
+	stats, _ := backend.GetStats()
+	if stats.TransactionLog >= stats.DatabaseSize {
+		t.Fatalf("Expected part of the database to live in the snapshot, got %+v", stats)
+	}
+
+	backend = openTestWAL(t, dbPath)
+	defer backend.Close()
+	if loaded := loadTestNodeIDs(t, backend); len(loaded) != len(ids) {
+		t.Fatalf("Expected %d nodes after compaction, got %d", len(ids), len(loaded))
+	}
+}
+
+func TestWALBackend_InterruptedCompaction(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.wal")
+
+	backend := openTestWAL(t, dbPath)
+	saveTestNodes(t, backend, "a")
+	if err := backend.Compact(); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	saveTestNodes(t, backend, "b")
+	backend.Close()
+
+	// Simulate a crash right after the log was sealed: the sealed log holds
+	// "b" and a new, empty live log has been started
+	if err := os.Rename(dbPath, dbPath+walCompactingSuffix); err != nil {
+		t.Fatalf("Failed to seal log: %v", err)
+	}
+	if err := os.WriteFile(dbPath, []byte(walMagic), 0600); err != nil {
+		t.Fatalf("Failed to create log: %v", err)
+	}
+
+	backend = openTestWAL(t, dbPath)
+	defer backend.Close()
+
+	if _, err := os.Stat(dbPath + walCompactingSuffix); !os.IsNotExist(err) {
+		t.Fatalf("Expected the interrupted compaction to be finished on open, got %v", err)
+	}
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "a,b" {
+		t.Fatalf("Expected a,b, got %v", ids)
+	}
+}
+
+func TestWALBackend_InterruptedSave(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.wal")
+	ctx := context.Background()
+
+	backend := openTestWAL(t, dbPath)
+	saveTestNodes(t, backend, "a", "b")
+
+	staleLog, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+
+	// Replace the graph, as an import does
+	g := graph.NewMemoryGraph()
+	g.AddNode(ctx, graph.Node{ID: "c"})
+	if err := backend.SaveGraph(ctx, g); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+	backend.Close()
+
+	// Simulate a crash after the snapshot was written but before the log was
+	// emptied: the old log must not be replayed on top of the snapshot
+	if err := os.WriteFile(dbPath, staleLog, 0600); err != nil {
+		t.Fatalf("Failed to restore log: %v", err)
+	}
+
+	backend = openTestWAL(t, dbPath)
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "c" {
+		t.Fatalf("Expected only the saved graph, got %v", ids)
+	}
+
+	// The stale log is replaced, so new writes are not skipped along with it
+	saveTestNodes(t, backend, "d")
+	backend.Close()
+
+	backend = openTestWAL(t, dbPath)
+	defer backend.Close()
+	if ids := loadTestNodeIDs(t, backend); strings.Join(ids, ",") != "c,d" {
+		t.Fatalf("Expected c,d after reopening, got %v", ids)
+	}
+}